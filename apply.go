@@ -0,0 +1,168 @@
+package nozzle
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotRegistered is returned by Registry.Apply when an OverrideOp names no registered Nozzle.
+var ErrNotRegistered = errors.New("nozzle: name not registered")
+
+// ErrInvalidOverride is returned by Registry.Apply when an OverrideOp cannot be applied as given.
+var ErrInvalidOverride = errors.New("nozzle: invalid override")
+
+// OverrideOp is one manual override of a batch applied with Registry.Apply.
+type OverrideOp struct {
+	// Name is the name the Nozzle is registered as.
+	Name string
+
+	// State is ForcedOpen or ForcedClosed to start an override, like ForceOpenFor and ForceCloseFor,
+	// or empty to end the Nozzle's override, like Unforce.
+	State State
+
+	// Duration is how long the override lasts.
+	// If zero, it never expires, like ForceOpen and ForceClose.
+	Duration time.Duration
+
+	// Reason is the reason given for the override, such as "payments incident INC-42".
+	Reason string
+}
+
+// String describes op for the audit record of its batch, such as "payments=forced-closed (INC-42)".
+func (op OverrideOp) String() string {
+	state := string(op.State)
+	if state == "" {
+		state = "unforced"
+	}
+
+	if op.Reason == "" {
+		return fmt.Sprintf("%s=%s", op.Name, state)
+	}
+
+	return fmt.Sprintf("%s=%s (%s)", op.Name, state, op.Reason)
+}
+
+// Apply applies a batch of manual overrides to the registered Nozzles, all or none of them.
+// Use it during incidents to force a group of Nozzles closed, or open, without a window where only some of them are.
+//
+// Every op is checked before any is applied: Apply returns ErrClosed if the Registry or one of the Nozzles is closed,
+// ErrNotRegistered if an op names no registered Nozzle, and ErrInvalidOverride if an op has an unknown State,
+// a negative Duration, or names a Nozzle another op already names. Nothing is applied when it returns one of them.
+// The Nozzles are locked together while the batch is applied, so no call sees some of the overrides without the others.
+//
+// Each Nozzle reports its own override to Options.OnEvent and Options.Audit, as if it had been forced on its own.
+// The batch as a whole is recorded once to RegistryOptions.Audit, with EventOverridesApplied.
+// An error from RegistryOptions.Audit is returned, though the overrides are already applied.
+//
+// Example:
+//
+//	err := registry.Apply([]nozzle.OverrideOp{
+//		{Name: "payments", State: nozzle.ForcedClosed, Duration: 15 * time.Minute, Reason: "INC-42 (alice)"},
+//		{Name: "refunds", State: nozzle.ForcedClosed, Duration: 15 * time.Minute, Reason: "INC-42 (alice)"},
+//	})
+func (r *Registry[T]) Apply(ops []OverrideOp) error {
+	r.mut.Lock()
+
+	nozzles, err := r.overridden(ops)
+	if err != nil {
+		r.mut.Unlock()
+
+		return err
+	}
+
+	for _, n := range nozzles {
+		n.mut.Lock()
+	}
+
+	// Checked with every Nozzle locked, so none can close between the check and the batch.
+	for _, n := range nozzles {
+		if !n.closed {
+			continue
+		}
+
+		for _, n := range nozzles {
+			n.mut.Unlock()
+		}
+
+		r.mut.Unlock()
+
+		return fmt.Errorf("%w: a Nozzle of the batch is closed", ErrClosed)
+	}
+
+	reports := make([]func(), 0, len(ops))
+
+	for i, n := range nozzles {
+		// Ending an override that is not there changes nothing, so there is nothing to report.
+		if op := ops[i]; op.State != "" || n.override != "" {
+			reports = append(reports, n.setOverride(op.State, op.Duration, op.Reason))
+		}
+	}
+
+	for _, n := range nozzles {
+		n.mut.Unlock()
+	}
+
+	r.mut.Unlock()
+
+	for _, report := range reports {
+		report()
+	}
+
+	if r.options.Audit == nil {
+		return nil
+	}
+
+	described := make([]string, 0, len(ops))
+	for _, op := range ops {
+		described = append(described, op.String())
+	}
+
+	record := AuditEntry{
+		Event: Event{
+			Type:   EventOverridesApplied,
+			Time:   r.now(),
+			Reason: strings.Join(described, ", "),
+		},
+		Actor: ActorOperator,
+	}
+
+	if err := r.options.Audit.Audit(record); err != nil {
+		return fmt.Errorf("nozzle: overrides applied, but not audited: %w", err)
+	}
+
+	return nil
+}
+
+// overridden checks ops, and returns the Nozzle each one names, in order.
+// The caller must hold the lock.
+func (r *Registry[T]) overridden(ops []OverrideOp) ([]*Nozzle[T], error) {
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	nozzles := make([]*Nozzle[T], 0, len(ops))
+	named := make(map[string]bool, len(ops))
+
+	for _, op := range ops {
+		e, ok := r.nozzles[op.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrNotRegistered, op.Name)
+		}
+
+		switch {
+		case op.State != "" && op.State != ForcedOpen && op.State != ForcedClosed:
+			return nil, fmt.Errorf("%w: %q has state %q, want %q, %q, or empty", ErrInvalidOverride, op.Name, op.State, ForcedOpen, ForcedClosed)
+		case op.Duration < 0:
+			return nil, fmt.Errorf("%w: %q has a negative duration %s", ErrInvalidOverride, op.Name, op.Duration)
+		case named[op.Name]:
+			return nil, fmt.Errorf("%w: %q is named more than once", ErrInvalidOverride, op.Name)
+		}
+
+		named[op.Name] = true
+		nozzles = append(nozzles, entry[T](e).nozzle)
+	}
+
+	return nozzles, nil
+}
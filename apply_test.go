@@ -0,0 +1,88 @@
+package nozzle_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestRegistryApply(t *testing.T) {
+	t.Parallel()
+
+	audit := &auditLog{}
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{Audit: audit})
+	defer registry.Close() //nolint:errcheck
+
+	options := nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	}
+
+	a, _ := registry.New("a", options)
+	b, _ := registry.New("b", options)
+
+	if err := registry.Apply([]nozzle.OverrideOp{
+		{Name: "a", State: nozzle.ForcedClosed, Reason: "INC-42"},
+		{Name: "b", State: nozzle.ForcedClosed, Duration: time.Hour, Reason: "INC-42"},
+	}); err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	if a.State() != nozzle.ForcedClosed || b.State() != nozzle.ForcedClosed {
+		t.Errorf("Expected both States=%s Got a=%s b=%s", nozzle.ForcedClosed, a.State(), b.State())
+	}
+
+	if s := b.Snapshot(); s.OverrideReason != "INC-42" {
+		t.Errorf("Expected OverrideReason=INC-42 Got=%+v", s)
+	}
+
+	audit.mut.Lock()
+
+	if len(audit.entries) != 1 || audit.entries[0].Type != nozzle.EventOverridesApplied || audit.entries[0].Reason != "a=forced-closed (INC-42), b=forced-closed (INC-42)" {
+		t.Errorf("Expected one %s entry for the batch Got=%+v", nozzle.EventOverridesApplied, audit.entries)
+	}
+
+	audit.mut.Unlock()
+
+	// Each batch fails as a whole, so a stays closed.
+	for i, test := range []struct {
+		ops []nozzle.OverrideOp
+		err error
+	}{
+		{ops: []nozzle.OverrideOp{{Name: "a"}, {Name: "c"}}, err: nozzle.ErrNotRegistered},
+		{ops: []nozzle.OverrideOp{{Name: "a"}, {Name: "b", State: nozzle.Opening}}, err: nozzle.ErrInvalidOverride},
+		{ops: []nozzle.OverrideOp{{Name: "a"}, {Name: "b", State: nozzle.ForcedOpen, Duration: -time.Second}}, err: nozzle.ErrInvalidOverride},
+		{ops: []nozzle.OverrideOp{{Name: "a"}, {Name: "a", State: nozzle.ForcedOpen}}, err: nozzle.ErrInvalidOverride},
+	} {
+		if err := registry.Apply(test.ops); !errors.Is(err, test.err) {
+			t.Errorf("test=%d Expected err=%v Got=%v", i, test.err, err)
+		}
+
+		if s := a.State(); s != nozzle.ForcedClosed {
+			t.Errorf("test=%d Expected State=%s Got=%s", i, nozzle.ForcedClosed, s)
+		}
+	}
+
+	// A Nozzle closed without the Registry knowing fails the batch too.
+	b.Close() //nolint:errcheck
+
+	if err := registry.Apply([]nozzle.OverrideOp{{Name: "a"}, {Name: "b"}}); !errors.Is(err, nozzle.ErrClosed) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrClosed, err)
+	}
+
+	if s := a.State(); s != nozzle.ForcedClosed {
+		t.Errorf("Expected State=%s Got=%s", nozzle.ForcedClosed, s)
+	}
+
+	// An empty State ends the override.
+	if err := registry.Apply([]nozzle.OverrideOp{{Name: "a"}}); err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	if s := a.State(); s != nozzle.Opening {
+		t.Errorf("Expected State=%s Got=%s", nozzle.Opening, s)
+	}
+}
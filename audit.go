@@ -0,0 +1,44 @@
+package nozzle
+
+// Actors recorded in AuditEntry.Actor.
+const (
+	// ActorNozzle means the Nozzle made the change on its own, such as adapting the flow rate or expiring an override.
+	ActorNozzle = "nozzle"
+
+	// ActorOperator means the change was made through a method call, such as ForceCloseFor or Pause.
+	ActorOperator = "operator"
+)
+
+// AuditEntry records a change to how a Nozzle sheds traffic, see Options.Audit.
+type AuditEntry struct {
+	// Event describes the change.
+	// Its Type is EventFlowRateChanged for decisions the Nozzle made on its own.
+	Event
+
+	// Actor is ActorNozzle or ActorOperator.
+	// Include who the operator was in the reason given to the override, such as "INC-42 (alice)".
+	Actor string
+
+	// FlowRate is the flow rate callers experience after the change.
+	FlowRate int64
+}
+
+// AuditSink records AuditEntries, see Options.Audit.
+// The nozzleaudit package provides a file-based AuditSink that chains entries with hashes, so tampering is detectable.
+type AuditSink interface {
+	Audit(AuditEntry) error
+}
+
+// audit records a change to Options.Audit, if set.
+// Failures are logged, since the change has already happened.
+// The caller must not hold the lock.
+func (n *Nozzle[T]) audit(actor string, e Event, flowRate int64) {
+	if n.options().Audit == nil {
+		return
+	}
+
+	err := n.options().Audit.Audit(AuditEntry{Event: e, Actor: actor, FlowRate: flowRate})
+	if err != nil && n.options().Logger != nil {
+		n.options().Logger.Warn("nozzle: could not audit change", "type", e.Type, "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package nozzle_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// A best-effort Nozzle combines DisableClosing with MaxConcurrent.
+// These tests pin down the behavior that combination promises.
+
+func TestBestEffortNeverCloses(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("analytics unavailable")
+
+	var buf bytes.Buffer
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 100,
+		DisableClosing:        true,
+		MaxConcurrent:         4,
+		Logger:                slog.New(slog.NewTextHandler(&buf, nil)),
+	})
+	defer noz.Close() //nolint:errcheck
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warnings for a best-effort Nozzle Got=%s", buf.String())
+	}
+
+	for range 5 {
+		for range 100 {
+			if _, err := noz.DoError(func() (any, error) {
+				return nil, errUnavailable
+			}); errors.Is(err, nozzle.ErrBlocked) {
+				t.Fatal("Expected a best-effort Nozzle never to block sequential calls")
+			}
+		}
+
+		snapshot, err := noz.WaitSnapshot(context.Background())
+		if err != nil {
+			t.Fatalf("Expected err=nil Got=%v", err)
+		}
+
+		if snapshot.FlowRate != 100 || snapshot.State == nozzle.Closing {
+			t.Errorf("Expected FlowRate=100 and not closing Got FlowRate=%d State=%s", snapshot.FlowRate, snapshot.State)
+		}
+
+		if snapshot.FailureRate != 100 {
+			t.Errorf("Expected failures to still be reported, FailureRate=100 Got=%d", snapshot.FailureRate)
+		}
+	}
+}
+
+func TestBestEffortCapsPileUps(t *testing.T) {
+	t.Parallel()
+
+	const maxConcurrent = 4
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:       time.Hour,
+		DisableClosing: true,
+		MaxConcurrent:  maxConcurrent,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	var running, peak, blocked atomic.Int64
+
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	for range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := noz.DoErrorContext(context.Background(), func(context.Context) (any, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+
+				for {
+					p := peak.Load()
+					if current <= p || peak.CompareAndSwap(p, current) {
+						break
+					}
+				}
+
+				// A slow dependency: hold the slot until released.
+				<-release
+
+				return nil, nil
+			})
+
+			if errors.Is(err, nozzle.ErrBlocked) {
+				blocked.Add(1)
+			}
+		}()
+	}
+
+	// Wait until the slots are full and every other call has been turned away.
+	for blocked.Load() < 50-maxConcurrent {
+		time.Sleep(time.Millisecond)
+	}
+
+	close(release)
+	wg.Wait()
+
+	if p := peak.Load(); p > maxConcurrent {
+		t.Errorf("Expected at most %d calls at once Got=%d", maxConcurrent, p)
+	}
+
+	stats := noz.Stats()
+
+	if stats.BulkheadBlocked != 50-maxConcurrent || stats.Blocked != 0 {
+		t.Errorf("Expected BulkheadBlocked=%d Blocked=0 Got BulkheadBlocked=%d Blocked=%d", 50-maxConcurrent, stats.BulkheadBlocked, stats.Blocked)
+	}
+
+	if _, err := noz.DoError(func() (any, error) {
+		return nil, nil
+	}); err != nil {
+		t.Errorf("Expected freed slots to admit calls again, err=nil Got=%v", err)
+	}
+}
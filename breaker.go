@@ -0,0 +1,71 @@
+package nozzle
+
+// BreakerState is the state of a classic circuit breaker.
+// See nozzle.Breaker for how a Nozzle's flow rate maps onto it.
+type BreakerState string
+
+const (
+	// BreakerClosed means every call is allowed.
+	BreakerClosed BreakerState = "closed"
+
+	// BreakerHalfOpen means some calls are allowed.
+	BreakerHalfOpen BreakerState = "half-open"
+
+	// BreakerOpen means every call is blocked.
+	BreakerOpen BreakerState = "open"
+)
+
+// Breaker exposes a Nozzle through the API of a classic circuit breaker, such as gobreaker or hystrix.
+// It lets you migrate call sites from those libraries with minimal changes, and adopt the Nozzle's gradual semantics later.
+//
+// A circuit breaker is all-or-nothing, while a Nozzle allows a percentage of calls.
+// State maps the flow rate back onto the three classic states: 100 is BreakerClosed, 0 is BreakerOpen, and anything in between is BreakerHalfOpen.
+// Note that a half-open Breaker allows a percentage of calls, instead of a single trial call.
+//
+// Example:
+//
+//	// Before: cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{Name: "payments"})
+//	cb := nozzle.NewBreaker(nozzle.New(nozzle.Options[any]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//	}))
+//
+//	res, err := cb.Execute(func() (any, error) {
+//		return charge(ctx, order)
+//	})
+//	if errors.Is(err, nozzle.ErrBlocked) {
+//		// the equivalent of gobreaker.ErrOpenState.
+//	}
+type Breaker[T any] struct {
+	nozzle *Nozzle[T]
+}
+
+// NewBreaker creates a Breaker backed by n.
+func NewBreaker[T any](n *Nozzle[T]) *Breaker[T] {
+	return &Breaker[T]{nozzle: n}
+}
+
+// Execute runs callback if the Breaker allows it, and counts a non-nil error as a failure.
+// A blocked call returns an error that matches ErrBlocked, without running callback.
+// It is DoError under the name circuit breakers use.
+func (b *Breaker[T]) Execute(callback func() (T, error)) (T, error) {
+	return b.nozzle.DoError(callback)
+}
+
+// State reports the Breaker's state, derived from the Nozzle's flow rate.
+// A manual override is reported by the flow rate it forces: ForcedOpen is BreakerClosed, and ForcedClosed is BreakerOpen.
+func (b *Breaker[T]) State() BreakerState {
+	switch b.nozzle.FlowRate() {
+	case 100:
+		return BreakerClosed
+	case 0:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// Nozzle returns the Nozzle behind the Breaker, for call sites that are ready to use it directly.
+func (b *Breaker[T]) Nozzle() *Nozzle[T] {
+	return b.nozzle
+}
@@ -0,0 +1,50 @@
+package nozzle_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestBreaker(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[int]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	cb := nozzle.NewBreaker(noz)
+
+	if cb.Nozzle() != noz {
+		t.Error("Expected Nozzle to return the Breaker's Nozzle")
+	}
+
+	if s := cb.State(); s != nozzle.BreakerClosed {
+		t.Errorf("Expected State=%s Got=%s", nozzle.BreakerClosed, s)
+	}
+
+	res, err := cb.Execute(func() (int, error) {
+		return 42, nil
+	})
+	if res != 42 || err != nil {
+		t.Errorf("Expected res=42 err=nil Got res=%d err=%v", res, err)
+	}
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	if s := cb.State(); s != nozzle.BreakerOpen {
+		t.Errorf("Expected State=%s Got=%s", nozzle.BreakerOpen, s)
+	}
+
+	if _, err := cb.Execute(func() (int, error) {
+		t.Error("Expected an open Breaker not to run the callback")
+
+		return 0, nil
+	}); !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrBlocked, err)
+	}
+}
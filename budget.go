@@ -0,0 +1,77 @@
+package nozzle
+
+import "reflect"
+
+// intervalSize is the estimated number of bytes one IntervalStats holds in memory.
+var intervalSize = int64(reflect.TypeFor[IntervalStats]().Size())
+
+// RuntimeStats describes the memory held by a Nozzle's optional features.
+// See Options.MemoryBudget.
+type RuntimeStats struct {
+	// MemoryUsage is the estimated number of bytes held by History and by intervals waiting for Options.OnIntervalEnd.
+	// Each interval is counted at the size of an IntervalStats.
+	MemoryUsage int64
+
+	// MemoryBudget is Options.MemoryBudget.
+	MemoryBudget int64
+
+	// HistoryIntervals is the number of intervals held by History.
+	HistoryIntervals int
+
+	// PendingIntervals is the number of intervals waiting to be delivered to Options.OnIntervalEnd.
+	PendingIntervals int
+
+	// TrimmedIntervals is the number of History intervals dropped to stay within MemoryBudget since the Nozzle was created.
+	TrimmedIntervals int64
+}
+
+// RuntimeStats reports the estimated memory held by the Nozzle's optional features.
+// Use it to bound what enabling History or OnIntervalEnd costs across a fleet of Nozzles.
+//
+// Example:
+//
+//	if s := n.RuntimeStats(); s.TrimmedIntervals > 0 {
+//		slog.Info("nozzle history trimmed", "usage", s.MemoryUsage, "budget", s.MemoryBudget)
+//	}
+func (n *Nozzle[T]) RuntimeStats() RuntimeStats {
+	if n.passThrough() {
+		return RuntimeStats{}
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return RuntimeStats{
+		MemoryUsage:      n.memoryUsage(),
+		MemoryBudget:     n.options().MemoryBudget,
+		HistoryIntervals: len(n.history),
+		PendingIntervals: len(n.pending),
+		TrimmedIntervals: n.trimmed,
+	}
+}
+
+// memoryUsage estimates the bytes held by History and pending intervals.
+// The caller must hold the lock.
+func (n *Nozzle[T]) memoryUsage() int64 {
+	return int64(len(n.history)+len(n.pending)) * intervalSize
+}
+
+// trim drops the oldest History intervals until the Nozzle is within Options.MemoryBudget, or History is empty.
+// Pending intervals are never dropped, since OnIntervalEnd promises to deliver every interval.
+// The caller must hold the lock.
+func (n *Nozzle[T]) trim() {
+	if n.options().MemoryBudget <= 0 {
+		return
+	}
+
+	over := n.memoryUsage() - n.options().MemoryBudget
+	if over <= 0 {
+		return
+	}
+
+	// Round up, so a partial interval over budget drops a whole one.
+	drop := int(min((over+intervalSize-1)/intervalSize, int64(len(n.history))))
+
+	n.history = append(n.history[:0], n.history[drop:]...)
+	n.trimmed += int64(drop)
+}
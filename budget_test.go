@@ -0,0 +1,58 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		MemoryBudget:          3 * intervalSize,
+	}, 100)
+
+	for range 5 {
+		noz.calculate()
+	}
+
+	s := noz.RuntimeStats()
+
+	if s.HistoryIntervals != 3 || s.TrimmedIntervals != 2 {
+		t.Errorf("Expected HistoryIntervals=3 TrimmedIntervals=2 Got HistoryIntervals=%d TrimmedIntervals=%d", s.HistoryIntervals, s.TrimmedIntervals)
+	}
+
+	if s.MemoryUsage != 3*intervalSize || s.MemoryBudget != 3*intervalSize {
+		t.Errorf("Expected MemoryUsage=MemoryBudget=%d Got MemoryUsage=%d MemoryBudget=%d", 3*intervalSize, s.MemoryUsage, s.MemoryBudget)
+	}
+}
+
+func TestMemoryBudgetPending(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("billing unavailable")
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		MemoryBudget:          3 * intervalSize,
+		OnIntervalEnd: func(IntervalStats) error {
+			return errUnavailable
+		},
+	}, 100)
+
+	for range 4 {
+		noz.calculate()
+	}
+
+	// Undelivered intervals are never dropped, so History gives way to them and the budget is exceeded.
+	s := noz.RuntimeStats()
+
+	if s.PendingIntervals != 4 || s.HistoryIntervals != 0 {
+		t.Errorf("Expected PendingIntervals=4 HistoryIntervals=0 Got PendingIntervals=%d HistoryIntervals=%d", s.PendingIntervals, s.HistoryIntervals)
+	}
+
+	if s.MemoryUsage != 4*intervalSize {
+		t.Errorf("Expected MemoryUsage=%d Got=%d", 4*intervalSize, s.MemoryUsage)
+	}
+}
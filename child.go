@@ -0,0 +1,167 @@
+package nozzle
+
+import (
+	"context"
+	"sync"
+)
+
+// ChildOptions are a Child's own admission rules, applied on top of its parent's flow rate.
+// The zero ChildOptions admits calls exactly like the parent does.
+type ChildOptions struct {
+	// Priority is the priority of the Child's calls, unless their context already has one, see nozzle.WithPriority.
+	// Example:
+	//
+	//	Priority: nozzle.PriorityLow // Batch traffic is shed before interactive traffic
+	Priority Priority
+
+	// MinFlowRate blocks every call of the Child while the parent's flow rate is below it.
+	// Example:
+	//
+	//	MinFlowRate: 80 // Only run while the dependency is nearly healthy
+	MinFlowRate int64
+
+	// MaxConcurrent caps how many of the Child's calls may run at once, in addition to the parent's Options.MaxConcurrent.
+	// If zero, only the parent's cap applies.
+	MaxConcurrent int64
+}
+
+// Child is a view of a Nozzle for one class of traffic to the same dependency.
+// It shares the parent's observations and flow rate, and reports its calls' outcomes to the parent,
+// but it applies its own ChildOptions before the parent decides.
+// See nozzle.Child for how to create one.
+type Child[T any] struct {
+	// parent makes the admission decisions and records the outcomes.
+	parent *Nozzle[T]
+
+	// options are the Child's own admission rules.
+	options ChildOptions
+
+	// mut guards inFlight and blocked.
+	mut sync.Mutex
+
+	// inFlight counts the Child's calls that are running.
+	// See ChildOptions.MaxConcurrent for usage.
+	inFlight int64
+
+	// blocked counts the calls the Child blocked by its own rules, before asking the parent.
+	blocked int64
+}
+
+// Every *Child[T] is a Nozzler[T].
+var _ Nozzler[any] = (*Child[any])(nil)
+
+// Child creates a Child of the Nozzle with its own admission rules.
+// Children are lightweight: they start no goroutine, and need not be closed.
+//
+// Example:
+//
+//	interactive := n.Child(nozzle.ChildOptions{Priority: nozzle.PriorityCritical})
+//	batch := n.Child(nozzle.ChildOptions{Priority: nozzle.PriorityLow, MinFlowRate: 80, MaxConcurrent: 4})
+func (n *Nozzle[T]) Child(options ChildOptions) *Child[T] {
+	return &Child[T]{
+		parent:  n,
+		options: options,
+	}
+}
+
+// DoBool is like Nozzle.DoBool, with the Child's rules applied first.
+func (c *Child[T]) DoBool(callback func() (T, bool)) (T, bool) {
+	if c.enter() != nil {
+		return *new(T), false
+	}
+	defer c.leave()
+
+	return c.parent.DoBoolContext(c.context(context.Background()), func(context.Context) (T, bool) {
+		return callback()
+	})
+}
+
+// DoError is like Nozzle.DoError, with the Child's rules applied first.
+// A call blocked by the Child's rules returns a *BlockedError, like one blocked by the parent.
+func (c *Child[T]) DoError(callback func() (T, error)) (T, error) {
+	return c.DoErrorContext(context.Background(), func(context.Context) (T, error) {
+		return callback()
+	})
+}
+
+// DoErrorContext is like Nozzle.DoErrorContext, with the Child's rules applied first.
+// A call blocked by the Child's rules returns a *BlockedError, like one blocked by the parent.
+func (c *Child[T]) DoErrorContext(ctx context.Context, callback func(context.Context) (T, error)) (T, error) {
+	if err := c.enter(); err != nil {
+		return *new(T), err
+	}
+	defer c.leave()
+
+	return c.parent.DoErrorContext(c.context(ctx), callback)
+}
+
+// FlowRate reports the parent's flow rate, or 0 while it is below ChildOptions.MinFlowRate.
+func (c *Child[T]) FlowRate() int64 {
+	flowRate := c.parent.FlowRate()
+	if flowRate < c.options.MinFlowRate {
+		return 0
+	}
+
+	return flowRate
+}
+
+// State reports the parent's State.
+func (c *Child[T]) State() State {
+	return c.parent.State()
+}
+
+// Blocked reports how many calls the Child blocked by its own rules, without asking the parent.
+// Calls the parent blocked are counted by the parent's Stats instead.
+func (c *Child[T]) Blocked() int64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.blocked
+}
+
+// Close does nothing: the parent owns the shared state, and must be closed on its own.
+// It exists so a Child is a Nozzler.
+func (c *Child[T]) Close() error {
+	return nil
+}
+
+// enter applies the Child's rules, and reserves a ChildOptions.MaxConcurrent slot for a call that passes them.
+// It returns the error for a call they block, or nil.
+// That error has no DecisionID, because the parent never decided.
+func (c *Child[T]) enter() *BlockedError {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	belowFloor := c.parent.FlowRate() < c.options.MinFlowRate
+	full := c.options.MaxConcurrent > 0 && c.inFlight >= c.options.MaxConcurrent
+
+	if belowFloor || full {
+		c.blocked++
+
+		err := c.parent.blocked(0)
+		err.Bulkhead = !belowFloor
+
+		return err
+	}
+
+	c.inFlight++
+
+	return nil
+}
+
+// leave frees the ChildOptions.MaxConcurrent slot of a call that is no longer running.
+func (c *Child[T]) leave() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.inFlight--
+}
+
+// context applies ChildOptions.Priority to ctx, unless ctx already has a priority.
+func (c *Child[T]) context(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+
+	return WithPriority(ctx, c.options.Priority)
+}
@@ -0,0 +1,78 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestChild(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	child := noz.Child(nozzle.ChildOptions{
+		Priority:      nozzle.PriorityLow,
+		MinFlowRate:   80,
+		MaxConcurrent: 1,
+	})
+
+	failure := errors.New("failure")
+
+	// Outcomes are reported to the parent.
+	child.DoBool(func() (any, bool) { return nil, true })
+	child.DoError(func() (any, error) { return nil, failure }) //nolint:errcheck
+
+	if stats := noz.Stats(); stats.Successes != 1 || stats.Failures != 1 {
+		t.Errorf("Expected Successes=1 Failures=1 Got Successes=%d Failures=%d", stats.Successes, stats.Failures)
+	}
+
+	// A second call is over the Child's cap while the first runs.
+	running := make(chan struct{})
+	release := make(chan struct{})
+
+	go child.DoErrorContext(context.Background(), func(context.Context) (any, error) { //nolint:errcheck
+		close(running)
+		<-release
+
+		return nil, nil
+	})
+
+	<-running
+
+	_, err := child.DoError(func() (any, error) { return nil, nil })
+
+	var blocked *nozzle.BlockedError
+	if !errors.As(err, &blocked) || !blocked.Bulkhead {
+		t.Errorf("Expected a bulkhead BlockedError Got=%v", err)
+	}
+
+	close(release)
+
+	// Below the Child's floor, every call is blocked without asking the parent.
+	noz.ForceClose("maintenance")
+
+	if child.FlowRate() != 0 {
+		t.Errorf("Expected FlowRate=0 Got=%d", child.FlowRate())
+	}
+
+	_, err = child.DoError(func() (any, error) { return nil, nil })
+	if !errors.As(err, &blocked) || blocked.Bulkhead {
+		t.Errorf("Expected a flow rate BlockedError Got=%v", err)
+	}
+
+	if child.Blocked() != 2 {
+		t.Errorf("Expected Blocked=2 Got=%d", child.Blocked())
+	}
+
+	if stats := noz.Stats(); stats.Blocked != 0 {
+		t.Errorf("Expected parent Blocked=0 Got=%d", stats.Blocked)
+	}
+}
@@ -0,0 +1,179 @@
+package nozzle
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells a Nozzle the time and schedules its ticks.
+// Set Options.Clock to control time in tests, instead of sleeping through real intervals.
+// See nozzle.ManualClock for a Clock that only moves when told to.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a Ticker that ticks every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks, like a *time.Ticker.
+type Ticker interface {
+	// C returns the channel ticks are delivered on.
+	C() <-chan time.Time
+
+	// Reset changes the Ticker to tick every d, starting d from now.
+	Reset(d time.Duration)
+
+	// Stop stops the Ticker. No more ticks are delivered.
+	Stop()
+}
+
+// systemClock is the Clock used when Options.Clock is nil.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{Ticker: time.NewTicker(d)}
+}
+
+// systemTicker adapts a *time.Ticker to Ticker.
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// clock returns Options.Clock, or the system clock when it is not set.
+func (n *Nozzle[T]) clock() Clock {
+	if c := n.options().Clock; c != nil {
+		return c
+	}
+
+	return systemClock{}
+}
+
+// now returns the current time according to the Nozzle's Clock.
+func (n *Nozzle[T]) now() time.Time {
+	return n.clock().Now()
+}
+
+// since returns the time elapsed since t according to the Nozzle's Clock.
+func (n *Nozzle[T]) since(t time.Time) time.Duration {
+	return n.now().Sub(t)
+}
+
+// ManualClock is a Clock that only moves when Advance is called.
+// It is safe for concurrent use.
+//
+// Example:
+//
+//	clock := nozzle.NewManualClock(time.Now())
+//
+//	n := nozzle.New(nozzle.Options[any]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//		Clock:                 clock,
+//	})
+//
+//	clock.Advance(time.Second) // Ends the interval, without waiting a second.
+type ManualClock struct {
+	// mut guards now and tickers.
+	mut sync.Mutex
+
+	// now is the time the clock reports.
+	now time.Time
+
+	// tickers are the Tickers that have not been stopped.
+	tickers map[*manualTicker]struct{}
+}
+
+// NewManualClock creates a ManualClock that starts at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{
+		now:     now,
+		tickers: map[*manualTicker]struct{}{},
+	}
+}
+
+// Now returns the time the clock was last advanced to.
+func (c *ManualClock) Now() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a Ticker that ticks every time the clock is advanced past a multiple of d.
+func (c *ManualClock) NewTicker(d time.Duration) Ticker {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	t := &manualTicker{
+		clock:  c,
+		c:      make(chan time.Time, 1),
+		period: d,
+		next:   c.now.Add(d),
+	}
+
+	c.tickers[t] = struct{}{}
+
+	return t
+}
+
+// Advance moves the clock forward by d, and delivers a tick to every Ticker that became due.
+// Like a *time.Ticker, a Ticker that is due more than once delivers a single tick, and drops ticks its reader is not ready for.
+// Ticks are processed asynchronously, as with a real clock.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.now = c.now.Add(d)
+
+	for t := range c.tickers {
+		if t.period <= 0 || t.next.After(c.now) {
+			continue
+		}
+
+		due := c.now.Sub(t.next)/t.period + 1
+		t.next = t.next.Add(due * t.period)
+
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+// manualTicker is a Ticker driven by a ManualClock.
+// Its fields are guarded by the clock's lock.
+type manualTicker struct {
+	clock  *ManualClock
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+func (t *manualTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTicker) Reset(d time.Duration) {
+	t.clock.mut.Lock()
+	defer t.clock.mut.Unlock()
+
+	t.period = d
+	t.next = t.clock.now.Add(d)
+	t.clock.tickers[t] = struct{}{}
+}
+
+func (t *manualTicker) Stop() {
+	t.clock.mut.Lock()
+	defer t.clock.mut.Unlock()
+
+	delete(t.clock.tickers, t)
+}
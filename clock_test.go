@@ -0,0 +1,107 @@
+package nozzle_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestManualClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := nozzle.NewManualClock(start)
+
+	ticker := clock.NewTicker(time.Second)
+
+	ticked := func() bool {
+		select {
+		case <-ticker.C():
+			return true
+		default:
+			return false
+		}
+	}
+
+	clock.Advance(time.Second / 2)
+
+	if ticked() {
+		t.Error("Expected no tick before the period elapsed")
+	}
+
+	// Due more than once, but a single tick is delivered.
+	clock.Advance(3 * time.Second)
+
+	if !ticked() || ticked() {
+		t.Error("Expected exactly one tick")
+	}
+
+	if expected := start.Add(3*time.Second + time.Second/2); !clock.Now().Equal(expected) {
+		t.Errorf("Expected Now=%s Got=%s", expected, clock.Now())
+	}
+
+	// The next tick is due at 4s, not 3.5s + 1s.
+	clock.Advance(time.Second / 2)
+
+	if !ticked() {
+		t.Error("Expected a tick at the next multiple of the period")
+	}
+
+	ticker.Reset(time.Minute)
+	clock.Advance(time.Second)
+
+	if ticked() {
+		t.Error("Expected no tick before the reset period elapsed")
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+
+	if ticked() {
+		t.Error("Expected no tick after Stop")
+	}
+}
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Now())
+
+	// The Interval is far too long to end on its own during the test.
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	snapshots := make(chan nozzle.StateSnapshot, 1)
+
+	go func() {
+		snapshot, err := noz.WaitSnapshot(ctx)
+		if err != nil {
+			t.Errorf("Expected err=nil Got=%v", err)
+		}
+
+		snapshots <- snapshot
+	}()
+
+	// Keep advancing until the waiter has registered and seen a tick.
+	for {
+		select {
+		case snapshot := <-snapshots:
+			if snapshot.IntervalSeq < 1 {
+				t.Errorf("Expected IntervalSeq>=1 Got=%d", snapshot.IntervalSeq)
+			}
+
+			return
+		case <-time.After(time.Millisecond):
+			clock.Advance(time.Hour)
+		}
+	}
+}
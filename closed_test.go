@@ -0,0 +1,78 @@
+package nozzle_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestClosedPanic(t *testing.T) {
+	t.Parallel()
+
+	for i, test := range []struct {
+		closedPanic bool
+		call        func(*nozzle.Nozzle[any])
+	}{
+		{
+			closedPanic: false,
+			call: func(noz *nozzle.Nozzle[any]) {
+				noz.DoBool(func() (any, bool) { return nil, true })
+			},
+		},
+		{
+			closedPanic: true,
+			call: func(noz *nozzle.Nozzle[any]) {
+				noz.DoBool(func() (any, bool) { return nil, true })
+			},
+		},
+		{
+			closedPanic: true,
+			call: func(noz *nozzle.Nozzle[any]) {
+				noz.DoError(func() (any, error) { return nil, nil })
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := nozzle.New(nozzle.Options[any]{
+				Interval:              time.Hour,
+				AllowedFailurePercent: 50,
+				ClosedPanic:           test.closedPanic,
+			})
+
+			// Calls before Close never panic.
+			test.call(noz)
+
+			if err := noz.Close(); err != nil {
+				t.Fatalf("Expected err=nil Got=%v", err)
+			}
+
+			var recovered any
+
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+
+				test.call(noz)
+			}()
+
+			if !test.closedPanic {
+				if recovered != nil {
+					t.Errorf("Expected recovered=nil Got=%v", recovered)
+				}
+
+				return
+			}
+
+			err, ok := recovered.(error)
+			if !ok || !errors.Is(err, nozzle.ErrClosed) {
+				t.Errorf("Expected recovered=%v Got=%v", nozzle.ErrClosed, recovered)
+			}
+		})
+	}
+}
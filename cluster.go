@@ -0,0 +1,143 @@
+package nozzle
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Cluster makes the replicas of a service share their outcomes, so each replica's Nozzle decides on the fleet-wide failure rate.
+// A low-traffic replica then closes as soon as the fleet sees the dependency failing, instead of waiting to see enough failures of its own.
+//
+// At the end of each interval, the Nozzle publishes its counts through the Transport and adds those most recently published by the other replicas.
+// The rates the Nozzle reports then describe the fleet, while Stats and admission stay local.
+//
+// Example:
+//
+//	Cluster: nozzle.Cluster{
+//		Transport: redisTransport, // Your ClusterTransport
+//		Replica:   os.Getenv("HOSTNAME"),
+//	},
+type Cluster struct {
+	// Transport shares the counts between replicas.
+	// If nil, the Nozzle only uses its own outcomes.
+	Transport ClusterTransport
+
+	// Replica uniquely names this replica, so its own counts are not added twice.
+	Replica string
+
+	// Timeout bounds each Exchange. When it fails, the interval is decided on local outcomes alone.
+	// If zero, half of Options.Interval is used.
+	Timeout time.Duration
+}
+
+// ClusterCounts are the outcomes one replica observed in one interval.
+type ClusterCounts struct {
+	// Replica is the Cluster.Replica that observed them.
+	Replica string
+
+	// End is when the interval ended.
+	// Counts that ended more than two Intervals before the current one are ignored as stale.
+	End time.Time
+
+	// Successes is the number of calls that succeeded.
+	Successes int64
+
+	// Failures is the number of calls that failed.
+	Failures int64
+}
+
+// ClusterTransport shares counts between the replicas of a Cluster, through a backend such as a cache or a database.
+// Implementations must be safe for concurrent use.
+type ClusterTransport interface {
+	// Exchange publishes local, this replica's counts for the interval that is ending,
+	// and returns the most recent counts published by every replica. They may include local.
+	Exchange(ctx context.Context, local ClusterCounts) ([]ClusterCounts, error)
+}
+
+// exchange publishes the current interval's counts, and adds the other replicas' to the engine.
+// The caller must hold the lock, which is released during the Exchange.
+func (n *Nozzle[T]) exchange() {
+	cluster := n.options().Cluster
+
+	o := n.engine.Observe()
+	local := ClusterCounts{
+		Replica:   cluster.Replica,
+		End:       n.now(),
+		Successes: o.Successes,
+		Failures:  o.Failures,
+	}
+
+	timeout := cluster.Timeout
+	if timeout <= 0 {
+		timeout = n.options().Interval / 2
+	}
+
+	// Need to unlock so the Transport can take its time without blocking calls.
+	n.mut.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	peers, err := cluster.Transport.Exchange(ctx, local)
+
+	cancel()
+
+	if err != nil && n.options().Logger != nil {
+		n.options().Logger.Warn("nozzle: cluster exchange failed; deciding on local outcomes", "error", err)
+	}
+
+	n.mut.Lock()
+
+	if err != nil {
+		n.totals.ClusterErrors++
+
+		return
+	}
+
+	stale := local.End.Add(-2 * n.options().Interval)
+
+	for _, peer := range peers {
+		if peer.Replica == cluster.Replica || peer.End.Before(stale) {
+			continue
+		}
+
+		n.engine.Record(max(peer.Successes, 0), max(peer.Failures, 0))
+	}
+}
+
+// MemoryTransport is a ClusterTransport that shares counts between Nozzles of the same process.
+// It is meant for tests, and as a reference for implementing a ClusterTransport on a real backend.
+//
+// Example:
+//
+//	transport := nozzle.NewMemoryTransport()
+//
+//	a := nozzle.New(nozzle.Options[any]{Cluster: nozzle.Cluster{Transport: transport, Replica: "a"}, ...})
+//	b := nozzle.New(nozzle.Options[any]{Cluster: nozzle.Cluster{Transport: transport, Replica: "b"}, ...})
+type MemoryTransport struct {
+	mut sync.Mutex
+
+	// latest holds the most recent counts of each replica.
+	latest map[string]ClusterCounts
+}
+
+// NewMemoryTransport creates an empty MemoryTransport.
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{latest: map[string]ClusterCounts{}}
+}
+
+// Exchange stores local as the latest counts of its replica, and returns the latest counts of every replica, sorted by replica.
+func (t *MemoryTransport) Exchange(_ context.Context, local ClusterCounts) ([]ClusterCounts, error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.latest[local.Replica] = local
+
+	counts := make([]ClusterCounts, 0, len(t.latest))
+	for _, replica := range slices.Sorted(maps.Keys(t.latest)) {
+		counts = append(counts, t.latest[replica])
+	}
+
+	return counts, nil
+}
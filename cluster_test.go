@@ -0,0 +1,79 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// failingTransport is a ClusterTransport whose backend is down.
+type failingTransport struct{}
+
+func (failingTransport) Exchange(context.Context, nozzle.ClusterCounts) ([]nozzle.ClusterCounts, error) {
+	return nil, errors.New("backend unavailable")
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	transport := nozzle.NewMemoryTransport()
+
+	replica := func(name string, transport nozzle.ClusterTransport) *nozzle.Nozzle[any] {
+		return nozzle.New(nozzle.Options[any]{
+			Interval:              time.Second,
+			AllowedFailurePercent: 50,
+			Clock:                 clock,
+			ManualTick:            true,
+			Cluster:               nozzle.Cluster{Transport: transport, Replica: name},
+		})
+	}
+
+	busy := replica("busy", transport)
+	defer busy.Close() //nolint:errcheck
+
+	quiet := replica("quiet", transport)
+	defer quiet.Close() //nolint:errcheck
+
+	isolated := replica("isolated", failingTransport{})
+	defer isolated.Close() //nolint:errcheck
+
+	for range 20 {
+		busy.DoBool(func() (any, bool) { return nil, false })
+	}
+
+	quiet.DoBool(func() (any, bool) { return nil, true })
+	isolated.DoBool(func() (any, bool) { return nil, true })
+
+	clock.Advance(time.Second)
+	busy.Tick()
+	quiet.Tick()
+	isolated.Tick()
+
+	if s := busy.State(); s != nozzle.Closing {
+		t.Errorf("Expected the busy replica to close on its own failures Got State=%s", s)
+	}
+
+	if s, r := quiet.State(), quiet.Reason(); s != nozzle.Closing || r != "failure rate 95% > 50%" {
+		t.Errorf("Expected the quiet replica to close on the fleet's failures Got State=%s Reason=%q", s, r)
+	}
+
+	if s := quiet.Stats(); s.Successes != 1 || s.Failures != 0 {
+		t.Errorf("Expected the quiet replica's Stats to stay local Got Successes=%d Failures=%d", s.Successes, s.Failures)
+	}
+
+	if s, errs := isolated.State(), isolated.Stats().ClusterErrors; s != nozzle.Opening || errs != 1 {
+		t.Errorf("Expected the isolated replica to decide alone Got State=%s ClusterErrors=%d", s, errs)
+	}
+
+	// The busy replica's counts are stale once it stops publishing.
+	clock.Advance(3 * time.Second)
+	quiet.Tick()
+
+	if s := quiet.State(); s != nozzle.Opening {
+		t.Errorf("Expected the quiet replica to ignore stale counts Got State=%s", s)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+// client calls the endpoints of a nozzlehttp.AdminHandler.
+type client struct {
+	// addr is the base URL the AdminHandler is served at, such as "http://127.0.0.1:9090/admin".
+	addr string
+
+	// token is sent as a bearer token with every request, unless it is empty.
+	token string
+
+	http *http.Client
+}
+
+// list returns the Snapshot of every Nozzle, by name.
+func (c *client) list(ctx context.Context) (map[string]nozzle.StateSnapshot, error) {
+	var snapshots map[string]nozzle.StateSnapshot
+
+	return snapshots, c.do(ctx, http.MethodGet, "/nozzles", nil, &snapshots)
+}
+
+// get returns the Nozzle name.
+func (c *client) get(ctx context.Context, name string) (nozzlehttp.AdminNozzle, error) {
+	var n nozzlehttp.AdminNozzle
+
+	return n, c.do(ctx, http.MethodGet, "/nozzles/"+url.PathEscape(name), nil, &n)
+}
+
+// act posts a to the action endpoint of the Nozzle name, and returns the Nozzle's Snapshot afterwards.
+func (c *client) act(ctx context.Context, name, action string, a nozzlehttp.AdminAction) (nozzle.StateSnapshot, error) {
+	var s nozzle.StateSnapshot
+
+	return s, c.do(ctx, http.MethodPost, "/nozzles/"+url.PathEscape(name)+"/"+action, a, &s)
+}
+
+// watch polls the Nozzle name every period, and calls changed with its Snapshot whenever an interval has ended since the last poll.
+// It returns nil once changed has been called count times, or when ctx is done; a count of 0 never stops.
+func (c *client) watch(ctx context.Context, name string, every time.Duration, count int, changed func(nozzle.StateSnapshot) error) error {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	var last uint64
+
+	for seen := 0; count == 0 || seen < count; {
+		n, err := c.get(ctx, name)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil:
+			return err
+		case n.Snapshot.IntervalSeq != last:
+			last = n.Snapshot.IntervalSeq
+			seen++
+
+			if err := changed(n.Snapshot); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// do sends a request with body encoded as JSON, if it is not nil, and decodes the JSON response into out.
+// A response that is not 2xx returns the error the AdminHandler responded with.
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("nozzlectl: %w", err)
+		}
+
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.addr, "/")+path, reader)
+	if err != nil {
+		return fmt.Errorf("nozzlectl: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("nozzlectl: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var failed struct {
+			Error string `json:"error"`
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&failed); err != nil || failed.Error == "" {
+			return fmt.Errorf("nozzlectl: %s %s: %s", method, path, resp.Status)
+		}
+
+		return fmt.Errorf("nozzlectl: %s %s: %s: %s", method, path, resp.Status, failed.Error)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("nozzlectl: %s %s: %w", method, path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,318 @@
+// Command nozzlectl inspects and controls the Nozzles of a running service through nozzlehttp.AdminHandler,
+// so runbooks and chatops have a supported tool instead of curl.
+//
+// It covers the admin endpoints:
+//
+//   - list prints the Snapshot of every Nozzle.
+//   - get prints one Nozzle's Snapshot, configuration, and fairness.
+//   - watch prints a Nozzle's Snapshot whenever an interval ends, until interrupted.
+//   - force-open, force-close, and unforce start and end manual overrides.
+//   - reset restores a Nozzle to fully open.
+//   - set-threshold changes AllowedFailurePercent and Interval from the next interval on.
+//
+// Output is a table, or JSON with -o json. Only the HTTP admin endpoints exist, so there is no gRPC transport.
+//
+// Usage:
+//
+//	export NOZZLECTL_ADDR=http://127.0.0.1:9090/admin NOZZLECTL_TOKEN=...
+//
+//	nozzlectl list
+//	nozzlectl get payments
+//	nozzlectl watch -every 2s payments
+//	nozzlectl force-close -for 10m -reason "INC-42 (alice)" payments
+//	nozzlectl unforce payments
+//	nozzlectl reset payments
+//	nozzlectl set-threshold -percent 30 -interval 2s payments
+//	nozzlectl -o json list | jq
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+// errUsage is returned for command lines nozzlectl cannot run. The flag package has already printed why.
+var errUsage = errors.New("nozzlectl: usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2) //nolint:gocritic // stop has nothing left to clean up.
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command line args, printing results to stdout and usage to stderr.
+// getenv supplies the defaults of -addr and -token.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) error {
+	flags := flag.NewFlagSet("nozzlectl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	addr := flags.String("addr", or(getenv("NOZZLECTL_ADDR"), "http://127.0.0.1:9090"), "base URL AdminHandler is served at, or $NOZZLECTL_ADDR")
+	token := flags.String("token", getenv("NOZZLECTL_TOKEN"), "bearer token sent with every request, or $NOZZLECTL_TOKEN")
+	output := flags.String("o", "table", "output format: table or json")
+	timeout := flags.Duration("timeout", 10*time.Second, "how long each request may take")
+
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "nozzlectl: unknown output %q, want table or json\n", *output)
+
+		return errUsage
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return errUsage
+	}
+
+	c := &client{
+		addr:  *addr,
+		token: *token,
+		http:  &http.Client{Timeout: *timeout},
+	}
+
+	p := printer{w: stdout, json: *output == "json"}
+
+	command, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "nozzlectl: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+
+		return errUsage
+	}
+
+	return command(ctx, c, p, flags.Args()[1:], stderr)
+}
+
+// command runs one subcommand with its own args.
+type command func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error
+
+// commands holds every subcommand, by name.
+var commands = map[string]command{
+	"list": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		if _, err := parse("list", "", args, stderr, nil); err != nil {
+			return err
+		}
+
+		snapshots, err := c.list(ctx)
+		if err != nil {
+			return err
+		}
+
+		return p.list(snapshots)
+	},
+
+	"get": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		name, err := parse("get", "NAME", args, stderr, nil)
+		if err != nil {
+			return err
+		}
+
+		n, err := c.get(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		return p.nozzle(name, n)
+	},
+
+	"watch": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		var every time.Duration
+
+		var count int
+
+		name, err := parse("watch", "NAME", args, stderr, func(flags *flag.FlagSet) {
+			flags.DurationVar(&every, "every", time.Second, "how often to poll the Nozzle")
+			flags.IntVar(&count, "count", 0, "stop after this many intervals; 0 watches until interrupted")
+		})
+		if err != nil {
+			return err
+		}
+
+		return c.watch(ctx, name, every, count, p.watched())
+	},
+
+	"force-open": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		return forceCommand(ctx, c, p, "force-open", args, stderr)
+	},
+
+	"force-close": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		return forceCommand(ctx, c, p, "force-close", args, stderr)
+	},
+
+	"unforce": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		return actionCommand(ctx, c, p, "unforce", args, stderr)
+	},
+
+	"reset": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		return actionCommand(ctx, c, p, "reset", args, stderr)
+	},
+
+	"set-threshold": func(ctx context.Context, c *client, p printer, args []string, stderr io.Writer) error {
+		var percent int64
+
+		var interval time.Duration
+
+		name, err := parse("set-threshold", "NAME", args, stderr, func(flags *flag.FlagSet) {
+			flags.Int64Var(&percent, "percent", -1, "new AllowedFailurePercent, from 0 to 100")
+			flags.DurationVar(&interval, "interval", 0, "new Interval, such as 2s")
+		})
+		if err != nil {
+			return err
+		}
+
+		if percent < 0 && interval == 0 {
+			fmt.Fprintln(stderr, "nozzlectl: set-threshold needs -percent, -interval, or both")
+
+			return errUsage
+		}
+
+		var a nozzlehttp.AdminAction
+
+		if percent >= 0 {
+			a.AllowedFailurePercent = &percent
+		}
+
+		if interval != 0 {
+			a.Interval = interval.String()
+		}
+
+		return act(ctx, c, p, name, "thresholds", a)
+	},
+}
+
+// forceCommand runs force-open or force-close.
+func forceCommand(ctx context.Context, c *client, p printer, name string, args []string, stderr io.Writer) error {
+	var d time.Duration
+
+	var reason string
+
+	target, err := parse(name, "NAME", args, stderr, func(flags *flag.FlagSet) {
+		flags.DurationVar(&d, "for", 0, "how long the override lasts; 0 lasts until unforce")
+		flags.StringVar(&reason, "reason", "", "why, such as \"INC-42 (alice)\", recorded in the Nozzle's events and audit log")
+	})
+	if err != nil {
+		return err
+	}
+
+	a := nozzlehttp.AdminAction{Reason: reason}
+
+	if d > 0 {
+		a.Duration = d.String()
+	}
+
+	return act(ctx, c, p, target, name, a)
+}
+
+// actionCommand runs a subcommand that posts an action without a body.
+func actionCommand(ctx context.Context, c *client, p printer, name string, args []string, stderr io.Writer) error {
+	target, err := parse(name, "NAME", args, stderr, nil)
+	if err != nil {
+		return err
+	}
+
+	return act(ctx, c, p, target, name, nozzlehttp.AdminAction{})
+}
+
+// act posts the action a to the Nozzle name, and prints its Snapshot afterwards.
+func act(ctx context.Context, c *client, p printer, name, path string, a nozzlehttp.AdminAction) error {
+	s, err := c.act(ctx, name, path, a)
+	if err != nil {
+		return err
+	}
+
+	return p.snapshot(name, s)
+}
+
+// parse parses the flags of the subcommand name, added by define if it is not nil, and returns its one positional argument.
+// An empty positional names a subcommand that takes none.
+func parse(name, positional string, args []string, stderr io.Writer, define func(flags *flag.FlagSet)) (string, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: nozzlectl %s [flags] %s\n", name, positional)
+		flags.PrintDefaults()
+	}
+
+	if define != nil {
+		define(flags)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return "", usageError(err)
+	}
+
+	want := 1
+	if positional == "" {
+		want = 0
+	}
+
+	if flags.NArg() != want {
+		flags.Usage()
+
+		return "", errUsage
+	}
+
+	return flags.Arg(0), nil
+}
+
+// usageError returns err from parsing flags, which the flag package has already printed, as errUsage.
+// Asking for help is not an error.
+func usageError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+
+	return errUsage
+}
+
+// or returns value, or fallback when value is empty.
+func or(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+const usage = `usage: nozzlectl [flags] COMMAND [command flags] [NAME]
+
+Commands:
+  list            the Snapshot of every Nozzle
+  get NAME        a Nozzle's Snapshot, configuration, and fairness
+  watch NAME      a Nozzle's Snapshot whenever an interval ends
+  force-open NAME, force-close NAME
+                  start a manual override, with -for and -reason
+  unforce NAME    end the manual override
+  reset NAME      restore the Nozzle to fully open
+  set-threshold NAME
+                  change -percent and -interval from the next interval on
+
+Flags:
+`
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+func TestNozzlectl(t *testing.T) {
+	t.Parallel()
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	payments, err := registry.New("payments", nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/admin", nozzlehttp.AdminHandler(registry, nozzlehttp.AdminOptions{
+		Authorize: func(r *http.Request) error {
+			if r.Method != http.MethodGet && r.Header.Get("Authorization") != "Bearer operator" {
+				return errors.New("actions need the operator token")
+			}
+
+			return nil
+		},
+	})))
+	defer server.Close()
+
+	env := map[string]string{"NOZZLECTL_ADDR": server.URL + "/admin", "NOZZLECTL_TOKEN": "operator"}
+
+	nozzlectl := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+
+		err := run(context.Background(), args, &stdout, &stderr, func(key string) string { return env[key] })
+
+		return stdout.String() + stderr.String(), err
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"list"}, want: []string{"NAME", "payments", "opening", "100%"}},
+		{args: []string{"force-close", "-for", "10m", "-reason", "INC-42", "payments"}, want: []string{"forced-closed", "INC-42 (10m0s left)"}},
+		{args: []string{"get", "payments"}, want: []string{"forced-closed", "ALLOWED FAILURE PERCENT  50", "INTERVAL"}},
+		{args: []string{"unforce", "payments"}, want: []string{"opening", "100%"}},
+		{args: []string{"set-threshold", "-percent", "20", "payments"}, want: []string{"payments"}},
+		{args: []string{"reset", "payments"}, want: []string{"opening"}},
+	}
+
+	for i, test := range tests {
+		out, err := nozzlectl(test.args...)
+		if err != nil {
+			t.Errorf("test=%d Expected err=nil Got=%v", i, err)
+		}
+
+		for _, want := range test.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("test=%d Expected output to contain %q Got=%s", i, want, out)
+			}
+		}
+	}
+
+	// Thresholds change from the next interval on.
+	clock.Advance(time.Hour)
+	payments.Tick()
+
+	if d := payments.DescribeConfig(); d.AllowedFailurePercent != 20 {
+		t.Errorf("Expected AllowedFailurePercent=20 Got=%d", d.AllowedFailurePercent)
+	}
+
+	// JSON output decodes into the types of the nozzle packages.
+	out, err := nozzlectl("-o", "json", "get", "payments")
+	if err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	var n nozzlehttp.AdminNozzle
+	if err := json.Unmarshal([]byte(out), &n); err != nil || n.Snapshot.State != nozzle.Opening {
+		t.Errorf("Expected the JSON of an opening Nozzle Got=%s err=%v", out, err)
+	}
+
+	// The AdminHandler's errors are reported.
+	if _, err := nozzlectl("get", "missing"); err == nil || !strings.Contains(err.Error(), "no such nozzle") {
+		t.Errorf("Expected a not found error Got=%v", err)
+	}
+
+	env["NOZZLECTL_TOKEN"] = ""
+
+	if _, err := nozzlectl("reset", "payments"); err == nil || !strings.Contains(err.Error(), "operator token") {
+		t.Errorf("Expected a forbidden error Got=%v", err)
+	}
+
+	for i, args := range [][]string{{}, {"explode"}, {"get"}, {"set-threshold", "payments"}, {"-o", "yaml", "list"}} {
+		if _, err := nozzlectl(args...); !errors.Is(err, errUsage) {
+			t.Errorf("test=%d Expected err=%v Got=%v", i, errUsage, err)
+		}
+	}
+}
+
+func TestNozzlectlWatch(t *testing.T) {
+	t.Parallel()
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	payments, err := registry.New("payments", nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(nozzlehttp.AdminHandler(registry, nozzlehttp.AdminOptions{}))
+	defer server.Close()
+
+	// Each interval ends on its own goroutine, so the watch sees new ones while it polls.
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond * 5):
+				clock.Advance(time.Hour)
+				payments.Tick()
+			}
+		}
+	}()
+
+	var stdout bytes.Buffer
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := run(ctx, []string{"-addr", server.URL, "watch", "-every", "1ms", "-count", "3", "payments"}, &stdout, &stdout, func(string) string { return "" }); err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(stdout.String()), "\n"); len(lines) != 4 || !strings.HasPrefix(lines[0], "TIME") {
+		t.Errorf("Expected a header and 3 rows Got=%s", stdout.String())
+	}
+
+	// A canceled watch stops without an error.
+	canceled, stop := context.WithCancel(context.Background())
+	stop()
+
+	if err := run(canceled, []string{"-addr", server.URL, "watch", "payments"}, &stdout, &stdout, func(string) string { return "" }); err != nil {
+		t.Errorf("Expected err=nil Got=%v", err)
+	}
+}
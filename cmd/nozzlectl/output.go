@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+// printer writes results as tables, or as JSON when json is set.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+// snapshotHeader heads the columns written by snapshotRow.
+const snapshotHeader = "NAME\tSTATE\tFLOW RATE\tFAILURE RATE\tALLOWED\tBLOCKED\tINTERVAL\tOVERRIDE\n"
+
+// list writes the Snapshot of every Nozzle, sorted by name.
+func (p printer) list(snapshots map[string]nozzle.StateSnapshot) error {
+	if p.json {
+		return p.encode(snapshots)
+	}
+
+	t := p.table()
+	fmt.Fprint(t, snapshotHeader)
+
+	for _, name := range slices.Sorted(maps.Keys(snapshots)) {
+		snapshotRow(t, name, snapshots[name])
+	}
+
+	return t.Flush()
+}
+
+// snapshot writes the Snapshot of the Nozzle name.
+func (p printer) snapshot(name string, s nozzle.StateSnapshot) error {
+	if p.json {
+		return p.encode(s)
+	}
+
+	t := p.table()
+	fmt.Fprint(t, snapshotHeader)
+	snapshotRow(t, name, s)
+
+	return t.Flush()
+}
+
+// nozzle writes the Snapshot, configuration, and fairness of the Nozzle name.
+func (p printer) nozzle(name string, n nozzlehttp.AdminNozzle) error {
+	if p.json {
+		return p.encode(n)
+	}
+
+	t := p.table()
+	fmt.Fprint(t, snapshotHeader)
+	snapshotRow(t, name, n.Snapshot)
+
+	fmt.Fprintf(t, "\nREASON\t%s\n", n.Snapshot.Reason)
+	fmt.Fprintf(t, "PAUSED\t%t\n", n.Snapshot.Paused)
+	fmt.Fprintf(t, "MAINTENANCE\t%t\n", n.Snapshot.Maintenance)
+	fmt.Fprintf(t, "INTERVAL\t%s\n", n.Config.Interval)
+	fmt.Fprintf(t, "ALLOWED FAILURE PERCENT\t%d\n", n.Config.AllowedFailurePercent)
+	fmt.Fprintf(t, "STRATEGY\t%s\n", n.Config.Strategy)
+	fmt.Fprintf(t, "JAIN INDEX\t%.2f\n", n.Fairness.JainIndex)
+
+	if len(n.Fairness.Callers) > 0 {
+		fmt.Fprint(t, "\nCALLER\tALLOWED\tBLOCKED\n")
+
+		for _, caller := range slices.Sorted(maps.Keys(n.Fairness.Callers)) {
+			a := n.Fairness.Callers[caller]
+			fmt.Fprintf(t, "%s\t%d\t%d\n", caller, a.Allowed, a.Blocked)
+		}
+	}
+
+	return t.Flush()
+}
+
+// watched returns a function that writes each Snapshot of a watched Nozzle as soon as it arrives:
+// a table row with the time it was taken, or one JSON object per line.
+func (p printer) watched() func(nozzle.StateSnapshot) error {
+	if p.json {
+		return func(s nozzle.StateSnapshot) error {
+			return p.encode(s)
+		}
+	}
+
+	t := p.table()
+	fmt.Fprint(t, "TIME\tSTATE\tFLOW RATE\tFAILURE RATE\tALLOWED\tBLOCKED\tINTERVAL\tREASON\n")
+
+	return func(s nozzle.StateSnapshot) error {
+		fmt.Fprintf(t, "%s\t%s\t%d%%\t%d%%\t%d\t%d\t%d\t%s\n",
+			s.Time.Format(time.TimeOnly), s.State, s.FlowRate, s.FailureRate, s.Allowed, s.Blocked, s.IntervalSeq, s.Reason)
+
+		// Flushed every row, so it appears while the watch goes on.
+		return t.Flush()
+	}
+}
+
+// snapshotRow writes s as a row under snapshotHeader.
+func snapshotRow(w io.Writer, name string, s nozzle.StateSnapshot) {
+	override := "-"
+
+	switch {
+	case s.OverrideReason != "" && s.OverrideRemaining > 0:
+		override = fmt.Sprintf("%s (%s left)", s.OverrideReason, s.OverrideRemaining.Round(time.Second))
+	case s.OverrideReason != "":
+		override = s.OverrideReason
+	}
+
+	fmt.Fprintf(w, "%s\t%s\t%d%%\t%d%%\t%d\t%d\t%d\t%s\n",
+		name, s.State, s.FlowRate, s.FailureRate, s.Allowed, s.Blocked, s.IntervalSeq, override)
+}
+
+// table returns a tabwriter aligning columns on p.w.
+func (p printer) table() *tabwriter.Writer {
+	return tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+}
+
+// encode writes v as indented JSON.
+func (p printer) encode(v any) error {
+	e := json.NewEncoder(p.w)
+	e.SetIndent("", "  ")
+
+	return e.Encode(v)
+}
@@ -0,0 +1,41 @@
+package nozzle
+
+import "fmt"
+
+// CompatLevel selects a documented version of the behaviors that changed over time, see Options.CompatLevel.
+// Each level keeps every behavior of the levels before it, except for the changes listed on it.
+type CompatLevel int
+
+const (
+	// CompatLatest uses the newest behaviors. It is the zero value, so Nozzles that do not pin a level change behavior when upgraded.
+	CompatLatest CompatLevel = 0
+
+	// Compat1 is the original behavior.
+	Compat1 CompatLevel = 1
+
+	// Compat2 stops counting calls made with DoErrorContext that end because the caller's context is done as failures.
+	// They are counted as Stats.CallerCanceled instead. At Compat1, they are failures.
+	Compat2 CompatLevel = 2
+
+	// CurrentCompatLevel is the newest level, which CompatLatest uses.
+	CurrentCompatLevel = Compat2
+)
+
+// compatLevel returns Options.CompatLevel, or CurrentCompatLevel for CompatLatest and unknown levels.
+func (n *Nozzle[T]) compatLevel() CompatLevel {
+	if level := n.options().CompatLevel; level > CompatLatest && level <= CurrentCompatLevel {
+		return level
+	}
+
+	return CurrentCompatLevel
+}
+
+// startedCompat reports the active CompatLevel, so upgrades can be checked against it.
+func (n *Nozzle[T]) startedCompat() {
+	n.emit(Event{
+		Type:   EventCompatLevel,
+		Time:   n.now(),
+		State:  n.State(),
+		Reason: fmt.Sprintf("compat level %d", n.compatLevel()),
+	})
+}
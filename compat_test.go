@@ -0,0 +1,65 @@
+package nozzle_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestCompatLevel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		level          nozzle.CompatLevel
+		active         nozzle.CompatLevel
+		failures       int64
+		callerCanceled int64
+	}{
+		{level: nozzle.CompatLatest, active: nozzle.CurrentCompatLevel, failures: 0, callerCanceled: 1},
+		{level: nozzle.Compat1, active: nozzle.Compat1, failures: 1, callerCanceled: 0},
+		{level: nozzle.Compat2, active: nozzle.Compat2, failures: 0, callerCanceled: 1},
+		{level: 99, active: nozzle.CurrentCompatLevel, failures: 0, callerCanceled: 1},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			var reasons []string
+
+			noz := nozzle.New(nozzle.Options[any]{
+				Interval:              time.Hour,
+				AllowedFailurePercent: 50,
+				CompatLevel:           test.level,
+				OnEvent: func(e nozzle.Event) {
+					if e.Type == nozzle.EventCompatLevel {
+						reasons = append(reasons, e.Reason)
+					}
+				},
+			})
+			defer noz.Close() //nolint:errcheck
+
+			if expected := fmt.Sprintf("compat level %d", test.active); len(reasons) != 1 || reasons[0] != expected {
+				t.Errorf("Expected one %s event with Reason=%q Got=%q", nozzle.EventCompatLevel, expected, reasons)
+			}
+
+			if l := noz.DescribeConfig().CompatLevel; l != test.active {
+				t.Errorf("Expected CompatLevel=%d Got=%d", test.active, l)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			noz.DoErrorContext(ctx, func(ctx context.Context) (any, error) { //nolint:errcheck
+				return nil, ctx.Err()
+			})
+
+			if s := noz.Stats(); s.Failures != test.failures || s.CallerCanceled != test.callerCanceled {
+				t.Errorf("Expected Failures=%d CallerCanceled=%d Got Failures=%d CallerCanceled=%d", test.failures, test.callerCanceled, s.Failures, s.CallerCanceled)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package nozzle
+
+import "context"
+
+// nozzleKey is the context key of a *Nozzle[T]. It is generic, so Nozzles of different types never collide.
+type nozzleKey[T any] struct{}
+
+// registryKey is the context key of a *Registry[T].
+type registryKey[T any] struct{}
+
+// WithNozzle returns a copy of ctx that carries n, for FromContext to retrieve in deeper call sites.
+// Middleware can use it to attach a per-request or per-tenant Nozzle that downstream layers honor, without passing it through every function.
+// A ctx carries one Nozzle per type T; WithNozzle replaces the one ctx already carries.
+//
+// Example:
+//
+//	func tenantMiddleware(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			n, _ := tenants.Get(tenantID(r), options)
+//			next.ServeHTTP(w, r.WithContext(nozzle.WithNozzle(r.Context(), n)))
+//		})
+//	}
+func WithNozzle[T any](ctx context.Context, n *Nozzle[T]) context.Context {
+	return context.WithValue(ctx, nozzleKey[T]{}, n)
+}
+
+// FromContext returns the Nozzle of type T carried by ctx, and whether there was one.
+//
+// Example:
+//
+//	func (s *Store) Load(ctx context.Context, id string) (*Profile, error) {
+//		n, ok := nozzle.FromContext[*Profile](ctx)
+//		if !ok {
+//			return s.load(ctx, id)
+//		}
+//
+//		return n.DoErrorContext(ctx, func(ctx context.Context) (*Profile, error) {
+//			return s.load(ctx, id)
+//		})
+//	}
+func FromContext[T any](ctx context.Context) (*Nozzle[T], bool) {
+	n, ok := ctx.Value(nozzleKey[T]{}).(*Nozzle[T])
+
+	return n, ok && n != nil
+}
+
+// WithRegistry returns a copy of ctx that carries r, for RegistryFromContext to retrieve in deeper call sites.
+// Use it instead of WithNozzle when downstream layers pick a Nozzle by name, such as one per dependency.
+//
+// Example:
+//
+//	handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		mux.ServeHTTP(w, r.WithContext(nozzle.WithRegistry(r.Context(), registry)))
+//	})
+func WithRegistry[T any](ctx context.Context, r *Registry[T]) context.Context {
+	return context.WithValue(ctx, registryKey[T]{}, r)
+}
+
+// RegistryFromContext returns the Registry of type T carried by ctx, and whether there was one.
+//
+// Example:
+//
+//	if registry, ok := nozzle.RegistryFromContext[*http.Response](ctx); ok {
+//		payments := registry.Nozzle("payments")
+//	}
+func RegistryFromContext[T any](ctx context.Context) (*Registry[T], bool) {
+	r, ok := ctx.Value(registryKey[T]{}).(*Registry[T])
+
+	return r, ok && r != nil
+}
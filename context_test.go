@@ -0,0 +1,51 @@
+package nozzle_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	if _, ok := nozzle.FromContext[any](ctx); ok {
+		t.Error("Expected no Nozzle in an empty context")
+	}
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	ctx = nozzle.WithNozzle(ctx, noz)
+
+	if n, ok := nozzle.FromContext[any](ctx); !ok || n != noz {
+		t.Errorf("Expected the attached Nozzle Got=%p ok=%t", n, ok)
+	}
+
+	// Nozzles of different types do not collide.
+	if _, ok := nozzle.FromContext[string](ctx); ok {
+		t.Error("Expected no Nozzle of type string")
+	}
+
+	if _, ok := nozzle.FromContext[any](nozzle.WithNozzle[any](ctx, nil)); ok {
+		t.Error("Expected a nil Nozzle to be reported as missing")
+	}
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	if _, ok := nozzle.RegistryFromContext[any](ctx); ok {
+		t.Error("Expected no Registry in the context")
+	}
+
+	if r, ok := nozzle.RegistryFromContext[any](nozzle.WithRegistry(ctx, registry)); !ok || r != registry {
+		t.Errorf("Expected the attached Registry Got=%p ok=%t", r, ok)
+	}
+}
@@ -0,0 +1,53 @@
+package nozzle
+
+import "context"
+
+// decisionKey is the context key DecisionID looks up.
+type decisionKey struct{}
+
+// callContext is the context passed to the callbacks of DoBoolContext and DoErrorContext.
+// It marks the call as being inside a specific Nozzle, see Options.Reentrancy, and carries the call's decision ID.
+// Carrying both in one context costs a single allocation per call.
+type callContext struct {
+	context.Context
+
+	// nozzle is the Nozzle whose callback receives the context.
+	nozzle any
+
+	// decision is the ID of the decision that admitted the call.
+	decision uint64
+}
+
+// Value implements context.Context.
+func (c *callContext) Value(key any) any {
+	switch key {
+	case decisionKey{}:
+		return c.decision
+	case reentrancyKey{nozzle: c.nozzle}:
+		return struct{}{}
+	default:
+		return c.Context.Value(key)
+	}
+}
+
+// DecisionID reports the ID of the decision that admitted the call ctx was passed into.
+// It finds the context given to a DoBoolContext or DoErrorContext callback, or any context derived from it.
+// When Nozzles are nested, the innermost call's decision is reported.
+//
+// Each Nozzle numbers its admission decisions, allowed and blocked alike, starting at 1.
+// Blocked calls report their ID through BlockedError, and every IntervalStats includes the range of IDs decided during that interval.
+// Together, they let a distributed trace be joined to the exact interval and flow rate that governed a call.
+//
+// Example:
+//
+//	n.DoErrorContext(ctx, func(ctx context.Context) (any, error) {
+//		if id, ok := nozzle.DecisionID(ctx); ok {
+//			span.SetAttributes(attribute.Int64("nozzle.decision", int64(id)))
+//		}
+//		return callDependency(ctx)
+//	})
+func DecisionID(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(decisionKey{}).(uint64)
+
+	return id, ok
+}
@@ -0,0 +1,78 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestDecisionID(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+
+	if _, ok := nozzle.DecisionID(context.Background()); ok {
+		t.Error("Expected no DecisionID outside of a callback")
+	}
+
+	for expected := uint64(1); expected <= 3; expected++ {
+		noz.DoErrorContext(context.Background(), func(ctx context.Context) (any, error) {
+			if id, ok := nozzle.DecisionID(ctx); !ok || id != expected {
+				t.Errorf("Expected DecisionID=%d Got=%d ok=%v", expected, id, ok)
+			}
+
+			return nil, nil
+		})
+	}
+
+	noz.ForceCloseFor(time.Hour, "test")
+
+	_, err := noz.DoError(func() (any, error) {
+		return nil, nil
+	})
+
+	if !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrBlocked, err)
+	}
+
+	var blocked *nozzle.BlockedError
+	if !errors.As(err, &blocked) || blocked.DecisionID != 4 {
+		t.Errorf("Expected BlockedError DecisionID=4 Got=%v", err)
+	}
+}
+
+func TestIntervalDecisionIDs(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+	})
+
+	noz.Wait()
+
+	for range 3 {
+		noz.DoBool(func() (any, bool) {
+			return nil, true
+		})
+	}
+
+	noz.Wait()
+
+	history := noz.History()
+	last := history[len(history)-1]
+
+	if last.FirstDecisionID != 1 || last.LastDecisionID != 3 {
+		t.Errorf("Expected decisions 1-3 Got=%d-%d", last.FirstDecisionID, last.LastDecisionID)
+	}
+
+	if first := history[0]; len(history) > 1 && (first.FirstDecisionID != 0 || first.LastDecisionID != 0) {
+		t.Errorf("Expected no decisions Got=%d-%d", first.FirstDecisionID, first.LastDecisionID)
+	}
+}
@@ -0,0 +1,253 @@
+package nozzle
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ConfigDescription is the effective configuration of a Nozzle.
+// Unlike Options, defaults are filled in, and the active manual override is included, so it describes exactly how the Nozzle is behaving.
+// Callback options cannot be described, so Hooks only lists which ones are set.
+type ConfigDescription struct {
+	// Interval is Options.Interval.
+	Interval time.Duration
+
+	// AllowedFailurePercent is Options.AllowedFailurePercent.
+	AllowedFailurePercent int64
+
+	// ThresholdInclusive is Options.ThresholdInclusive.
+	ThresholdInclusive bool
+
+	// DisableClosing is Options.DisableClosing.
+	DisableClosing bool
+
+	// StrictMode is Options.StrictMode.
+	StrictMode bool
+
+	// Reentrancy is Options.Reentrancy.
+	Reentrancy ReentrancyPolicy
+
+	// StartingFlowRate is the flow rate the Nozzle started with.
+	// It accounts for Options.InitialFlowRate, Options.WarmStart, and Options.WarmUp.
+	StartingFlowRate int64
+
+	// WarmStartTimeout is Options.WarmStartTimeout, or DefaultWarmStartTimeout.
+	// It is zero when Options.WarmStart is not set.
+	WarmStartTimeout time.Duration
+
+	// WarmUp is Options.WarmUp.
+	WarmUp time.Duration
+
+	// WarmUpFlowRate is Options.WarmUpFlowRate, or DefaultWarmUpFlowRate.
+	// It is zero when Options.WarmUp is not set.
+	WarmUpFlowRate int64
+
+	// Strategy is the type of the Strategy that moves the flow rate.
+	// Example: "*engine.Exponential" when Options.Strategy is nil.
+	Strategy string
+
+	// HysteresisBand is Options.HysteresisBand.
+	HysteresisBand int64
+
+	// MinIntervalsBeforeReverse is Options.MinIntervalsBeforeReverse.
+	MinIntervalsBeforeReverse int
+
+	// ReopenCooldown is Options.ReopenCooldown.
+	ReopenCooldown time.Duration
+
+	// FailureWindow is Options.FailureWindow.
+	FailureWindow int
+
+	// FailureWindowWeights is Options.FailureWindowWeights.
+	FailureWindowWeights []float64
+
+	// SlowFailureWindow is Options.SlowFailureWindow.
+	SlowFailureWindow int
+
+	// FailureSmoothing is Options.FailureSmoothing.
+	FailureSmoothing float64
+
+	// MaxQueueDepth is Options.MaxQueueDepth.
+	MaxQueueDepth int64
+
+	// MaxConcurrent is Options.MaxConcurrent.
+	MaxConcurrent int64
+
+	// ClosedPanic is Options.ClosedPanic.
+	ClosedPanic bool
+
+	// MemoryBudget is Options.MemoryBudget.
+	MemoryBudget int64
+
+	// CalculateBudget is Options.CalculateBudget, or a tenth of Interval when it is not set.
+	CalculateBudget time.Duration
+
+	// TrackFairness is Options.TrackFairness.
+	TrackFairness bool
+
+	// ReservationTTL is Options.ReservationTTL.
+	ReservationTTL int
+
+	// CompatLevel is the active Options.CompatLevel, never CompatLatest.
+	CompatLevel CompatLevel
+
+	// PanicPolicy is Options.PanicPolicy.
+	PanicPolicy PanicPolicy
+
+	// ClusterReplica is Options.Cluster.Replica, or empty when no Cluster.Transport is set.
+	ClusterReplica string
+
+	// ProfileLabel is Options.ProfileLabel.
+	ProfileLabel string
+
+	// VerifyInterval is how often Options.Verify runs, or zero when it is not set.
+	VerifyInterval time.Duration
+
+	// RetryMaxAttempts is Options.Retry.MaxAttempts.
+	RetryMaxAttempts int
+
+	// RetryBudget is Options.Retry.Budget.
+	RetryBudget int64
+
+	// MaxWait is Options.MaxWait.
+	MaxWait time.Duration
+
+	// SharedScheduler reports whether the Nozzle is ticked by an Options.Scheduler instead of its own goroutine.
+	SharedScheduler bool
+
+	// ManualTick is Options.ManualTick.
+	ManualTick bool
+
+	// Hooks lists the callback options that are set, by name, in the order they are declared in Options.
+	// Example: []string{"OnStateChange", "Logger"}
+	Hooks []string
+
+	// Override is the state of the active manual override, or empty when there is none.
+	Override State
+
+	// OverrideReason is the reason given for the active manual override.
+	OverrideReason string
+
+	// OverrideRemaining is how long until the active manual override expires.
+	// It is zero when there is no override, or when the override does not expire.
+	OverrideRemaining time.Duration
+}
+
+// DescribeConfig reports the Nozzle's effective configuration.
+// Use it to audit how a Nozzle is configured in production, for example by logging it at startup or serving it from an admin endpoint.
+//
+// Example:
+//
+//	slog.Info("nozzle configured", "config", n.DescribeConfig())
+func (n *Nozzle[T]) DescribeConfig() ConfigDescription {
+	if n.passThrough() {
+		return ConfigDescription{}
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	o := *n.options()
+
+	d := ConfigDescription{
+		Interval:                  o.Interval,
+		AllowedFailurePercent:     o.AllowedFailurePercent,
+		ThresholdInclusive:        o.ThresholdInclusive,
+		DisableClosing:            o.DisableClosing,
+		StrictMode:                o.StrictMode,
+		Reentrancy:                o.Reentrancy,
+		StartingFlowRate:          n.startingFlowRate,
+		WarmUp:                    o.WarmUp,
+		Strategy:                  fmt.Sprintf("%T", o.Strategy),
+		HysteresisBand:            o.HysteresisBand,
+		MinIntervalsBeforeReverse: o.MinIntervalsBeforeReverse,
+		ReopenCooldown:            o.ReopenCooldown,
+		FailureWindow:             o.FailureWindow,
+		FailureWindowWeights:      slices.Clone(o.FailureWindowWeights),
+		SlowFailureWindow:         o.SlowFailureWindow,
+		FailureSmoothing:          o.FailureSmoothing,
+		MaxQueueDepth:             o.MaxQueueDepth,
+		MaxConcurrent:             o.MaxConcurrent,
+		ClosedPanic:               o.ClosedPanic,
+		MemoryBudget:              o.MemoryBudget,
+		CalculateBudget:           n.calculateBudget(),
+		TrackFairness:             o.TrackFairness,
+		ReservationTTL:            o.ReservationTTL,
+		CompatLevel:               n.compatLevel(),
+		PanicPolicy:               o.PanicPolicy,
+		ProfileLabel:              o.ProfileLabel,
+		RetryMaxAttempts:          o.Retry.MaxAttempts,
+		RetryBudget:               o.Retry.Budget,
+		MaxWait:                   o.MaxWait,
+		SharedScheduler:           o.Scheduler != nil && !o.ManualTick,
+		ManualTick:                o.ManualTick,
+		Hooks:                     []string{},
+	}
+
+	if o.Strategy == nil {
+		d.Strategy = fmt.Sprintf("%T", &Exponential{})
+	}
+
+	if o.WarmStart != nil {
+		d.WarmStartTimeout = o.WarmStartTimeout
+		if d.WarmStartTimeout <= 0 {
+			d.WarmStartTimeout = DefaultWarmStartTimeout
+		}
+	}
+
+	if o.WarmUp > 0 {
+		d.WarmUpFlowRate = n.warmUpFlowRate()
+	}
+
+	if o.Cluster.Transport != nil {
+		d.ClusterReplica = o.Cluster.Replica
+	}
+
+	if o.Verify != nil {
+		d.VerifyInterval = o.VerifyInterval
+		if d.VerifyInterval <= 0 {
+			d.VerifyInterval = o.Interval
+		}
+	}
+
+	hooks := []struct {
+		name string
+		set  bool
+	}{
+		{name: "OnStateChange", set: o.OnStateChange != nil},
+		{name: "OnFullyClosed", set: o.OnFullyClosed != nil},
+		{name: "OnFullyOpened", set: o.OnFullyOpened != nil},
+		{name: "Logger", set: o.Logger != nil},
+		{name: "WarmStart", set: o.WarmStart != nil},
+		{name: "OnEvent", set: o.OnEvent != nil},
+		{name: "SLAImpacting", set: o.SLAImpacting != nil},
+		{name: "IsFailure", set: o.IsFailure != nil},
+		{name: "TraceID", set: o.TraceID != nil},
+		{name: "QueueDepth", set: o.QueueDepth != nil},
+		{name: "OnInvariantViolation", set: o.OnInvariantViolation != nil},
+		{name: "OnIntervalEnd", set: o.OnIntervalEnd != nil},
+		{name: "Verify", set: o.Verify != nil},
+		{name: "Audit", set: o.Audit != nil},
+		{name: "Clock", set: o.Clock != nil},
+		{name: "Maintenance", set: o.Maintenance != nil},
+		{name: "OnPanic", set: o.OnPanic != nil},
+	}
+
+	for _, hook := range hooks {
+		if hook.set {
+			d.Hooks = append(d.Hooks, hook.name)
+		}
+	}
+
+	if forced := n.forced(); forced != "" {
+		d.Override = forced
+		d.OverrideReason = n.overrideReason
+
+		if !n.overrideUntil.IsZero() {
+			d.OverrideRemaining = n.overrideUntil.Sub(n.now())
+		}
+	}
+
+	return d
+}
@@ -0,0 +1,62 @@
+package nozzle_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestDescribeConfig(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 20,
+		WarmUp:                time.Minute,
+		FailureWindow:         2,
+		FailureWindowWeights:  []float64{1, 0.5},
+		OnEvent:               func(nozzle.Event) {},
+		IsFailure:             func(error) bool { return true },
+	})
+	defer noz.Close() //nolint:errcheck
+
+	d := noz.DescribeConfig()
+
+	if d.Interval != time.Hour || d.AllowedFailurePercent != 20 {
+		t.Errorf("Expected Interval=%s AllowedFailurePercent=20 Got Interval=%s AllowedFailurePercent=%d", time.Hour, d.Interval, d.AllowedFailurePercent)
+	}
+
+	if d.StartingFlowRate != nozzle.DefaultWarmUpFlowRate || d.WarmUpFlowRate != nozzle.DefaultWarmUpFlowRate {
+		t.Errorf("Expected StartingFlowRate=%d WarmUpFlowRate=%d Got StartingFlowRate=%d WarmUpFlowRate=%d", nozzle.DefaultWarmUpFlowRate, nozzle.DefaultWarmUpFlowRate, d.StartingFlowRate, d.WarmUpFlowRate)
+	}
+
+	if d.Strategy != "*engine.Exponential" {
+		t.Errorf("Expected Strategy=%q Got=%q", "*engine.Exponential", d.Strategy)
+	}
+
+	if expected := []float64{1, 0.5}; !slices.Equal(d.FailureWindowWeights, expected) {
+		t.Errorf("Expected FailureWindowWeights=%v Got=%v", expected, d.FailureWindowWeights)
+	}
+
+	if expected := []string{"OnEvent", "IsFailure"}; !slices.Equal(d.Hooks, expected) {
+		t.Errorf("Expected Hooks=%v Got=%v", expected, d.Hooks)
+	}
+
+	if d.Override != "" {
+		t.Errorf("Expected no Override Got=%s", d.Override)
+	}
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	d = noz.DescribeConfig()
+
+	if d.Override != nozzle.ForcedClosed || d.OverrideReason != "maintenance" {
+		t.Errorf("Expected Override=%s OverrideReason=%q Got Override=%s OverrideReason=%q", nozzle.ForcedClosed, "maintenance", d.Override, d.OverrideReason)
+	}
+
+	if d.OverrideRemaining <= 0 || d.OverrideRemaining > time.Hour {
+		t.Errorf("Expected 0 < OverrideRemaining <= %s Got=%s", time.Hour, d.OverrideRemaining)
+	}
+}
@@ -0,0 +1,50 @@
+package nozzle
+
+import "context"
+
+// Do2 is like DoError, for callbacks that return two results, such as a response and its metadata, so they need not be packed into one struct.
+// It is a function rather than a method, since methods cannot declare type parameters; n can be a Nozzle of any T, such as a Nozzle[any].
+// The callback's results are returned even when it fails, as DoError does. If n blocks the call, they are zero and the error is ErrBlocked.
+//
+// Example:
+//
+//	user, etag, err := nozzle.Do2(n, func() (User, string, error) {
+//		return client.GetUser(id)
+//	})
+func Do2[T, A, B any](n *Nozzle[T], callback func() (A, B, error)) (A, B, error) {
+	var a A
+	var b B
+
+	err := n.Do(func() error {
+		var err error
+
+		a, b, err = callback()
+
+		return err
+	})
+
+	return a, b, err
+}
+
+// Do2Context is like DoErrorContext, for callbacks that return two results.
+// See Do2.
+//
+// Example:
+//
+//	user, etag, err := nozzle.Do2Context(ctx, n, func(ctx context.Context) (User, string, error) {
+//		return client.GetUserContext(ctx, id)
+//	})
+func Do2Context[T, A, B any](ctx context.Context, n *Nozzle[T], callback func(context.Context) (A, B, error)) (A, B, error) {
+	var a A
+	var b B
+
+	err := n.DoContext(ctx, func(ctx context.Context) error {
+		var err error
+
+		a, b, err = callback(ctx)
+
+		return err
+	})
+
+	return a, b, err
+}
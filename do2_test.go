@@ -0,0 +1,48 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestDo2(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	res, meta, err := nozzle.Do2(noz, func() (int, string, error) {
+		return 1, "etag", nil
+	})
+	if res != 1 || meta != "etag" || err != nil {
+		t.Errorf("Expected res=1 meta=etag err=nil Got res=%d meta=%s err=%v", res, meta, err)
+	}
+
+	// A failing callback's results are still returned.
+	res, meta, err = nozzle.Do2Context(context.Background(), noz, func(context.Context) (int, string, error) {
+		return 2, "partial", ErrNotAllowed
+	})
+	if res != 2 || meta != "partial" || !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Expected res=2 meta=partial err=%v Got res=%d meta=%s err=%v", ErrNotAllowed, res, meta, err)
+	}
+
+	if s := noz.Stats(); s.Successes != 1 || s.Failures != 1 {
+		t.Errorf("Expected Successes=1 Failures=1 Got Successes=%d Failures=%d", s.Successes, s.Failures)
+	}
+
+	noz.ForceClose("test")
+
+	res, meta, err = nozzle.Do2(noz, func() (int, string, error) {
+		return 3, "ran", nil
+	})
+	if res != 0 || meta != "" || !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected res=0 meta= err=%v Got res=%d meta=%s err=%v", nozzle.ErrBlocked, res, meta, err)
+	}
+}
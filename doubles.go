@@ -0,0 +1,91 @@
+package nozzle
+
+import "context"
+
+// Noop returns a Nozzler that allows every call and records nothing.
+// Use it in tests that are not about shedding, or where a feature flag disables shedding.
+// Its FlowRate is always 100 and its State is always Opening.
+//
+// Example:
+//
+//	var n nozzle.Nozzler[*Receipt] = nozzle.Noop[*Receipt]()
+//	if !flags.Shedding {
+//		n = nozzle.New(options)
+//	}
+func Noop[T any]() Nozzler[T] {
+	return noop[T]{}
+}
+
+// AlwaysBlocked returns a Nozzler that blocks every call without running it.
+// Use it to test how callers handle a fully closed dependency.
+// DoError and DoErrorContext return a *BlockedError, which wraps ErrBlocked.
+// Its FlowRate is always 0 and its State is always Closing.
+//
+// Example:
+//
+//	client := PaymentsClient{nozzle: nozzle.AlwaysBlocked[*Receipt]()}
+//	if _, err := client.Charge(ctx); !errors.Is(err, nozzle.ErrBlocked) {
+//		t.Errorf("Expected err=%v Got=%v", nozzle.ErrBlocked, err)
+//	}
+func AlwaysBlocked[T any]() Nozzler[T] {
+	return alwaysBlocked[T]{}
+}
+
+type noop[T any] struct{}
+
+func (noop[T]) DoBool(callback func() (T, bool)) (T, bool) {
+	return callback()
+}
+
+func (noop[T]) DoError(callback func() (T, error)) (T, error) {
+	return callback()
+}
+
+func (noop[T]) DoErrorContext(ctx context.Context, callback func(context.Context) (T, error)) (T, error) {
+	return callback(ctx)
+}
+
+func (noop[T]) FlowRate() int64 {
+	return 100
+}
+
+func (noop[T]) State() State {
+	return Opening
+}
+
+func (noop[T]) Close() error {
+	return nil
+}
+
+type alwaysBlocked[T any] struct{}
+
+func (alwaysBlocked[T]) DoBool(func() (T, bool)) (T, bool) {
+	return *new(T), false
+}
+
+func (b alwaysBlocked[T]) DoError(func() (T, error)) (T, error) {
+	return *new(T), b.blocked()
+}
+
+func (b alwaysBlocked[T]) DoErrorContext(context.Context, func(context.Context) (T, error)) (T, error) {
+	return *new(T), b.blocked()
+}
+
+func (alwaysBlocked[T]) FlowRate() int64 {
+	return 0
+}
+
+func (alwaysBlocked[T]) State() State {
+	return Closing
+}
+
+func (alwaysBlocked[T]) Close() error {
+	return nil
+}
+
+func (alwaysBlocked[T]) blocked() *BlockedError {
+	return &BlockedError{
+		FlowRate: 0,
+		State:    Closing,
+	}
+}
@@ -0,0 +1,58 @@
+package nozzle_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestNoop(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.Noop[int]()
+
+	failure := errors.New("failure")
+
+	for range 100 {
+		if res, ok := noz.DoBool(func() (int, bool) { return 1, false }); res != 1 || ok {
+			t.Errorf("Expected res=1 ok=false Got res=%d ok=%t", res, ok)
+		}
+
+		if _, err := noz.DoError(func() (int, error) { return 0, failure }); !errors.Is(err, failure) {
+			t.Errorf("Expected err=%v Got=%v", failure, err)
+		}
+	}
+
+	// Failures are not recorded, so the Noop never closes.
+	if noz.FlowRate() != 100 || noz.State() != nozzle.Opening {
+		t.Errorf("Expected FlowRate=100 State=%s Got FlowRate=%d State=%s", nozzle.Opening, noz.FlowRate(), noz.State())
+	}
+}
+
+func TestAlwaysBlocked(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.AlwaysBlocked[int]()
+
+	var calls int
+
+	if _, ok := noz.DoBool(func() (int, bool) { calls++; return 1, true }); ok {
+		t.Errorf("Expected ok=false Got=%t", ok)
+	}
+
+	_, err := noz.DoError(func() (int, error) { calls++; return 1, nil })
+
+	var blocked *nozzle.BlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrBlocked, err)
+	}
+
+	if calls != 0 {
+		t.Errorf("Expected calls=0 Got=%d", calls)
+	}
+
+	if noz.FlowRate() != 0 || noz.State() != nozzle.Closing {
+		t.Errorf("Expected FlowRate=0 State=%s Got FlowRate=%d State=%s", nozzle.Closing, noz.FlowRate(), noz.State())
+	}
+}
@@ -0,0 +1,19 @@
+package nozzle
+
+// edge records the effective flowRate, and returns Options.OnFullyClosed or Options.OnFullyOpened when it crosses one of their edges, or nil.
+// The hooks alternate: OnFullyClosed when the flow rate reaches 0, then OnFullyOpened once it is back at 100.
+// The caller must hold the lock, and call the returned hook after releasing it.
+func (n *Nozzle[T]) edge(flowRate int64) func() {
+	switch {
+	case flowRate <= 0 && !n.fullyClosed:
+		n.fullyClosed = true
+
+		return n.options().OnFullyClosed
+	case flowRate >= 100 && n.fullyClosed:
+		n.fullyClosed = false
+
+		return n.options().OnFullyOpened
+	default:
+		return nil
+	}
+}
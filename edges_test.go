@@ -0,0 +1,79 @@
+package nozzle_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestFullyClosedAndOpened(t *testing.T) {
+	t.Parallel()
+
+	var closed, opened atomic.Int64
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+		OnFullyClosed: func() {
+			closed.Add(1)
+		},
+		OnFullyOpened: func() {
+			opened.Add(1)
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	expect := func(step string, c, o int64) {
+		t.Helper()
+
+		if closed.Load() != c || opened.Load() != o {
+			t.Errorf("%s Expected closed=%d opened=%d Got closed=%d opened=%d", step, c, o, closed.Load(), opened.Load())
+		}
+	}
+
+	expect("start", 0, 0)
+
+	noz.ForceClose("maintenance")
+	expect("force-close", 1, 0)
+
+	// Edge-triggered: staying closed does not call OnFullyClosed again.
+	noz.ForceClose("still maintenance")
+	clock.Advance(time.Second)
+	noz.Tick()
+	expect("still closed", 1, 0)
+
+	noz.Unforce()
+	expect("unforce", 1, 1)
+
+	noz.ForceOpen("drill")
+	noz.Unforce()
+	expect("already open", 1, 1)
+
+	// Failing every call closes the Nozzle on its own, one interval at a time.
+	for noz.FlowRate() > 0 {
+		noz.ReportFailure()
+		clock.Advance(time.Second)
+		noz.Tick()
+	}
+
+	expect("closed by failures", 2, 1)
+
+	// Succeeding reopens it, but OnFullyOpened waits for the flow rate to reach 100.
+	for noz.FlowRate() < 100 {
+		if opened.Load() != 1 {
+			t.Fatalf("Expected OnFullyOpened to wait for FlowRate=100 Got=%d", noz.FlowRate())
+		}
+
+		noz.ReportSuccess()
+		clock.Advance(time.Second)
+		noz.Tick()
+	}
+
+	expect("opened by successes", 2, 2)
+}
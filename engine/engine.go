@@ -0,0 +1,709 @@
+// Package engine is the decision core of a Nozzle.
+// It decides which calls to admit, and how the flow rate adapts at the end of each interval.
+//
+// An Engine starts no goroutines, takes no locks, and never reads the clock.
+// The caller owns concurrency and time: it must serialize every call to the Engine, and it decides when an interval ends.
+// This lets the same algorithm run inside programs with a different concurrency model, such as a proxy built around an event loop.
+//
+// Example:
+//
+//	e := engine.New(engine.Config{AllowedFailurePercent: 50}, 100)
+//
+//	// For every call:
+//	if e.Admit() {
+//		if err := call(); err != nil {
+//			e.Record(0, 1)
+//		} else {
+//			e.Record(1, 0)
+//		}
+//	}
+//
+//	// At the end of every interval:
+//	e.Adapt()
+//	e.Reset()
+//
+// The nozzle package wraps an Engine with a mutex and a ticker; most programs should use nozzle.Nozzle instead.
+package engine
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// State describes the direction the flow rate is moving.
+type State string
+
+const (
+	// Opening means the flow rate is increasing.
+	Opening State = "opening"
+
+	// Closing means the flow rate is decreasing.
+	Closing State = "closing"
+)
+
+// Config controls how an Engine adapts.
+// Each field behaves like the nozzle.Options field of the same name.
+type Config struct {
+	// AllowedFailurePercent is the failure rate above which the Engine closes.
+	AllowedFailurePercent int64
+
+	// ThresholdInclusive closes the Engine when the failure rate equals AllowedFailurePercent, not only when it exceeds it.
+	// A failure rate of 0 never closes the Engine, so a threshold of 0 still reopens once nothing fails.
+	ThresholdInclusive bool
+
+	// DisableClosing prevents the Engine from ever closing.
+	DisableClosing bool
+
+	// StrictMode closes the Engine on any failure, regardless of AllowedFailurePercent.
+	StrictMode bool
+
+	// HysteresisBand widens AllowedFailurePercent into a band, so a failure rate sitting on the threshold does not flip the state every interval.
+	HysteresisBand int64
+
+	// MinIntervalsBeforeReverse is the minimum number of consecutive intervals the Engine stays in a state after reversing.
+	MinIntervalsBeforeReverse int
+
+	// Window is the number of intervals, including the current one, whose outcomes are combined to decide the direction.
+	// If zero or one, only the current interval is used.
+	Window int
+
+	// WindowWeights weighs each interval of Window, newest first, starting with the current interval.
+	// Intervals without a weight weigh 1, and negative weights count as 0.
+	// If empty, every interval of Window weighs the same.
+	WindowWeights []float64
+
+	// SlowWindow is the number of intervals in a second, longer window that is evaluated alongside the first.
+	// The Engine closes if either window exceeds the threshold, so it reacts quickly to outages but only reopens after sustained health.
+	// If zero or one, only the first window is used.
+	SlowWindow int
+
+	// Smoothing weighs the current interval's failure rate against an exponentially weighted moving average of the previous intervals.
+	// It must be between 0 and 1; 1 uses only the current interval.
+	// If zero, or if Window is greater than one, no smoothing is applied.
+	Smoothing float64
+
+	// Strategy decides the next flow rate once the Engine has decided its direction.
+	// If nil, a new Exponential strategy is used.
+	Strategy Strategy
+}
+
+// Engine holds the state of the adaptation algorithm.
+// It is not safe for concurrent use.
+type Engine struct {
+	// config controls how the Engine adapts.
+	config Config
+
+	// strategy is config.Strategy, or an Exponential strategy if none was given.
+	strategy Strategy
+
+	// flowRate indicates the percentage of allowed operations.
+	// Example: A flowRate of 100 means all operations are allowed, while a flowRate of 0 means none are allowed.
+	flowRate int64
+
+	// state represents whether the flow rate is currently opening or closing.
+	state State
+
+	// reason explains the most recent decision.
+	// Example: "failure rate 30% > 20%"
+	reason string
+
+	// intervalsInState counts the consecutive intervals spent in the current state since the last reversal.
+	// It stays at zero until the first reversal, so a new Engine can react immediately.
+	intervalsInState int
+
+	// successes counts the successful operations in the current interval.
+	successes int64
+
+	// failures counts the failed operations in the current interval.
+	failures int64
+
+	// allowed counts the operations allowed in the current interval.
+	allowed int64
+
+	// blocked counts the operations blocked in the current interval.
+	blocked int64
+
+	// overload explains why the current interval is overloaded, or is empty when it is not.
+	// See Engine.Overload.
+	overload string
+
+	// classes counts the operations allowed and blocked in the current interval for each Priority, lowest first.
+	// Each Priority is admitted at its own share of the flow rate, so each keeps its own ratio.
+	classes [3]admissions
+
+	// recent holds the outcomes of the most recent completed intervals, oldest first.
+	// It holds at most one less than the larger of Config.Window and Config.SlowWindow.
+	recent []outcomes
+
+	// average is the smoothed failure rate of the completed intervals.
+	// See Config.Smoothing.
+	average float64
+
+	// averaged is false until the first interval completes, so the first average is not blended with zero.
+	averaged bool
+}
+
+// outcomes are the successes and failures of a single completed interval.
+type outcomes struct {
+	successes int64
+	failures  int64
+}
+
+// New creates an Engine that starts Opening at flowRate.
+// flowRate is clamped to [0, 100].
+func New(config Config, flowRate int64) *Engine {
+	strategy := config.Strategy
+	if strategy == nil {
+		strategy = &Exponential{}
+	}
+
+	return &Engine{
+		config:   config,
+		strategy: strategy,
+		flowRate: Clamp(flowRate),
+		state:    Opening,
+	}
+}
+
+// Admit decides whether a call may proceed and counts the decision.
+// It compares the share of calls allowed so far in the interval with the flow rate.
+// Example: At a flow rate of 50, calls alternate between allowed and blocked.
+func (e *Engine) Admit() bool {
+	return e.AdmitN(1)
+}
+
+// AdmitN is like Admit, but the call counts as weight calls toward allowed or blocked.
+// Example: At a flow rate of 50, a call with a weight of 10 followed by ten calls with a weight of 1 allows the first and blocks the rest.
+func (e *Engine) AdmitN(weight int64) bool {
+	return e.AdmitPriority(PriorityNormal, weight)
+}
+
+// AdmitPriority is like AdmitN, for a call of priority p.
+// The call is admitted at p's share of the flow rate, compared with the calls of the same priority; see Priority.FlowRate.
+func (e *Engine) AdmitPriority(p Priority, weight int64) bool {
+	flowRate := p.FlowRate(e.flowRate)
+	class := e.class(p)
+
+	var allow bool
+
+	switch {
+	case flowRate == 100:
+		allow = true
+	case flowRate > 0:
+		var allowRate int64
+
+		if class.allowed != 0 {
+			allowRate = int64((float64(class.allowed) / float64(class.allowed+class.blocked)) * 100)
+		}
+
+		allow = allowRate < flowRate
+	}
+
+	if allow {
+		class.allowed += weight
+	} else {
+		class.blocked += weight
+	}
+
+	e.TallyN(allow, weight)
+
+	return allow
+}
+
+// Retract undoes an admission decision of priority p and weight weight, made by AdmitPriority or TallyN during the current interval.
+// Example: A caller that reserves a slot and then gives it up retracts the decision, so the slot is available to another call.
+// Decisions made before the last Reset cannot be retracted; the caller must not try.
+func (e *Engine) Retract(p Priority, allowed bool, weight int64) {
+	class := e.class(p)
+
+	// Decisions counted by TallyN never reached the class, so it must not go negative.
+	if allowed {
+		class.allowed = max(class.allowed-weight, 0)
+		e.allowed -= weight
+	} else {
+		class.blocked = max(class.blocked-weight, 0)
+		e.blocked -= weight
+	}
+}
+
+// Tally counts an admission decision that was made outside the Engine, such as by a manual override.
+func (e *Engine) Tally(allowed bool) {
+	e.TallyN(allowed, 1)
+}
+
+// TallyN is like Tally, but the call counts as weight calls.
+func (e *Engine) TallyN(allowed bool, weight int64) {
+	if allowed {
+		e.allowed += weight
+	} else {
+		e.blocked += weight
+	}
+}
+
+// Record counts the outcomes of allowed calls.
+// Example: Record(1, 0) counts a single success, while Record(75, 25) counts an aggregate of 100 calls.
+func (e *Engine) Record(successes, failures int64) {
+	e.successes += successes
+	e.failures += failures
+}
+
+// RecordLate counts outcomes that were discovered after the interval they belong to ended.
+// intervalsAgo is how many intervals before the current one they occurred in; 0 is the current interval.
+// Example: RecordLate(1, 0, 1) counts a failure that occurred during the previous interval.
+//
+// Completed intervals only affect decisions while they are inside Config.Window or Config.SlowWindow.
+// RecordLate reports whether the outcomes were counted; outcomes for intervals that are no longer remembered are dropped.
+func (e *Engine) RecordLate(intervalsAgo int, successes, failures int64) bool {
+	if intervalsAgo <= 0 {
+		e.Record(successes, failures)
+
+		return true
+	}
+
+	i := len(e.recent) - intervalsAgo
+	if i < 0 {
+		return false
+	}
+
+	e.recent[i].successes += successes
+	e.recent[i].failures += failures
+
+	return true
+}
+
+// Adapt ends the current interval.
+// It decides whether to open or close, then lets the Strategy decide the next flow rate.
+// It does not clear the interval's counters; call Reset for that.
+func (e *Engine) Adapt() {
+	exceeded, reason := e.exceeded()
+
+	next := Opening
+	if exceeded {
+		next = Closing
+	}
+
+	if next != e.state && e.intervalsInState > 0 && e.intervalsInState < e.config.MinIntervalsBeforeReverse {
+		next = e.state
+		reason = fmt.Sprintf("%s, but %s for at least %d intervals", reason, next, e.config.MinIntervalsBeforeReverse)
+	}
+
+	e.reason = reason
+
+	if next != e.state {
+		e.intervalsInState = 1
+	} else if e.intervalsInState > 0 {
+		e.intervalsInState++
+	}
+
+	e.state = next
+	e.flowRate = Clamp(e.strategy.Next(e.flowRate, e.Observe()))
+}
+
+// Hold ends the current interval without moving the flow rate, leaving the Engine in state.
+// reason explains the decision, and is reported by Observe.
+// Example: A caller that enforces a cooldown after fully closing calls Hold(Closing, "reopen cooldown") until the cooldown is over.
+func (e *Engine) Hold(state State, reason string) {
+	e.state = state
+	e.reason = reason
+}
+
+// maxProjection is the most intervals Project simulates before giving up.
+const maxProjection = 10_000
+
+// Project estimates how many more intervals it takes the flow rate to reach target, if no call fails from now on.
+// It simulates the Strategy on a copy, so the Engine is not affected, and accounts for Config.MinIntervalsBeforeReverse holding the Engine Closing.
+// Example: An Exponential Engine Closing at 0 reaches 100 in 7 intervals: 1, 3, 7, 15, 31, 63, 100.
+//
+// It reports false for a Strategy it cannot copy, which is any Strategy outside this package, or when target is never reached.
+func (e *Engine) Project(target int64) (int, bool) {
+	target = Clamp(target)
+
+	if e.flowRate >= target {
+		return 0, true
+	}
+
+	c, ok := e.strategy.(cloner)
+	if !ok {
+		return 0, false
+	}
+
+	// A Strategy that embeds a built-in one inherits its clone, but not necessarily its Next.
+	strategy := c.clone()
+	if reflect.TypeOf(strategy) != reflect.TypeOf(e.strategy) {
+		return 0, false
+	}
+	flowRate := e.flowRate
+
+	hold := 0
+	if e.state == Closing && e.intervalsInState > 0 {
+		hold = max(e.config.MinIntervalsBeforeReverse-e.intervalsInState, 0)
+	}
+
+	for i := 1; i <= maxProjection; i++ {
+		state := Opening
+		if i <= hold {
+			state = Closing
+		}
+
+		o := Observation{FlowRate: flowRate, State: state}.WithFlowRate(flowRate)
+		flowRate = Clamp(strategy.Next(flowRate, o))
+
+		if flowRate >= target {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// Overload marks the current interval as overloaded, so Adapt closes even if the failure rate is acceptable.
+// reason explains the overload, and becomes the decision's reason.
+// Example: A caller that watches a work queue calls Overload("queue depth 1200 > 1000") when the queue backs up, before any call fails.
+//
+// Config.DisableClosing still takes precedence. The mark is cleared by Reset.
+func (e *Engine) Overload(reason string) {
+	e.overload = reason
+}
+
+// Cap lowers the flow rate to limit, if it is above it, and appends reason to the decision's reason.
+// It reports whether the flow rate was lowered.
+// Example: A caller that warms up slowly calls Cap after Adapt, with a limit that rises over time.
+func (e *Engine) Cap(limit int64, reason string) bool {
+	limit = Clamp(limit)
+
+	if e.flowRate <= limit {
+		return false
+	}
+
+	e.flowRate = limit
+	e.Annotate(reason)
+
+	return true
+}
+
+// Annotate appends note to the reason for the most recent decision.
+// Example: A caller that tracks which calls failed annotates a decision to close with a representative failing call.
+func (e *Engine) Annotate(note string) {
+	if e.reason == "" {
+		e.reason = note
+	} else {
+		e.reason += ", " + note
+	}
+}
+
+// exceeded reports whether the current interval's failure rate should close the Engine, and the comparison that decided it.
+// It accounts for DisableClosing, StrictMode, HysteresisBand, and ThresholdInclusive.
+func (e *Engine) exceeded() (bool, string) {
+	if e.config.DisableClosing {
+		return false, "closing is disabled"
+	}
+
+	threshold := e.config.AllowedFailurePercent
+	if e.config.StrictMode {
+		threshold = 0
+	}
+
+	if e.state == Closing {
+		threshold = max(threshold-e.config.HysteresisBand, 0)
+	} else {
+		threshold += e.config.HysteresisBand
+	}
+
+	exceeded, reason := e.compare(e.describeFailureRate(), e.failureRate(), threshold)
+
+	if e.config.SlowWindow > 1 {
+		slow, slowReason := e.compare(fmt.Sprintf("failure rate over %d intervals", e.config.SlowWindow), e.windowFailureRate(e.config.SlowWindow), threshold)
+
+		switch {
+		case exceeded:
+		case slow:
+			exceeded, reason = true, slowReason
+		default:
+			reason += ", " + slowReason
+		}
+	}
+
+	if e.overload != "" {
+		if exceeded {
+			reason += ", " + e.overload
+		} else {
+			exceeded, reason = true, e.overload
+		}
+	}
+
+	return exceeded, reason
+}
+
+// compare reports whether rate exceeds threshold, and describes the comparison using name.
+// It accounts for ThresholdInclusive.
+func (e *Engine) compare(name string, rate, threshold int64) (bool, string) {
+	describe := func(comparison string) string {
+		return fmt.Sprintf("%s %d%% %s %d%%", name, rate, comparison, threshold)
+	}
+
+	// A failure rate of 0 never closes, or an inclusive threshold of 0 would never reopen.
+	if e.config.ThresholdInclusive && rate > 0 {
+		if rate >= threshold {
+			return true, describe(">=")
+		}
+
+		return false, describe("<")
+	}
+
+	if rate > threshold {
+		return true, describe(">")
+	}
+
+	return false, describe("<=")
+}
+
+// describeFailureRate names the failure rate used to decide the direction, for the decision's reason.
+func (e *Engine) describeFailureRate() string {
+	switch {
+	case e.config.Window > 1 && len(e.config.WindowWeights) > 0:
+		return fmt.Sprintf("weighted failure rate over %d intervals", e.config.Window)
+	case e.config.Window > 1:
+		return fmt.Sprintf("failure rate over %d intervals", e.config.Window)
+	case e.smoothing():
+		return "smoothed failure rate"
+	default:
+		return "failure rate"
+	}
+}
+
+// failureRate is the failure rate used to decide the direction.
+// It combines the current interval with previous ones according to Config.Window or Config.Smoothing.
+func (e *Engine) failureRate() int64 {
+	switch {
+	case e.config.Window > 1 && len(e.config.WindowWeights) > 0:
+		return e.weightedFailureRate()
+	case e.config.Window > 1:
+		return e.windowFailureRate(e.config.Window)
+	case e.smoothing():
+		return int64(e.smoothed())
+	default:
+		return FailureRate(e.successes, e.failures)
+	}
+}
+
+// windowFailureRate combines the current interval with the intervals - 1 most recent completed intervals.
+func (e *Engine) windowFailureRate(intervals int) int64 {
+	successes, failures := e.successes, e.failures
+
+	for _, o := range e.recent[max(len(e.recent)-(intervals-1), 0):] {
+		successes += o.successes
+		failures += o.failures
+	}
+
+	return FailureRate(successes, failures)
+}
+
+// weightedFailureRate combines the current interval with the Config.Window - 1 most recent completed intervals,
+// counting the outcomes of each by its Config.WindowWeights.
+// Example: With weights 1 and 0.5, 10 failures in the previous interval count as 5.
+func (e *Engine) weightedFailureRate() int64 {
+	successes, failures := float64(e.successes)*e.weight(0), float64(e.failures)*e.weight(0)
+
+	recent := e.recent[max(len(e.recent)-(e.config.Window-1), 0):]
+
+	for i, o := range recent {
+		weight := e.weight(len(recent) - i)
+
+		successes += float64(o.successes) * weight
+		failures += float64(o.failures) * weight
+	}
+
+	if successes+failures == 0 {
+		return 0
+	}
+
+	return int64(failures / (successes + failures) * 100)
+}
+
+// weight returns the Config.WindowWeights of the interval age intervals before the current one.
+func (e *Engine) weight(age int) float64 {
+	if age >= len(e.config.WindowWeights) {
+		return 1
+	}
+
+	return max(e.config.WindowWeights[age], 0)
+}
+
+// smoothing reports whether Config.Smoothing applies.
+func (e *Engine) smoothing() bool {
+	return e.config.Window <= 1 && e.config.Smoothing > 0 && e.config.Smoothing < 1
+}
+
+// smoothed blends the current interval's failure rate into the moving average.
+// An interval without outcomes counts as a failure rate of 0, so the average decays while the Engine is fully closed.
+func (e *Engine) smoothed() float64 {
+	var current float64
+
+	if total := e.successes + e.failures; total > 0 {
+		current = float64(e.failures) / float64(total) * 100
+	}
+
+	if !e.averaged {
+		return current
+	}
+
+	return e.config.Smoothing*current + (1-e.config.Smoothing)*e.average
+}
+
+// Reset clears the counters for the next interval.
+// The flow rate, state, and Strategy are kept, and the outcomes are remembered for Config.Window and Config.Smoothing.
+func (e *Engine) Reset() {
+	if remember := max(e.config.Window, e.config.SlowWindow) - 1; remember > 0 {
+		e.recent = append(e.recent, outcomes{successes: e.successes, failures: e.failures})
+
+		if len(e.recent) > remember {
+			e.recent = e.recent[1:]
+		}
+	}
+
+	if e.smoothing() {
+		e.average = e.smoothed()
+		e.averaged = true
+	}
+
+	e.clear()
+}
+
+// Skip clears the counters for the next interval like Reset, but forgets the outcomes instead of remembering them.
+// Example: A caller that knows an interval's failures were planned, such as during maintenance, calls Skip so they do not weigh on the next decisions.
+func (e *Engine) Skip() {
+	e.clear()
+}
+
+// clear zeroes the counters of the current interval.
+func (e *Engine) clear() {
+	e.successes = 0
+	e.failures = 0
+	e.allowed = 0
+	e.blocked = 0
+	e.classes = [3]admissions{}
+	e.overload = ""
+}
+
+// SetAllowedFailurePercent changes Config.AllowedFailurePercent, starting with the next call to Adapt.
+func (e *Engine) SetAllowedFailurePercent(percent int64) {
+	e.config.AllowedFailurePercent = percent
+}
+
+// Restart returns the Engine to how New left it, Opening at flowRate, as if no interval had ever ended.
+// The counters, remembered outcomes, and the state of the package's own Strategies are cleared.
+// A Strategy from outside this package keeps its state.
+func (e *Engine) Restart(flowRate int64) {
+	if r, ok := e.strategy.(resetter); ok {
+		r.reset()
+	}
+
+	*e = Engine{
+		config:   e.config,
+		strategy: e.strategy,
+		flowRate: Clamp(flowRate),
+		state:    Opening,
+	}
+}
+
+// FlowRate reports the current flow rate.
+func (e *Engine) FlowRate() int64 {
+	return e.flowRate
+}
+
+// State reports the direction the flow rate is moving.
+func (e *Engine) State() State {
+	return e.state
+}
+
+// Observe reports the current interval as an Observation.
+func (e *Engine) Observe() Observation {
+	return Observation{
+		State:     e.state,
+		Reason:    e.reason,
+		Allowed:   e.allowed,
+		Blocked:   e.blocked,
+		Successes: e.successes,
+		Failures:  e.failures,
+	}.WithFlowRate(e.flowRate)
+}
+
+// Observation is a point-in-time view of an Engine's current interval.
+type Observation struct {
+	// FlowRate is the percentage of calls being allowed.
+	FlowRate int64
+
+	// State is the direction the flow rate is moving.
+	// When passed to a Strategy, it is the direction the Engine just decided to move in.
+	State State
+
+	// Reason explains the most recent decision, including the comparison that decided the direction.
+	// Example: "failure rate 30% > 20%"
+	// It is empty until the first interval ends.
+	Reason string
+
+	// FailureRate is the percentage of allowed calls that failed.
+	// It is 0 when FlowRate is 0.
+	FailureRate int64
+
+	// SuccessRate is the percentage of allowed calls that succeeded.
+	// It is 100 when no calls have completed, and 0 when FlowRate is 0.
+	SuccessRate int64
+
+	// Allowed is the number of calls allowed.
+	Allowed int64
+
+	// Blocked is the number of calls blocked.
+	Blocked int64
+
+	// Successes is the number of allowed calls that succeeded.
+	Successes int64
+
+	// Failures is the number of allowed calls that failed.
+	Failures int64
+}
+
+// WithFlowRate returns a copy of o as it would be observed at flowRate.
+// The rates are recomputed, because nothing is reported as succeeding or failing at a flow rate of 0.
+// Example: A caller with a manual override reports the forced flow rate instead of the Engine's.
+func (o Observation) WithFlowRate(flowRate int64) Observation {
+	o.FlowRate = flowRate
+	o.FailureRate = 0
+	o.SuccessRate = 0
+
+	switch {
+	case flowRate == 0:
+	case o.Successes == 0 && o.Failures == 0:
+		o.SuccessRate = 100
+	default:
+		o.FailureRate = FailureRate(o.Successes, o.Failures)
+		o.SuccessRate = 100 - o.FailureRate
+	}
+
+	return o
+}
+
+// FailureRate calculates the percentage of failures out of successes and failures.
+// Example: With 500 failures and 500 successes, the failure rate will be 50%.
+func FailureRate(successes, failures int64) int64 {
+	if failures == 0 && successes == 0 {
+		return 0
+	}
+
+	// Ex: 500 failures, 500 successes
+	// (500 / (500 + 500)) * 100 = 50
+	return int64((float64(failures) / float64(failures+successes)) * 100)
+}
+
+// Clamp constrains a flow rate to the range [0, 100].
+func Clamp(flowRate int64) int64 {
+	if flowRate < 0 {
+		return 0
+	}
+
+	if flowRate > 100 {
+		return 100
+	}
+
+	return flowRate
+}
@@ -0,0 +1,567 @@
+package engine_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/justindfuller/nozzle/engine"
+)
+
+func TestAdmit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		flowRate int64
+		allowed  int64
+	}{
+		{
+			flowRate: 100,
+			allowed:  100,
+		},
+		{
+			flowRate: 50,
+			allowed:  50,
+		},
+		{
+			flowRate: 25,
+			allowed:  25,
+		},
+		{
+			flowRate: 0,
+			allowed:  0,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			e := engine.New(engine.Config{}, test.flowRate)
+
+			for range 100 {
+				e.Admit()
+			}
+
+			o := e.Observe()
+
+			if o.Allowed != test.allowed {
+				t.Errorf("Expected Allowed=%d Got=%d", test.allowed, o.Allowed)
+			}
+
+			if o.Blocked != 100-test.allowed {
+				t.Errorf("Expected Blocked=%d Got=%d", 100-test.allowed, o.Blocked)
+			}
+		})
+	}
+}
+
+func TestAdapt(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{AllowedFailurePercent: 50}, 100)
+
+	tests := []struct {
+		failures int64
+		flowRate int64
+		state    engine.State
+	}{
+		{failures: 100, flowRate: 99, state: engine.Closing},
+		{failures: 100, flowRate: 97, state: engine.Closing},
+		{failures: 100, flowRate: 93, state: engine.Closing},
+		{failures: 0, flowRate: 94, state: engine.Opening},
+		{failures: 0, flowRate: 96, state: engine.Opening},
+		{failures: 50, flowRate: 100, state: engine.Opening},
+		{failures: 51, flowRate: 99, state: engine.Closing},
+	}
+
+	for i, test := range tests {
+		e.Record(100-test.failures, test.failures)
+		e.Adapt()
+		e.Reset()
+
+		if fr, s := e.FlowRate(), e.State(); fr != test.flowRate || s != test.state {
+			t.Errorf("interval=%d Expected FlowRate=%d State=%s Got FlowRate=%d State=%s", i, test.flowRate, test.state, fr, s)
+		}
+	}
+}
+
+func TestHold(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{}, 0)
+
+	e.Hold(engine.Closing, "cooling down")
+
+	if fr, s := e.FlowRate(), e.State(); fr != 0 || s != engine.Closing {
+		t.Errorf("Expected FlowRate=0 State=%s Got FlowRate=%d State=%s", engine.Closing, fr, s)
+	}
+
+	if r := e.Observe().Reason; r != "cooling down" {
+		t.Errorf("Expected Reason=%q Got=%q", "cooling down", r)
+	}
+}
+
+func TestWithFlowRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		observation engine.Observation
+		flowRate    int64
+		failureRate int64
+		successRate int64
+	}{
+		{
+			observation: engine.Observation{Successes: 75, Failures: 25},
+			flowRate:    100,
+			failureRate: 25,
+			successRate: 75,
+		},
+		{
+			observation: engine.Observation{Successes: 75, Failures: 25},
+			flowRate:    0,
+			failureRate: 0,
+			successRate: 0,
+		},
+		{
+			observation: engine.Observation{},
+			flowRate:    50,
+			failureRate: 0,
+			successRate: 100,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			o := test.observation.WithFlowRate(test.flowRate)
+
+			if o.FlowRate != test.flowRate || o.FailureRate != test.failureRate || o.SuccessRate != test.successRate {
+				t.Errorf("Expected FlowRate=%d FailureRate=%d SuccessRate=%d Got=%+v", test.flowRate, test.failureRate, test.successRate, o)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		config   engine.Config
+		failures []int64
+		expected []engine.State
+	}{
+		{
+			config:   engine.Config{AllowedFailurePercent: 20},
+			failures: []int64{60, 0, 0, 0},
+			expected: []engine.State{engine.Closing, engine.Opening, engine.Opening, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Window: 3},
+			failures: []int64{60, 0, 0, 0},
+			expected: []engine.State{engine.Closing, engine.Closing, engine.Opening, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Window: 3, WindowWeights: []float64{1, 0.1, 0.1}},
+			failures: []int64{60, 0, 0, 0},
+			expected: []engine.State{engine.Closing, engine.Opening, engine.Opening, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Window: 3, WindowWeights: []float64{1, 1}},
+			failures: []int64{60, 0, 0, 0},
+			expected: []engine.State{engine.Closing, engine.Closing, engine.Opening, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Smoothing: 0.5},
+			failures: []int64{60, 0, 0, 0},
+			expected: []engine.State{engine.Closing, engine.Closing, engine.Opening, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, SlowWindow: 4},
+			failures: []int64{30, 0, 0, 0, 0},
+			expected: []engine.State{engine.Closing, engine.Opening, engine.Opening, engine.Opening, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, SlowWindow: 4},
+			failures: []int64{90, 10, 10, 10, 10},
+			expected: []engine.State{engine.Closing, engine.Closing, engine.Closing, engine.Closing, engine.Opening},
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Smoothing: 0.1},
+			failures: []int64{60, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			expected: []engine.State{
+				engine.Closing, engine.Closing, engine.Closing, engine.Closing, engine.Closing, engine.Closing,
+				engine.Closing, engine.Closing, engine.Closing, engine.Closing, engine.Opening,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			e := engine.New(test.config, 100)
+
+			for j, failures := range test.failures {
+				e.Record(100-failures, failures)
+				e.Adapt()
+				e.Reset()
+
+				if s := e.State(); s != test.expected[j] {
+					t.Errorf("interval=%d Expected State=%s Got=%s", j, test.expected[j], s)
+				}
+			}
+		})
+	}
+}
+
+func TestThresholdInclusive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		config   engine.Config
+		failures int64
+		state    engine.State
+		reason   string
+	}{
+		{
+			config:   engine.Config{AllowedFailurePercent: 50},
+			failures: 50,
+			state:    engine.Opening,
+			reason:   "failure rate 50% <= 50%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 50, ThresholdInclusive: true},
+			failures: 50,
+			state:    engine.Closing,
+			reason:   "failure rate 50% >= 50%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 50, ThresholdInclusive: true},
+			failures: 49,
+			state:    engine.Opening,
+			reason:   "failure rate 49% < 50%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 0, ThresholdInclusive: true},
+			failures: 0,
+			state:    engine.Opening,
+			reason:   "failure rate 0% <= 0%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Window: 3},
+			failures: 30,
+			state:    engine.Closing,
+			reason:   "failure rate over 3 intervals 30% > 20%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, Window: 3, WindowWeights: []float64{1, 0.5, 0.25}},
+			failures: 30,
+			state:    engine.Closing,
+			reason:   "weighted failure rate over 3 intervals 30% > 20%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, SlowWindow: 3},
+			failures: 10,
+			state:    engine.Opening,
+			reason:   "failure rate 10% <= 20%, failure rate over 3 intervals 10% <= 20%",
+		},
+		{
+			config:   engine.Config{AllowedFailurePercent: 20, DisableClosing: true},
+			failures: 30,
+			state:    engine.Opening,
+			reason:   "closing is disabled",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			e := engine.New(test.config, 100)
+
+			e.Record(100-test.failures, test.failures)
+			e.Adapt()
+
+			if s := e.State(); s != test.state {
+				t.Errorf("Expected State=%s Got=%s", test.state, s)
+			}
+
+			if r := e.Observe().Reason; r != test.reason {
+				t.Errorf("Expected Reason=%q Got=%q", test.reason, r)
+			}
+		})
+	}
+}
+
+func TestRecordLate(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{AllowedFailurePercent: 20, Window: 2}, 100)
+
+	e.Record(4, 0)
+	e.Adapt()
+	e.Reset()
+
+	if !e.RecordLate(1, 0, 3) {
+		t.Error("Expected the previous interval to be remembered")
+	}
+
+	if e.RecordLate(2, 0, 100) {
+		t.Error("Expected an interval outside the window to be dropped")
+	}
+
+	e.Record(4, 0)
+	e.Adapt()
+
+	if s := e.State(); s != engine.Closing {
+		t.Errorf("Expected State=%s Got=%s", engine.Closing, s)
+	}
+}
+
+func TestCap(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{}, 80)
+
+	if e.Cap(90, "warming up") {
+		t.Error("Expected a limit above the flow rate to do nothing")
+	}
+
+	if !e.Cap(40, "warming up") {
+		t.Error("Expected a limit below the flow rate to lower it")
+	}
+
+	if fr := e.FlowRate(); fr != 40 {
+		t.Errorf("Expected FlowRate=40 Got=%d", fr)
+	}
+
+	if r := e.Observe().Reason; r != "warming up" {
+		t.Errorf("Expected Reason=%q Got=%q", "warming up", r)
+	}
+}
+
+func TestAdmitN(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{}, 50)
+
+	if !e.AdmitN(4) {
+		t.Error("Expected the first call to be allowed")
+	}
+
+	for i := range 4 {
+		if e.AdmitN(1) {
+			t.Errorf("call=%d Expected blocked until the weighted call is balanced", i)
+		}
+	}
+
+	if o := e.Observe(); o.Allowed != 4 || o.Blocked != 4 {
+		t.Errorf("Expected Allowed=4 Blocked=4 Got Allowed=%d Blocked=%d", o.Allowed, o.Blocked)
+	}
+}
+
+func TestAdmitPriority(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		flowRate int64
+		low      int64
+		normal   int64
+		critical int64
+	}{
+		{flowRate: 100, low: 100, normal: 100, critical: 100},
+		{flowRate: 75, low: 50, normal: 75, critical: 100},
+		{flowRate: 50, low: 0, normal: 50, critical: 100},
+		{flowRate: 25, low: 0, normal: 25, critical: 50},
+		{flowRate: 0, low: 0, normal: 0, critical: 0},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			e := engine.New(engine.Config{}, test.flowRate)
+
+			allowed := map[engine.Priority]int64{}
+
+			// Interleave the priorities, so each is admitted against its own ratio rather than the shared one.
+			for range 100 {
+				for _, p := range []engine.Priority{engine.PriorityLow, engine.PriorityNormal, engine.PriorityCritical} {
+					if e.AdmitPriority(p, 1) {
+						allowed[p]++
+					}
+				}
+			}
+
+			expected := map[engine.Priority]int64{
+				engine.PriorityLow:      test.low,
+				engine.PriorityNormal:   test.normal,
+				engine.PriorityCritical: test.critical,
+			}
+
+			for p, want := range expected {
+				if allowed[p] != want {
+					t.Errorf("priority=%s Expected Allowed=%d Got=%d", p, want, allowed[p])
+				}
+			}
+
+			if o := e.Observe(); o.Allowed+o.Blocked != 300 {
+				t.Errorf("Expected Allowed+Blocked=300 Got=%d", o.Allowed+o.Blocked)
+			}
+		})
+	}
+}
+
+func TestOverload(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{AllowedFailurePercent: 50}, 100)
+
+	e.Record(10, 0)
+	e.Overload("queue depth 1200 > 1000")
+	e.Adapt()
+	e.Reset()
+
+	if s, r := e.State(), e.Observe().Reason; s != engine.Closing || r != "queue depth 1200 > 1000" {
+		t.Errorf("Expected State=%s Reason=%q Got State=%s Reason=%q", engine.Closing, "queue depth 1200 > 1000", s, r)
+	}
+
+	e.Record(10, 0)
+	e.Adapt()
+
+	if s := e.State(); s != engine.Opening {
+		t.Errorf("Expected Reset to clear the overload, State=%s Got=%s", engine.Opening, s)
+	}
+}
+
+func TestProject(t *testing.T) {
+	t.Parallel()
+
+	// custom is a Strategy outside the engine package, which Project cannot copy.
+	type custom struct{ engine.AIMD }
+
+	tests := []struct {
+		config    engine.Config
+		flowRate  int64
+		closing   int
+		target    int64
+		intervals int
+		ok        bool
+	}{
+		{flowRate: 0, target: 100, intervals: 7, ok: true},
+		{flowRate: 100, target: 100, intervals: 0, ok: true},
+		{flowRate: 0, target: 50, intervals: 6, ok: true},
+		{config: engine.Config{Strategy: engine.AIMD{Increase: 10}}, flowRate: 0, target: 100, intervals: 10, ok: true},
+		{config: engine.Config{Strategy: &engine.PID{}}, flowRate: 0, target: 100, ok: false},
+		{config: engine.Config{Strategy: custom{}}, flowRate: 0, target: 100, ok: false},
+		{
+			// After two intervals Closing (99, 97), MinIntervalsBeforeReverse holds it Closing for one more (93), then it reopens: 94, 96, 100.
+			config:    engine.Config{AllowedFailurePercent: 50, MinIntervalsBeforeReverse: 3},
+			flowRate:  100,
+			closing:   2,
+			target:    100,
+			intervals: 4,
+			ok:        true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			e := engine.New(test.config, test.flowRate)
+
+			if test.closing > 0 {
+				// Reverse once so MinIntervalsBeforeReverse applies, then keep closing.
+				e.Record(1, 0)
+				e.Adapt()
+				e.Reset()
+
+				for range test.closing {
+					e.Record(0, 1)
+					e.Adapt()
+					e.Reset()
+				}
+			}
+
+			before := e.FlowRate()
+
+			intervals, ok := e.Project(test.target)
+
+			if intervals != test.intervals || ok != test.ok {
+				t.Errorf("Expected intervals=%d ok=%t Got intervals=%d ok=%t", test.intervals, test.ok, intervals, ok)
+			}
+
+			if e.FlowRate() != before {
+				t.Errorf("Expected Project not to change FlowRate=%d Got=%d", before, e.FlowRate())
+			}
+		})
+	}
+}
+
+func TestRestart(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		strategy engine.Strategy
+		expected int64
+	}{
+		{strategy: &engine.Exponential{}, expected: 99},
+		{strategy: &engine.Ramp{Close: engine.StepCurve(1, 5, 20)}, expected: 99},
+		{strategy: engine.AIMD{DecreaseFactor: 0.5}, expected: 50},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			e := engine.New(engine.Config{AllowedFailurePercent: 50, Strategy: test.strategy}, 100)
+
+			for range 3 {
+				e.Admit()
+				e.Record(0, 1)
+				e.Adapt()
+				e.Reset()
+			}
+
+			e.Admit()
+			e.Record(0, 1)
+			e.Restart(100)
+
+			if o := e.Observe(); o.FlowRate != 100 || o.State != engine.Opening || o.Allowed != 0 || o.Failures != 0 {
+				t.Errorf("Expected FlowRate=100 State=%s and no counts Got=%+v", engine.Opening, o)
+			}
+
+			// The Strategy starts over, instead of continuing where it left off.
+			e.Admit()
+			e.Record(0, 1)
+			e.Adapt()
+
+			if f := e.FlowRate(); f != test.expected {
+				t.Errorf("Expected FlowRate=%d Got=%d", test.expected, f)
+			}
+		})
+	}
+}
+
+func TestSkip(t *testing.T) {
+	t.Parallel()
+
+	e := engine.New(engine.Config{AllowedFailurePercent: 20, Window: 2}, 100)
+
+	e.Record(0, 10)
+	e.Hold(engine.Opening, "maintenance")
+	e.Skip()
+
+	e.Record(4, 0)
+	e.Adapt()
+
+	if s, fr := e.State(), e.FlowRate(); s != engine.Opening || fr != 100 {
+		t.Errorf("Expected the skipped failures to be forgotten Got State=%s FlowRate=%d", s, fr)
+	}
+
+	if o := e.Observe(); o.Successes != 4 || o.Failures != 0 {
+		t.Errorf("Expected Successes=4 Failures=0 Got Successes=%d Failures=%d", o.Successes, o.Failures)
+	}
+}
@@ -0,0 +1,63 @@
+package engine
+
+// Priority ranks calls for shedding.
+// As the flow rate drops, PriorityLow calls are blocked first and PriorityCritical calls last.
+// The zero Priority is PriorityNormal, so calls without a priority are admitted at the flow rate.
+type Priority int
+
+const (
+	// PriorityLow calls are admitted at twice the distance from fully open: at a flow rate of 75, half of them are allowed, and at 50 or below, none are.
+	PriorityLow Priority = iota - 1
+
+	// PriorityNormal calls are admitted at the flow rate.
+	PriorityNormal
+
+	// PriorityCritical calls are admitted at twice the flow rate: at a flow rate of 50 or above, all of them are allowed, and at 25, half of them are.
+	PriorityCritical
+)
+
+// String implements fmt.Stringer.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// FlowRate reports the share of p's calls to admit when the flow rate is flowRate.
+// Unknown priorities are admitted like PriorityNormal.
+// Example: PriorityLow.FlowRate(75) is 50, and PriorityCritical.FlowRate(25) is 50.
+func (p Priority) FlowRate(flowRate int64) int64 {
+	switch p {
+	case PriorityLow:
+		return Clamp(2*flowRate - 100)
+	case PriorityCritical:
+		return Clamp(2 * flowRate)
+	default:
+		return flowRate
+	}
+}
+
+// admissions counts the admission decisions of one Priority in the current interval.
+type admissions struct {
+	allowed int64
+	blocked int64
+}
+
+// class returns the admissions of p.
+func (e *Engine) class(p Priority) *admissions {
+	switch p {
+	case PriorityLow:
+		return &e.classes[0]
+	case PriorityCritical:
+		return &e.classes[2]
+	default:
+		return &e.classes[1]
+	}
+}
@@ -0,0 +1,341 @@
+package engine
+
+import "math"
+
+// Strategy decides how the flow rate changes at the end of each interval.
+//
+// The Engine decides the direction: it is Closing when the failure rate exceeds Config.AllowedFailurePercent, and Opening otherwise.
+// Next receives the current flow rate and an Observation whose State is that direction, and returns the next flow rate.
+// The result is clamped to [0, 100].
+//
+// Inside a Nozzle, Next is called with the Nozzle's lock held, so it must not call the Nozzle's methods.
+// Implementations may keep state between calls, so each Engine needs its own instance.
+//
+// Example:
+//
+//	// linear moves the flow rate by 10 each interval.
+//	type linear struct{}
+//
+//	func (linear) Next(current int64, o engine.Observation) int64 {
+//		if o.State == engine.Closing {
+//			return current - 10
+//		}
+//		return current + 10
+//	}
+type Strategy interface {
+	Next(current int64, o Observation) int64
+}
+
+// cloner is implemented by the built-in strategies, so Engine.Project can simulate them without disturbing their state.
+type cloner interface {
+	clone() Strategy
+}
+
+// resetter is implemented by the Strategies in this package that keep state between intervals, so Engine.Restart can clear it.
+type resetter interface {
+	reset()
+}
+
+// Exponential is the default Strategy.
+// It moves the flow rate by 1, then doubles the step each interval it keeps moving in the same direction.
+// Changing direction starts over at 1.
+//
+// Example: Closing from 100 gives 99, 97, 93, 85, 69, 37, 0. Opening from 0 gives 1, 3, 7, 15, 31, 63, 100.
+//
+// The zero value is ready to use.
+type Exponential struct {
+	// step is the amount added to the flow rate on the next call.
+	// It is negative while closing and positive while opening.
+	// Example: If step is -2, the flow rate decreases faster than if step is -1
+	step int64
+}
+
+// Next implements Strategy.
+func (e *Exponential) Next(current int64, o Observation) int64 {
+	if o.State == Closing {
+		step := e.step
+		if step > -1 {
+			step = -1
+		}
+
+		e.step = step * 2
+
+		return current + step
+	}
+
+	if current == 100 {
+		return current
+	}
+
+	step := e.step
+	if step < 1 {
+		step = 1
+	}
+
+	e.step = step * 2
+
+	return current + step
+}
+
+// clone implements cloner.
+func (e *Exponential) clone() Strategy {
+	c := *e
+
+	return &c
+}
+
+// reset implements resetter.
+func (e *Exponential) reset() {
+	*e = Exponential{}
+}
+
+// AIMD is an additive-increase/multiplicative-decrease Strategy, the same control law TCP uses for congestion.
+// While opening, it adds Increase to the flow rate each interval.
+// While closing, it multiplies the flow rate by DecreaseFactor each interval.
+//
+// Compared to Exponential, AIMD backs off hard as soon as failures appear and recovers slowly and predictably.
+// Under oscillating load it settles into a sawtooth around the highest flow rate the dependency can handle.
+//
+// Example: With Increase 5 and DecreaseFactor 0.5, closing from 100 gives 50, 25, 12, 6, 3, 1, 0. Opening from 0 gives 5, 10, 15, ...
+//
+// AIMD keeps no state, so one value can be shared by many Engines.
+type AIMD struct {
+	// Increase is added to the flow rate each interval the Engine is opening.
+	// If zero, 1 is used.
+	Increase int64
+
+	// DecreaseFactor multiplies the flow rate each interval the Engine is closing.
+	// It should be between 0 and 1; the result is rounded down.
+	// If zero, 0.5 is used.
+	DecreaseFactor float64
+}
+
+// Next implements Strategy.
+func (a AIMD) Next(current int64, o Observation) int64 {
+	if o.State == Closing {
+		factor := a.DecreaseFactor
+		if factor == 0 {
+			factor = 0.5
+		}
+
+		return int64(float64(current) * factor)
+	}
+
+	increase := a.Increase
+	if increase == 0 {
+		increase = 1
+	}
+
+	return current + increase
+}
+
+// clone implements cloner.
+func (a AIMD) clone() Strategy {
+	return a
+}
+
+// PID is a Strategy that steers the failure rate toward TargetFailurePercent using a proportional-integral-derivative controller.
+//
+// Each interval it computes the error as TargetFailurePercent minus the observed failure rate.
+// The flow rate moves by Kp times the error, plus Ki times the accumulated error, plus Kd times the change in error.
+// A failure rate above the target closes the Engine, and one below the target opens it, in proportion to how far off it is.
+//
+// Exponential doubles its step until the direction changes, so it overshoots when the failure rate hovers near the threshold.
+// PID instead makes small corrections near the target and large ones far from it.
+//
+// PID steers by its own target and ignores the State the Engine decided on.
+// Keep TargetFailurePercent at or below AllowedFailurePercent so the reported State agrees with the direction PID moves.
+//
+// Example:
+//
+//	Strategy: &engine.PID{TargetFailurePercent: 10}
+//
+// PID keeps state between intervals, so each Engine needs its own instance.
+type PID struct {
+	// TargetFailurePercent is the failure rate the controller steers toward.
+	TargetFailurePercent int64
+
+	// Kp is the proportional gain: how strongly the current error moves the flow rate.
+	// If Kp, Ki, and Kd are all zero, DefaultKp, DefaultKi, and DefaultKd are used.
+	Kp float64
+
+	// Ki is the integral gain: how strongly a persistent error moves the flow rate.
+	Ki float64
+
+	// Kd is the derivative gain: how strongly a changing error moves the flow rate.
+	Kd float64
+
+	// integral accumulates the error, bounded to [-100, 100] to prevent windup.
+	integral float64
+
+	// previous is the error from the previous interval.
+	previous float64
+
+	// started is false until the first call to Next, so the first derivative is zero.
+	started bool
+}
+
+const (
+	// DefaultKp is the proportional gain PID uses when no gains are set.
+	DefaultKp = 0.5
+
+	// DefaultKi is the integral gain PID uses when no gains are set.
+	DefaultKi = 0.1
+
+	// DefaultKd is the derivative gain PID uses when no gains are set.
+	DefaultKd = 0.1
+)
+
+// Next implements Strategy.
+func (p *PID) Next(current int64, o Observation) int64 {
+	kp, ki, kd := p.Kp, p.Ki, p.Kd
+	if kp == 0 && ki == 0 && kd == 0 {
+		kp, ki, kd = DefaultKp, DefaultKi, DefaultKd
+	}
+
+	err := float64(p.TargetFailurePercent - o.FailureRate)
+
+	p.integral = math.Max(-100, math.Min(100, p.integral+err))
+
+	var derivative float64
+	if p.started {
+		derivative = err - p.previous
+	}
+
+	p.previous = err
+	p.started = true
+
+	return current + int64(math.Round(kp*err+ki*p.integral+kd*derivative))
+}
+
+// clone implements cloner.
+func (p *PID) clone() Strategy {
+	c := *p
+
+	return &c
+}
+
+// reset implements resetter.
+// The gains and target are configuration, so they are kept.
+func (p *PID) reset() {
+	p.integral, p.previous, p.started = 0, 0, false
+}
+
+// Curve decides how far the flow rate moves on each consecutive interval in the same direction.
+// step is 0 on the first interval after a change of direction, 1 on the next, and so on.
+// It returns the size of the move, which should be positive.
+//
+// Example:
+//
+//	// Moves by 1, 1, 1, then 5 from the fourth interval on.
+//	func(step int) int64 {
+//		if step < 3 {
+//			return 1
+//		}
+//		return 5
+//	}
+type Curve func(step int) int64
+
+// LinearCurve moves the flow rate by the same amount every interval.
+// Example: LinearCurve(1) reopens by 1% per interval.
+func LinearCurve(delta int64) Curve {
+	return func(int) int64 {
+		return delta
+	}
+}
+
+// ExponentialCurve moves the flow rate by 1, 2, 4, 8, ... doubling every interval.
+// It is the same curve the Exponential strategy follows.
+func ExponentialCurve() Curve {
+	return func(step int) int64 {
+		if step >= 7 {
+			return 128
+		}
+
+		return 1 << step
+	}
+}
+
+// StepCurve moves the flow rate by each of deltas in turn, then keeps using the last one.
+// Example: StepCurve(1, 1, 5, 10) moves by 1, 1, 5, 10, 10, 10, ...
+// With no deltas, it moves by 1.
+func StepCurve(deltas ...int64) Curve {
+	return func(step int) int64 {
+		if len(deltas) == 0 {
+			return 1
+		}
+
+		return deltas[min(step, len(deltas)-1)]
+	}
+}
+
+// Ramp is a Strategy that follows separate curves for opening and closing.
+// Use it when a dependency needs to reopen much more slowly than it should close.
+//
+// Example:
+//
+//	// Close exponentially to limit the blast radius, but reopen by only 1% per interval.
+//	Strategy: &engine.Ramp{
+//		Open:  engine.LinearCurve(1),
+//		Close: engine.ExponentialCurve(),
+//	}
+//
+// Ramp keeps state between intervals, so each Engine needs its own instance.
+type Ramp struct {
+	// Open is the curve followed while the Engine is opening.
+	// If nil, ExponentialCurve is used.
+	Open Curve
+
+	// Close is the curve followed while the Engine is closing.
+	// If nil, ExponentialCurve is used.
+	Close Curve
+
+	// direction is the State of the previous call to Next.
+	direction State
+
+	// step counts the consecutive calls to Next in the current direction.
+	step int
+}
+
+// Next implements Strategy.
+func (r *Ramp) Next(current int64, o Observation) int64 {
+	if o.State == Opening && current == 100 {
+		return current
+	}
+
+	if o.State != r.direction {
+		r.direction = o.State
+		r.step = 0
+	}
+
+	curve := r.Open
+	if o.State == Closing {
+		curve = r.Close
+	}
+
+	if curve == nil {
+		curve = ExponentialCurve()
+	}
+
+	delta := curve(r.step)
+	r.step++
+
+	if o.State == Closing {
+		return current - delta
+	}
+
+	return current + delta
+}
+
+// clone implements cloner.
+func (r *Ramp) clone() Strategy {
+	c := *r
+
+	return &c
+}
+
+// reset implements resetter.
+// The curves are configuration, so they are kept.
+func (r *Ramp) reset() {
+	r.direction, r.step = "", 0
+}
@@ -0,0 +1,49 @@
+package nozzle
+
+import "time"
+
+// EstimatedReopen projects how long until the flow rate reaches target, if no call fails from now on.
+// It answers "when will traffic be back?" from the Strategy's trajectory, the Interval, and anything holding the Nozzle closed,
+// such as Options.ReopenCooldown, Options.WarmUp, or a manual override that expires.
+//
+// It reports false when there is no estimate: for a Strategy outside this module, a Nozzle without a positive Interval,
+// an override that does not expire, a Nozzle that is paused, or a target the Strategy never reaches.
+//
+// Example:
+//
+//	if d, ok := n.EstimatedReopen(100); ok {
+//		fmt.Printf("fully open in about %s\n", d.Round(time.Second))
+//	}
+func (n *Nozzle[T]) EstimatedReopen(target int64) (time.Duration, bool) {
+	if n.passThrough() {
+		return 0, true
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	if n.effectiveFlowRate() >= target {
+		return 0, true
+	}
+
+	interval := n.options().Interval
+	if interval <= 0 || n.paused || (n.forced() != "" && n.overrideUntil.IsZero()) {
+		return 0, false
+	}
+
+	intervals, ok := n.engine.Project(target)
+	if !ok {
+		return 0, false
+	}
+
+	// The first move happens at the next decision, and each later one an Interval after it.
+	estimate := n.retryAfter() + time.Duration(max(intervals-1, 0))*interval
+
+	// The warm-up cap rises linearly, so it may reach target later than the Strategy would.
+	if floor := n.warmUpFlowRate(); n.options().WarmUp > 0 && target > floor {
+		capped := time.Duration(float64(n.options().WarmUp) * float64(target-floor) / float64(100-floor))
+		estimate = max(estimate, capped-n.since(n.created))
+	}
+
+	return estimate, true
+}
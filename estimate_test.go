@@ -0,0 +1,83 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEstimatedReopen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prepare  func(noz *Nozzle[any])
+		estimate time.Duration
+		ok       bool
+	}{
+		{
+			// The next decision is in a minute, then 6 more intervals: 1, 3, 7, 15, 31, 63, 100.
+			prepare:  func(*Nozzle[any]) {},
+			estimate: 7 * time.Minute,
+			ok:       true,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				configure(noz, func(options *Options[any]) { options.ReopenCooldown = 150 * time.Second })
+				noz.closedAt = noz.start
+			},
+			estimate: 9 * time.Minute,
+			ok:       true,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				noz.ForceCloseFor(time.Hour, "maintenance")
+			},
+			estimate: time.Hour + 6*time.Minute,
+			ok:       true,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				noz.force(ForcedClosed, 0, "until further notice")
+			},
+			ok: false,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				noz.ForceOpenFor(time.Hour, "verifying")
+			},
+			estimate: 0,
+			ok:       true,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				configure(noz, func(options *Options[any]) { options.Interval = 0 })
+			},
+			ok: false,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := newTestNozzle(Options[any]{
+				Interval:              time.Minute,
+				AllowedFailurePercent: 50,
+			}, 0)
+			noz.start = time.Now()
+
+			test.prepare(noz)
+
+			estimate, ok := noz.EstimatedReopen(100)
+
+			if ok != test.ok {
+				t.Fatalf("Expected ok=%t Got=%t", test.ok, ok)
+			}
+
+			// Allow for the time the test takes to run.
+			if estimate > test.estimate || estimate < test.estimate-time.Second {
+				t.Errorf("Expected estimate=%s Got=%s", test.estimate, estimate)
+			}
+		})
+	}
+}
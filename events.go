@@ -0,0 +1,81 @@
+package nozzle
+
+import "time"
+
+// EventType identifies the kind of an Event.
+type EventType string
+
+const (
+	// EventOverrideStarted is reported when a manual override starts, such as with ForceCloseFor.
+	EventOverrideStarted EventType = "override-started"
+
+	// EventOverrideEnded is reported when a manual override ends, either because it expired or because it was replaced.
+	EventOverrideEnded EventType = "override-ended"
+
+	// EventOverridesApplied is only recorded by RegistryOptions.Audit, once for each batch of overrides applied with Registry.Apply.
+	// Its Reason lists the batch's overrides, such as "payments=forced-closed (INC-42), refunds=forced-closed (INC-42)".
+	EventOverridesApplied EventType = "overrides-applied"
+
+	// EventIntervalClamped is reported by New when Options.Interval is below MinInterval.
+	// Its Duration is the Interval the Nozzle uses instead.
+	EventIntervalClamped EventType = "interval-clamped"
+
+	// EventFlowRateChanged is only recorded by Options.Audit, when the Nozzle's flow rate or state changes at the end of an interval.
+	// Options.OnStateChange reports the same changes.
+	EventFlowRateChanged EventType = "flow-rate-changed"
+
+	// EventPaused is reported when Pause freezes the flow rate.
+	EventPaused EventType = "paused"
+
+	// EventResumed is reported when Resume lets the flow rate adapt again.
+	EventResumed EventType = "resumed"
+
+	// EventReset is reported when Reset restores the Nozzle to fully open.
+	// Its State and Reason are the ones the Nozzle had before the reset.
+	EventReset EventType = "reset"
+
+	// EventVerificationFailed is reported when Options.Verify returns an error.
+	// Its Reason is the error's message.
+	EventVerificationFailed EventType = "verification-failed"
+
+	// EventCompatLevel is reported by New with the active Options.CompatLevel.
+	// Its Reason states the level, such as "compat level 2".
+	EventCompatLevel EventType = "compat-level"
+
+	// EventMaintenanceStarted is reported when an interval ends that overlapped a window declared by Options.Maintenance, after one that did not.
+	// Its Reason is the window's.
+	EventMaintenanceStarted EventType = "maintenance-started"
+
+	// EventMaintenanceEnded is reported when an interval ends that overlapped no maintenance window, after one that did.
+	// Its Reason is the ended window's.
+	EventMaintenanceEnded EventType = "maintenance-ended"
+)
+
+// Event describes something notable that happened to a Nozzle.
+// Events are delivered to Options.OnEvent.
+type Event struct {
+	// Type identifies what happened.
+	Type EventType
+
+	// Time is when it happened.
+	Time time.Time
+
+	// State is the state the event relates to.
+	// Example: For override events, it is ForcedOpen or ForcedClosed.
+	State State
+
+	// Reason is the human-readable explanation given for the event, if any.
+	Reason string
+
+	// Duration is how long the event's effect lasts, if it is time-bound.
+	// Example: For EventOverrideStarted, it is the duration passed to ForceCloseFor.
+	Duration time.Duration
+}
+
+// emit delivers an Event to Options.OnEvent, if set.
+// The caller must not hold the lock.
+func (n *Nozzle[T]) emit(e Event) {
+	if n.options().OnEvent != nil {
+		n.options().OnEvent(e)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+// errInventory is returned when the inventory service responds with a status other than 2xx.
+var errInventory = errors.New("orders: inventory failed")
+
+// errShutdown is returned by Shutdown when orders were still queued when its context ended.
+var errShutdown = errors.New("orders: shutdown before every order was fulfilled")
+
+// Config controls an App.
+type Config struct {
+	// Inventory is the base URL of the inventory service, the App's only dependency.
+	Inventory string
+
+	// Interval is the Interval of every Nozzle.
+	// If zero, one second is used.
+	Interval time.Duration
+
+	// Workers is how many orders are fulfilled at once.
+	// If zero, 1 is used.
+	Workers int
+
+	// Queue is how many accepted orders may wait for a worker. When it is full, new orders are rejected.
+	// If zero, 100 is used.
+	Queue int
+
+	// Clock and ManualTick are passed to every Nozzle, so tests can end intervals deterministically.
+	Clock      nozzle.Clock
+	ManualTick bool
+
+	// Logger receives the Nozzles' warnings and the workers' errors.
+	// If nil, slog.Default() is used.
+	Logger *slog.Logger
+}
+
+// App is the reference application: an HTTP API that reads from the inventory service, and workers that fulfil orders against it.
+//
+// Every Nozzle lives in one Registry, which the admin endpoints, the dashboard, and the metrics read from, and which Shutdown closes:
+//
+//	api        sheds incoming requests when the App itself fails, see protect
+//	inventory  sheds calls to the inventory service, from the API through nozzlehttp.Transport and from the workers through DoWaitContext
+type App struct {
+	config   Config
+	registry *nozzle.Registry[*http.Response]
+
+	// api protects the App's own handlers.
+	api *nozzle.Nozzle[*http.Response]
+
+	// inventory protects the inventory service.
+	inventory *nozzle.Nozzle[*http.Response]
+
+	// client sends the API's requests to the inventory service, through the inventory Nozzle.
+	client *http.Client
+
+	// orders queues accepted orders for the workers, by item.
+	orders chan string
+
+	// stop cancels the workers' calls, when Shutdown runs out of time.
+	stop context.CancelFunc
+
+	workers   sync.WaitGroup
+	fulfilled atomic.Int64
+	failed    atomic.Int64
+}
+
+// New creates an App, and its Nozzles. Call Start to start its workers, and Shutdown to stop them.
+func New(config Config) (*App, error) {
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+
+	if config.Queue <= 0 {
+		config.Queue = 100
+	}
+
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	a := &App{
+		config:   config,
+		registry: nozzle.NewRegistry[*http.Response](nozzle.RegistryOptions{}),
+		orders:   make(chan string, config.Queue),
+		stop:     func() {},
+	}
+
+	var err error
+
+	a.api, err = a.registry.New("api", a.options())
+	if err != nil {
+		return nil, fmt.Errorf("orders: %w", err)
+	}
+
+	a.inventory, err = a.registry.New("inventory", a.options())
+	if err != nil {
+		return nil, fmt.Errorf("orders: %w", err)
+	}
+
+	a.client = &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &nozzlehttp.Transport{
+			Nozzle:          a.inventory,
+			HonorRetryAfter: true,
+		},
+	}
+
+	return a, nil
+}
+
+// options are the Options shared by every Nozzle of the App.
+func (a *App) options() nozzle.Options[*http.Response] {
+	return nozzle.Options[*http.Response]{
+		Interval:              a.config.Interval,
+		AllowedFailurePercent: 50,
+		Clock:                 a.config.Clock,
+		ManualTick:            a.config.ManualTick,
+		Logger:                a.config.Logger,
+	}
+}
+
+// Start starts the workers.
+func (a *App) Start() {
+	ctx, stop := context.WithCancel(context.Background())
+	a.stop = stop
+
+	for range a.config.Workers {
+		a.workers.Add(1)
+
+		go a.work(ctx)
+	}
+}
+
+// Shutdown stops accepting orders, and waits for the workers to fulfil the queued ones until ctx is done.
+// Then it closes every Nozzle. Stop serving Handler before calling it, since orders cannot be accepted after.
+func (a *App) Shutdown(ctx context.Context) error {
+	close(a.orders)
+
+	drained := make(chan struct{})
+
+	go func() {
+		defer close(drained)
+
+		a.workers.Wait()
+	}()
+
+	var err error
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = fmt.Errorf("%w: %w", errShutdown, ctx.Err())
+
+		// Cancel the calls in progress; their orders, and the queued ones, count as failed.
+		a.stop()
+		<-drained
+	}
+
+	a.stop()
+
+	return errors.Join(err, a.registry.Close())
+}
+
+// Handler serves the public API:
+//
+//	GET  /stock/{item}  the inventory service's stock of item
+//	POST /orders        queues {"item": "..."} to be fulfilled by a worker
+func (a *App) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /stock/{item}", a.stock)
+	mux.HandleFunc("POST /orders", a.order)
+
+	return protect(a.api, mux)
+}
+
+// AdminHandler serves the operator endpoints, which must only be reachable internally:
+//
+//	/nozzles/...    nozzlehttp.AdminHandler, to inspect and force the Nozzles
+//	/debug/nozzles  nozzlehttp.DashboardHandler
+//	/metrics        every Nozzle's metrics, for Prometheus to scrape
+func (a *App) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	admin := nozzlehttp.AdminHandler(a.registry, nozzlehttp.AdminOptions{})
+
+	mux.Handle("/nozzles", admin)
+	mux.Handle("/nozzles/", admin)
+	mux.Handle("GET /debug/nozzles", nozzlehttp.DashboardHandler(a.registry))
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		writeMetrics(w, a.registry) //nolint:errcheck // the status is already sent.
+	})
+
+	return mux
+}
+
+// Fulfilled reports how many orders the workers fulfilled.
+func (a *App) Fulfilled() int64 {
+	return a.fulfilled.Load()
+}
+
+// Failed reports how many orders the workers gave up on.
+func (a *App) Failed() int64 {
+	return a.failed.Load()
+}
+
+// stock proxies the inventory service's stock of an item.
+func (a *App) stock(w http.ResponseWriter, r *http.Request) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, a.config.Inventory+"/stock/"+url.PathEscape(r.PathValue("item")), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	resp, err := a.client.Do(req)
+
+	var blocked *nozzle.BlockedError
+	if errors.As(err, &blocked) {
+		// The inventory service is failing, so tell the caller when to come back instead of waiting for a timeout.
+		if blocked.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+		}
+
+		http.Error(w, "inventory is unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, fmt.Sprintf("inventory responded %d", resp.StatusCode), http.StatusBadGateway)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	io.Copy(w, resp.Body) //nolint:errcheck // the status is already sent.
+}
+
+// order queues an order for the workers.
+func (a *App) order(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Item string `json:"item"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Item == "" {
+		http.Error(w, `expected {"item": "..."}`, http.StatusBadRequest)
+
+		return
+	}
+
+	select {
+	case a.orders <- body.Item:
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "too many orders are queued", http.StatusServiceUnavailable)
+	}
+}
+
+// work fulfils queued orders until the queue is closed and empty.
+func (a *App) work(ctx context.Context) {
+	defer a.workers.Done()
+
+	for item := range a.orders {
+		if err := a.fulfil(ctx, item); err != nil {
+			a.failed.Add(1)
+			a.config.Logger.Error("orders: could not fulfil order", "item", item, "error", err)
+
+			continue
+		}
+
+		a.fulfilled.Add(1)
+	}
+}
+
+// fulfil reserves an item from the inventory service.
+// Unlike the API, which sheds calls the inventory Nozzle blocks, a worker waits for the Nozzle to allow it, since its order was already accepted.
+func (a *App) fulfil(ctx context.Context, item string) error {
+	resp, err := a.inventory.DoWaitContext(ctx, func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.Inventory+"/reserve/"+url.PathEscape(item), nil)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // wrapped below.
+		}
+
+		// Not a.client: its Transport would send the call through the inventory Nozzle a second time.
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // wrapped below.
+		}
+		defer resp.Body.Close()
+
+		if nozzlehttp.ClassifyStatus(resp, nil) == nozzle.Failure {
+			return resp, fmt.Errorf("%w: status %d", errInventory, resp.StatusCode)
+		}
+
+		return resp, nil
+	})
+	if err != nil {
+		return fmt.Errorf("orders: reserve %s: %w", item, err)
+	}
+
+	// Such as 409 when the item is out of stock: the inventory service is healthy, but the order cannot be fulfilled.
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: reserve %s: status %d", errInventory, item, resp.StatusCode)
+	}
+
+	return nil
+}
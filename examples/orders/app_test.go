@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// inventory fakes the inventory service. While failing is set, every call fails with 500.
+func inventory(failing *atomic.Bool) *httptest.Server {
+	mux := http.NewServeMux()
+
+	fail := func(w http.ResponseWriter) bool {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		return failing.Load()
+	}
+
+	mux.HandleFunc("GET /stock/{item}", func(w http.ResponseWriter, r *http.Request) {
+		if !fail(w) {
+			io.WriteString(w, `{"item":"`+r.PathValue("item")+`","available":3}`) //nolint:errcheck
+		}
+	})
+
+	mux.HandleFunc("POST /reserve/{item}", func(w http.ResponseWriter, _ *http.Request) {
+		fail(w)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestApp(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+
+	upstream := inventory(&failing)
+	defer upstream.Close()
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	app, err := New(Config{
+		Inventory:  upstream.URL,
+		Interval:   time.Second,
+		Workers:    2,
+		Clock:      clock,
+		ManualTick: true,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Start()
+
+	api := httptest.NewServer(app.Handler())
+	defer api.Close()
+
+	admin := httptest.NewServer(app.AdminHandler())
+	defer admin.Close()
+
+	request := func(server *httptest.Server, method, path, body string) (int, string) {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(context.Background(), method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp.StatusCode, string(b)
+	}
+
+	tick := func() {
+		clock.Advance(time.Second)
+		app.api.Tick()
+		app.inventory.Tick()
+	}
+
+	if status, body := request(api, http.MethodGet, "/stock/widget", ""); status != http.StatusOK || !strings.Contains(body, `"available":3`) {
+		t.Errorf("Expected the stock of widget Got status=%d %s", status, body)
+	}
+
+	// The inventory service fails: the API reports it, and the inventory Nozzle starts shedding calls to it.
+	failing.Store(true)
+
+	for range 10 {
+		if status, _ := request(api, http.MethodGet, "/stock/widget", ""); status != http.StatusBadGateway {
+			t.Errorf("Expected status=%d Got=%d", http.StatusBadGateway, status)
+		}
+	}
+
+	tick()
+
+	if s := app.inventory.State(); s != nozzle.Closing {
+		t.Errorf("Expected the inventory Nozzle to be %s Got=%s", nozzle.Closing, s)
+	}
+
+	if s := app.api.State(); s != nozzle.Opening || app.api.FlowRate() != 100 {
+		t.Errorf("Expected the api Nozzle to ignore the inventory's failures Got State=%s FlowRate=%d", s, app.api.FlowRate())
+	}
+
+	// An operator closes the inventory Nozzle through the admin endpoint, so the API sheds immediately.
+	if status, body := request(admin, http.MethodPost, "/nozzles/inventory/force-close", `{"reason":"INC-42","duration":"1m"}`); status != http.StatusOK {
+		t.Fatalf("Expected status=200 Got=%d %s", status, body)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, api.URL+"/stock/widget", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := api.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "60" {
+		t.Errorf("Expected status=503 Retry-After=60 Got status=%d Retry-After=%q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	if _, body := request(admin, http.MethodGet, "/metrics", ""); !strings.Contains(body, `nozzle_state{nozzle="inventory",nozzle_state="forced-closed"} 1`) || !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("Expected metrics of the forced-closed inventory Nozzle Got=%s", body)
+	}
+
+	if _, body := request(admin, http.MethodGet, "/debug/nozzles", ""); !strings.Contains(body, "INC-42") {
+		t.Errorf("Expected the dashboard to show the override Got=%s", body)
+	}
+
+	// The inventory service recovers. Orders accepted while it is closed wait for it instead of failing.
+	failing.Store(false)
+
+	for range 3 {
+		if status, _ := request(api, http.MethodPost, "/orders", `{"item":"widget"}`); status != http.StatusAccepted {
+			t.Errorf("Expected status=%d Got=%d", http.StatusAccepted, status)
+		}
+	}
+
+	if status, _ := request(api, http.MethodPost, "/orders", `{}`); status != http.StatusBadRequest {
+		t.Errorf("Expected status=%d Got=%d", http.StatusBadRequest, status)
+	}
+
+	if status, _ := request(admin, http.MethodPost, "/nozzles/inventory/unforce", ""); status != http.StatusOK {
+		t.Errorf("Expected status=200 Got=%d", status)
+	}
+
+	// Shutdown waits for the queued orders.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- app.Shutdown(ctx)
+	}()
+
+	// The workers wait for the Nozzle to allow them, which takes intervals to end.
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if app.Fulfilled() != 3 || app.Failed() != 0 {
+				t.Errorf("Expected Fulfilled=3 Failed=0 Got Fulfilled=%d Failed=%d", app.Fulfilled(), app.Failed())
+			}
+
+			return
+		case <-time.After(10 * time.Millisecond):
+			tick()
+		}
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+
+	upstream := inventory(&failing)
+	defer upstream.Close()
+
+	app, err := New(Config{
+		Inventory:  upstream.URL,
+		ManualTick: true,
+		Logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.Start()
+
+	// Closed indefinitely, so the queued order can never be fulfilled.
+	app.inventory.ForceClose("maintenance")
+
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"item":"widget"}`)))
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected status=%d Got=%d", http.StatusAccepted, rec.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := app.Shutdown(ctx); err == nil {
+		t.Error("Expected an error for the order left unfulfilled")
+	}
+
+	if app.Fulfilled() != 0 || app.Failed() != 1 {
+		t.Errorf("Expected Fulfilled=0 Failed=1 Got Fulfilled=%d Failed=%d", app.Fulfilled(), app.Failed())
+	}
+}
@@ -0,0 +1,94 @@
+// Command orders is a reference application showing how the parts of nozzle fit together in a service.
+//
+// It serves a small HTTP API backed by an inventory service, and runs workers that fulfil orders against the same service:
+//
+//   - A Registry owns every Nozzle, and closes them at shutdown.
+//   - Middleware sheds incoming requests with the api Nozzle, and nozzlehttp.Transport sheds outgoing ones with the inventory Nozzle.
+//   - Workers wait for the inventory Nozzle with DoWaitContext, since their orders were already accepted.
+//   - An internal admin address serves nozzlehttp.AdminHandler, nozzlehttp.DashboardHandler, and metrics for Prometheus.
+//   - SIGINT and SIGTERM stop the servers, then drain the workers, within a deadline.
+//
+// Copy what you need; App is the part to read.
+//
+// Usage:
+//
+//	go run ./examples/orders -inventory http://inventory.internal -addr :8080 -admin 127.0.0.1:9090
+//
+//	curl localhost:8080/stock/widget
+//	curl -X POST localhost:8080/orders -d '{"item":"widget"}'
+//	curl -X POST 127.0.0.1:9090/nozzles/inventory/force-close -d '{"reason":"INC-42","duration":"10m"}'
+//	open http://127.0.0.1:9090/debug/nozzles
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address of the public API")
+	adminAddr := flag.String("admin", "127.0.0.1:9090", "address of the admin endpoints; keep it internal")
+	inventory := flag.String("inventory", "http://localhost:8081", "base URL of the inventory service")
+	workers := flag.Int("workers", 4, "how many orders are fulfilled at once")
+	grace := flag.Duration("grace", 30*time.Second, "how long shutdown waits for requests and queued orders")
+	flag.Parse()
+
+	if err := run(*addr, *adminAddr, Config{Inventory: *inventory, Workers: *workers}, *grace); err != nil {
+		slog.Error("orders: stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run serves the App until SIGINT or SIGTERM, then shuts it down gracefully within grace.
+func run(addr, adminAddr string, config Config, grace time.Duration) error {
+	app, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	app.Start()
+
+	api := &http.Server{Addr: addr, Handler: app.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	admin := &http.Server{Addr: adminAddr, Handler: app.AdminHandler(), ReadHeaderTimeout: 5 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := make(chan error, 2)
+
+	for _, server := range []*http.Server{api, admin} {
+		go func() {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				failed <- err
+			}
+		}()
+	}
+
+	slog.Info("orders: serving", "addr", addr, "admin", adminAddr)
+
+	var serveErr error
+
+	select {
+	case <-ctx.Done():
+	case serveErr = <-failed:
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	// Stop accepting requests first, so no order is accepted after the workers stop.
+	// The admin endpoints stay up until the orders are drained, so operators can still see and force the Nozzles.
+	return errors.Join(
+		serveErr,
+		api.Shutdown(shutdown),
+		app.Shutdown(shutdown),
+		admin.Shutdown(shutdown),
+	)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// writeMetrics writes the metrics of every Nozzle of registry to w in the OpenMetrics text format, labeled by name.
+// It is the App's Prometheus collector: Nozzle.WriteOpenMetrics writes a complete exposition per Nozzle, so one response cannot combine them.
+func writeMetrics[T any](w io.Writer, registry *nozzle.Registry[T]) error {
+	// Neither counts as using the Nozzles, so scraping does not keep idle ones from expiring.
+	snapshots := registry.Snapshot()
+	stats := registry.Stats()
+
+	var buf bytes.Buffer
+
+	family := func(kind, name, help string) {
+		fmt.Fprintf(&buf, "# TYPE nozzle_%s %s\n# HELP nozzle_%s %s\n", name, kind, name, help)
+	}
+
+	family("gauge", "flow_rate", "Percentage of calls the Nozzle allows.")
+
+	for _, name := range registry.Names() {
+		fmt.Fprintf(&buf, "nozzle_flow_rate{nozzle=%q} %d\n", name, snapshots[name].FlowRate)
+	}
+
+	family("gauge", "failure_rate", "Percentage of allowed calls that failed in the last interval.")
+
+	for _, name := range registry.Names() {
+		fmt.Fprintf(&buf, "nozzle_failure_rate{nozzle=%q} %d\n", name, snapshots[name].FailureRate)
+	}
+
+	family("stateset", "state", "State of the Nozzle.")
+
+	for _, name := range registry.Names() {
+		for _, state := range []nozzle.State{nozzle.Opening, nozzle.Closing, nozzle.ForcedOpen, nozzle.ForcedClosed} {
+			var value int
+			if snapshots[name].State == state {
+				value = 1
+			}
+
+			fmt.Fprintf(&buf, "nozzle_state{nozzle=%q,nozzle_state=%q} %d\n", name, state, value)
+		}
+	}
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(nozzle.Stats) int64
+	}{
+		{name: "allowed", help: "Calls the Nozzle allowed.", value: func(s nozzle.Stats) int64 { return s.Allowed }},
+		{name: "blocked", help: "Calls the Nozzle blocked.", value: func(s nozzle.Stats) int64 { return s.Blocked }},
+		{name: "failures", help: "Allowed calls that failed.", value: func(s nozzle.Stats) int64 { return s.Failures }},
+	}
+
+	for _, c := range counters {
+		family("counter", c.name, c.help)
+
+		for _, name := range registry.Names() {
+			if s, ok := stats[name]; ok {
+				fmt.Fprintf(&buf, "nozzle_%s_total{nozzle=%q} %d\n", c.name, name, c.value(s))
+			}
+		}
+	}
+
+	buf.WriteString("# EOF\n")
+
+	_, err := buf.WriteTo(w)
+
+	return err //nolint:wrapcheck // the caller cannot recover from a failed write either way.
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// errInternal marks a request the App failed to serve, so the api Nozzle counts it as a failure.
+var errInternal = errors.New("orders: internal server error")
+
+// recorder remembers the status a handler wrote.
+type recorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// protect sends every request to next through noz, and rejects the requests it blocks with 503 Service Unavailable.
+//
+// Only 500 responses count as failures: they are the App's own bugs or overload.
+// A 502 or 503 means a dependency failed, and the dependency's own Nozzle already sheds those calls;
+// counting them here too would also shed the requests that do not need the dependency.
+func protect(noz *nozzle.Nozzle[*http.Response], next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := noz.DoErrorContext(r.Context(), func(ctx context.Context) (*http.Response, error) {
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if rec.status == http.StatusInternalServerError {
+				return nil, errInternal
+			}
+
+			return nil, nil
+		})
+
+		var blocked *nozzle.BlockedError
+		if errors.As(err, &blocked) {
+			if blocked.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+			}
+
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+		}
+	})
+}
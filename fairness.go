@@ -0,0 +1,111 @@
+package nozzle
+
+import (
+	"context"
+	"maps"
+)
+
+// callerKey is the context key WithCaller sets.
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx that labels calls admitted with it as made by caller, such as a tenant.
+// With Options.TrackFairness, the Nozzle records how each caller's calls were admitted while it sheds.
+// Use few distinct labels: each one is kept in memory for the life of the Nozzle.
+//
+// Example:
+//
+//	ctx = nozzle.WithCaller(ctx, tenantID)
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerAdmission counts how one caller's calls were admitted while the Nozzle was shedding.
+type CallerAdmission struct {
+	// Allowed is the number of the caller's calls that were allowed.
+	Allowed int64
+
+	// Blocked is the number of the caller's calls that were blocked.
+	Blocked int64
+}
+
+// FairnessReport is evidence of whether shedding treats every caller alike.
+// See nozzle.Fairness for how to get one.
+type FairnessReport struct {
+	// Callers holds the admissions of every labeled caller, by label.
+	Callers map[string]CallerAdmission
+
+	// JainIndex is Jain's fairness index of the callers' admission ratios, between 1/len(Callers) and 1.
+	// It is 1 when every caller had the same share of its calls allowed, and approaches 1/len(Callers) when one caller got everything.
+	// It is 1 when there are no callers.
+	JainIndex float64
+}
+
+// Fairness reports how the calls of each caller, labeled with WithCaller, were admitted while the flow rate was below 100.
+// It is only recorded with Options.TrackFairness. Unlabeled calls and calls blocked by Options.MaxConcurrent are not counted.
+//
+// Example:
+//
+//	report := n.Fairness()
+//	if report.JainIndex < 0.9 {
+//		// shedding is favoring some tenants over others.
+//	}
+func (n *Nozzle[T]) Fairness() FairnessReport {
+	if n.passThrough() {
+		return FairnessReport{JainIndex: 1}
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return FairnessReport{
+		Callers:   maps.Clone(n.callers),
+		JainIndex: jainIndex(n.callers),
+	}
+}
+
+// trackFairness counts an admission decision of weight weight for the caller of ctx, if the Nozzle is shedding.
+func (n *Nozzle[T]) trackFairness(ctx context.Context, allowed bool, weight int64) {
+	caller, ok := ctx.Value(callerKey{}).(string)
+	if !ok {
+		return
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if n.effectiveFlowRate() >= 100 {
+		return
+	}
+
+	if n.callers == nil {
+		n.callers = map[string]CallerAdmission{}
+	}
+
+	admission := n.callers[caller]
+
+	if allowed {
+		admission.Allowed += weight
+	} else {
+		admission.Blocked += weight
+	}
+
+	n.callers[caller] = admission
+}
+
+// jainIndex computes Jain's fairness index, (Σx)² / (n·Σx²), of the callers' allowed ratios.
+func jainIndex(callers map[string]CallerAdmission) float64 {
+	var sum, squares float64
+
+	for _, c := range callers {
+		x := float64(c.Allowed) / float64(c.Allowed+c.Blocked)
+		sum += x
+		squares += x * x
+	}
+
+	// Nobody was allowed anything, which is equally unfair to everyone.
+	if squares == 0 {
+		return 1
+	}
+
+	return sum * sum / (float64(len(callers)) * squares)
+}
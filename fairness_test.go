@@ -0,0 +1,95 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFairness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		flowRate   int64
+		priority   Priority
+		interleave bool
+		expectedA  CallerAdmission
+		expectedB  CallerAdmission
+		jainIndex  float64
+	}{
+		{
+			// Not shedding, so nothing is recorded.
+			flowRate:  100,
+			priority:  PriorityNormal,
+			jainIndex: 1,
+		},
+		{
+			flowRate:  50,
+			priority:  PriorityNormal,
+			expectedA: CallerAdmission{Allowed: 5, Blocked: 5},
+			expectedB: CallerAdmission{Allowed: 5, Blocked: 5},
+			jainIndex: 1,
+		},
+		{
+			// Strictly alternating callers fall in step with ratio-based admission, which favors b: (0.1 + 0.9)² / (2 × (0.1² + 0.9²)).
+			flowRate:   50,
+			priority:   PriorityNormal,
+			interleave: true,
+			expectedA:  CallerAdmission{Allowed: 1, Blocked: 9},
+			expectedB:  CallerAdmission{Allowed: 9, Blocked: 1},
+			jainIndex:  1 / 1.64,
+		},
+		{
+			// b's calls are low priority, so they are all shed: (0.5 + 0)² / (2 × 0.5²).
+			flowRate:  50,
+			priority:  PriorityLow,
+			expectedA: CallerAdmission{Allowed: 5, Blocked: 5},
+			expectedB: CallerAdmission{Allowed: 0, Blocked: 10},
+			jainIndex: 0.5,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := newTestNozzle(Options[any]{
+				AllowedFailurePercent: 50,
+				TrackFairness:         true,
+			}, test.flowRate)
+
+			a := WithCaller(context.Background(), "a")
+			b := WithPriority(WithCaller(context.Background(), "b"), test.priority)
+
+			call := func(ctx context.Context, calls int) {
+				for range calls {
+					noz.DoBoolContext(ctx, func(context.Context) (any, bool) { return nil, true })
+				}
+			}
+
+			if test.interleave {
+				for range 10 {
+					call(a, 1)
+					call(b, 1)
+				}
+			} else {
+				call(a, 10)
+				call(b, 10)
+			}
+
+			// Unlabeled calls are not counted.
+			noz.DoBool(func() (any, bool) { return nil, true })
+
+			report := noz.Fairness()
+
+			if report.Callers["a"] != test.expectedA || report.Callers["b"] != test.expectedB {
+				t.Errorf("Expected a=%+v b=%+v Got a=%+v b=%+v", test.expectedA, test.expectedB, report.Callers["a"], report.Callers["b"])
+			}
+
+			if math.Abs(report.JainIndex-test.jainIndex) > 1e-9 {
+				t.Errorf("Expected JainIndex=%v Got=%v", test.jainIndex, report.JainIndex)
+			}
+		})
+	}
+}
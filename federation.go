@@ -0,0 +1,162 @@
+package nozzle
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// Federation keeps a Nozzle per region and routes each call to the healthiest region.
+// It lets a multi-region client use its Nozzles as the health signal for failover: as a region's flow rate drops, calls move to the others.
+//
+// A region is healthier when its flow rate is higher.
+// Ties go to the region listed first, so list the preferred region, such as the local one, first.
+// Flow rates are read from each Nozzle's Snapshot, so routing never contends with calls.
+//
+// Example:
+//
+//	f := nozzle.NewFederation(nozzle.Options[*Order]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//	}, "us-east-1", "us-west-2", "eu-west-1")
+//	defer f.Close()
+//
+//	order, err := f.DoErrorContext(ctx, func(ctx context.Context, region string) (*Order, error) {
+//		return clients[region].GetOrder(ctx, id)
+//	})
+type Federation[T any] struct {
+	// regions are the region names, in order of preference.
+	regions []string
+
+	// nozzles holds each region's Nozzle.
+	nozzles map[string]*Nozzle[T]
+}
+
+// FederationSnapshot is a point-in-time view of every region of a Federation.
+type FederationSnapshot struct {
+	// Healthiest is the region calls are currently routed to first.
+	Healthiest string
+
+	// FlowRate is the flow rate of the healthiest region.
+	// Example: A FlowRate of 0 means every region is fully closed.
+	FlowRate int64
+
+	// Regions holds the Snapshot of each region's Nozzle.
+	Regions map[string]StateSnapshot
+}
+
+// NewFederation creates a Federation with a Nozzle for each region, all created with options.
+// regions are listed in order of preference; duplicates are ignored.
+func NewFederation[T any](options Options[T], regions ...string) *Federation[T] {
+	f := &Federation[T]{
+		nozzles: make(map[string]*Nozzle[T], len(regions)),
+	}
+
+	for _, region := range regions {
+		if _, ok := f.nozzles[region]; ok {
+			continue
+		}
+
+		f.regions = append(f.regions, region)
+		f.nozzles[region] = New(options)
+	}
+
+	return f
+}
+
+// Nozzle returns the Nozzle of region, or nil if the Federation has no such region.
+func (f *Federation[T]) Nozzle(region string) *Nozzle[T] {
+	return f.nozzles[region]
+}
+
+// Healthiest reports the region calls are currently routed to first.
+// It is empty when the Federation has no regions.
+func (f *Federation[T]) Healthiest() string {
+	ranked := f.ranked()
+	if len(ranked) == 0 {
+		return ""
+	}
+
+	return ranked[0]
+}
+
+// ranked returns the regions from healthiest to least healthy.
+func (f *Federation[T]) ranked() []string {
+	flowRates := make(map[string]int64, len(f.regions))
+	for _, region := range f.regions {
+		flowRates[region] = f.nozzles[region].Snapshot().FlowRate
+	}
+
+	ranked := slices.Clone(f.regions)
+
+	// A stable sort keeps the order of preference between regions with the same flow rate.
+	slices.SortStableFunc(ranked, func(a, b string) int {
+		return int(flowRates[b] - flowRates[a])
+	})
+
+	return ranked
+}
+
+// DoErrorContext runs callback through the healthiest region's Nozzle, passing it the region's name.
+// If that region blocks the call, the next healthiest is tried, and so on, so a call is only blocked when every region blocks it.
+// In that case, it returns the healthiest region's BlockedError.
+//
+// Only the region that runs callback counts its outcome, see Nozzle.DoErrorContext.
+func (f *Federation[T]) DoErrorContext(ctx context.Context, callback func(ctx context.Context, region string) (T, error)) (T, error) {
+	var first error
+
+	for _, region := range f.ranked() {
+		var ran bool
+
+		res, err := f.nozzles[region].DoErrorContext(ctx, func(ctx context.Context) (T, error) {
+			ran = true
+
+			return callback(ctx, region)
+		})
+		if ran || !errors.Is(err, ErrBlocked) {
+			return res, err
+		}
+
+		if first == nil {
+			first = err
+		}
+	}
+
+	if first == nil {
+		first = ErrBlocked
+	}
+
+	return *new(T), first
+}
+
+// Snapshot reports the Snapshot of every region, along with the region calls are routed to first.
+func (f *Federation[T]) Snapshot() FederationSnapshot {
+	s := FederationSnapshot{
+		Regions: make(map[string]StateSnapshot, len(f.regions)),
+	}
+
+	for _, region := range f.regions {
+		s.Regions[region] = f.nozzles[region].Snapshot()
+	}
+
+	for _, region := range f.regions {
+		if s.Healthiest == "" || s.Regions[region].FlowRate > s.FlowRate {
+			s.Healthiest = region
+			s.FlowRate = s.Regions[region].FlowRate
+		}
+	}
+
+	return s
+}
+
+// Close closes every region's Nozzle, and returns their errors joined.
+// See Nozzle.Close.
+func (f *Federation[T]) Close() error {
+	errs := make([]error, 0, len(f.regions))
+
+	for _, region := range f.regions {
+		errs = append(errs, f.nozzles[region].Close())
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,65 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestFederation(t *testing.T) {
+	t.Parallel()
+
+	f := nozzle.NewFederation(nozzle.Options[string]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	}, "us-east-1", "us-west-2", "us-east-1", "eu-west-1")
+
+	call := func() (string, error) {
+		return f.DoErrorContext(context.Background(), func(_ context.Context, region string) (string, error) {
+			return region, nil
+		})
+	}
+
+	// Every region is healthy, so the first listed is preferred.
+	if region, err := call(); region != "us-east-1" || err != nil {
+		t.Errorf("Expected region=us-east-1 err=nil Got region=%s err=%v", region, err)
+	}
+
+	f.Nozzle("us-east-1").ForceCloseFor(time.Hour, "regional outage")
+
+	if healthiest := f.Healthiest(); healthiest != "us-west-2" {
+		t.Errorf("Expected Healthiest=us-west-2 Got=%s", healthiest)
+	}
+
+	if region, err := call(); region != "us-west-2" || err != nil {
+		t.Errorf("Expected failover region=us-west-2 err=nil Got region=%s err=%v", region, err)
+	}
+
+	s := f.Snapshot()
+
+	if len(s.Regions) != 3 {
+		t.Errorf("Expected 3 regions, without duplicates Got=%d", len(s.Regions))
+	}
+
+	if s.Healthiest != "us-west-2" || s.FlowRate != 100 {
+		t.Errorf("Expected Healthiest=us-west-2 FlowRate=100 Got Healthiest=%s FlowRate=%d", s.Healthiest, s.FlowRate)
+	}
+
+	if state := s.Regions["us-east-1"].State; state != nozzle.ForcedClosed {
+		t.Errorf("Expected us-east-1 State=%s Got=%s", nozzle.ForcedClosed, state)
+	}
+
+	f.Nozzle("us-west-2").ForceCloseFor(time.Hour, "regional outage")
+	f.Nozzle("eu-west-1").ForceCloseFor(time.Hour, "regional outage")
+
+	if region, err := call(); region != "" || !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected every region to block, err=%v Got region=%s err=%v", nozzle.ErrBlocked, region, err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Errorf("Expected Close err=nil Got=%v", err)
+	}
+}
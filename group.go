@@ -0,0 +1,131 @@
+package nozzle
+
+import (
+	"context"
+	"sync"
+)
+
+// FanOut runs functions in parallel through a Nozzle, like errgroup.Group.
+// Go only launches the functions the Nozzle admits; blocked ones are skipped and counted, and never fail the group.
+// Each launched function's error is its outcome, as with DoErrorContext.
+//
+// Create one with Group.
+type FanOut[T any] struct {
+	// nozzle admits the functions.
+	nozzle *Nozzle[T]
+
+	// ctx is passed to every function, and canceled by the first error.
+	ctx context.Context //nolint:containedctx // mirrors errgroup.WithContext.
+
+	// cancel cancels ctx with the first error.
+	cancel context.CancelCauseFunc
+
+	// wg tracks the launched functions.
+	wg sync.WaitGroup
+
+	// mut guards err and skipped.
+	mut sync.Mutex
+
+	// err is the first error returned by a function.
+	err error
+
+	// skipped counts the functions the Nozzle blocked.
+	skipped int
+}
+
+// Group creates a FanOut that launches functions admitted by n, and a context derived from ctx.
+// The context is canceled when a function returns an error, or when Wait returns, whichever comes first.
+//
+// Example:
+//
+//	g, ctx := nozzle.Group(ctx, n)
+//
+//	for _, id := range ids {
+//		g.Go(func(ctx context.Context) error {
+//			return fetch(ctx, id)
+//		})
+//	}
+//
+//	if err := g.Wait(); err != nil {
+//		// a launched function failed.
+//	}
+//
+//	fmt.Printf("%d fetches were shed\n", g.Skipped())
+func Group[T any](ctx context.Context, n *Nozzle[T]) (*FanOut[T], context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	return &FanOut[T]{nozzle: n, ctx: ctx, cancel: cancel}, ctx
+}
+
+// Go launches f in a new goroutine if the Nozzle admits it, and reports whether it did.
+// The decision is made before Go returns, so callers can react to a blocked function right away.
+// A pass-through Nozzle launches every function, see PassThroughs.
+func (g *FanOut[T]) Go(f func(ctx context.Context) error) bool {
+	if g.nozzle.passedThrough() {
+		g.launch(f)
+
+		return true
+	}
+
+	decision, ok := g.nozzle.admit(g.ctx, 1)
+	if !ok {
+		g.mut.Lock()
+		g.skipped++
+		g.mut.Unlock()
+
+		return false
+	}
+
+	g.launch(func(ctx context.Context) error {
+		_, panicked, err := g.nozzle.call(ctx, decision, func(ctx context.Context) (T, error) {
+			return *new(T), f(ctx)
+		})
+
+		if !panicked {
+			g.nozzle.outcomeContext(ctx, err)
+		}
+
+		return err
+	})
+
+	return true
+}
+
+// launch runs f in a new goroutine, and cancels the group with its error, if it is the first.
+func (g *FanOut[T]) launch(f func(ctx context.Context) error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+
+		if err := f(g.ctx); err != nil {
+			g.mut.Lock()
+
+			if g.err == nil {
+				g.err = err
+				g.cancel(err)
+			}
+
+			g.mut.Unlock()
+		}
+	}()
+}
+
+// Wait blocks until every launched function has returned, and returns the first error any of them returned.
+func (g *FanOut[T]) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	return g.err
+}
+
+// Skipped reports how many functions the Nozzle blocked.
+func (g *FanOut[T]) Skipped() int {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	return g.skipped
+}
@@ -0,0 +1,60 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		InitialFlowRate:       50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	g, ctx := nozzle.Group(context.Background(), noz)
+
+	var ran atomic.Int64
+	var launched int
+
+	// At a 50% flow rate, every other function is launched.
+	for i := range 10 {
+		if g.Go(func(context.Context) error {
+			ran.Add(1)
+
+			if i == 0 {
+				return errUnavailable
+			}
+
+			return nil
+		}) {
+			launched++
+		}
+	}
+
+	if err := g.Wait(); !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected err=%v Got=%v", errUnavailable, err)
+	}
+
+	if launched != 5 || ran.Load() != 5 || g.Skipped() != 5 {
+		t.Errorf("Expected launched=5 ran=5 skipped=5 Got launched=%d ran=%d skipped=%d", launched, ran.Load(), g.Skipped())
+	}
+
+	if ctx.Err() == nil {
+		t.Error("Expected the group's context to be canceled")
+	}
+
+	if stats := noz.Stats(); stats.Allowed != 5 || stats.Blocked != 5 || stats.Failures != 1 {
+		t.Errorf("Expected Allowed=5 Blocked=5 Failures=1 Got=%+v", stats)
+	}
+}
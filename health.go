@@ -0,0 +1,114 @@
+package nozzle
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// HealthOptions decides when a Health reports the Nozzle as unhealthy.
+type HealthOptions struct {
+	// MinFlowRate is the lowest flow rate that is healthy.
+	// Example: With 50, a Nozzle that sheds more than half of its calls is unhealthy.
+	// If zero, only a fully closed Nozzle is unhealthy.
+	MinFlowRate int64
+
+	// Intervals is how many completed intervals in a row must be below MinFlowRate before the Nozzle is unhealthy,
+	// so a single bad interval does not take a replica out of rotation.
+	// It may exceed how many intervals History remembers.
+	// If zero, 1 is used.
+	Intervals int
+}
+
+// Health maps a Nozzle's flow rate to health check semantics, for readiness probes.
+// Create one with Nozzle.Health.
+type Health[T any] struct {
+	nozzle  *Nozzle[T]
+	options HealthOptions
+
+	// below counts the completed intervals in a row whose flow rate was below MinFlowRate.
+	// The Nozzle updates it with its lock held, as each interval ends.
+	below int
+}
+
+// Health creates a Health that reports the Nozzle as unhealthy once its flow rate has stayed below HealthOptions.MinFlowRate for HealthOptions.Intervals completed intervals.
+// Use it as a Kubernetes readiness probe, so a replica that cannot reach its dependency stops receiving traffic it would only shed.
+// Manual overrides count like any other flow rate: ForceClose makes the Nozzle unhealthy too.
+// The Nozzle updates every Health it created as each interval ends, so create one per probe, not one per request.
+//
+// Example:
+//
+//	http.Handle("/readyz", payments.Health(nozzle.HealthOptions{
+//		MinFlowRate: 50,
+//		Intervals:   3,
+//	}))
+func (n *Nozzle[T]) Health(options HealthOptions) *Health[T] {
+	if options.MinFlowRate <= 0 {
+		options.MinFlowRate = 1
+	}
+
+	if options.Intervals <= 0 {
+		options.Intervals = 1
+	}
+
+	h := &Health[T]{nozzle: n, options: options}
+
+	if n.passThrough() {
+		return h
+	}
+
+	n.mut.Lock()
+	n.healths = append(n.healths, h)
+	n.mut.Unlock()
+
+	return h
+}
+
+// Healthy reports whether the Nozzle is healthy.
+// A Nozzle that has not completed HealthOptions.Intervals intervals yet is healthy.
+func (h *Health[T]) Healthy() bool {
+	healthy, _ := h.check()
+
+	return healthy
+}
+
+// ServeHTTP responds 200 OK when the Nozzle is healthy, and 503 Service Unavailable with the reason when it is not.
+func (h *Health[T]) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	healthy, reason := h.check()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	fmt.Fprintln(w, reason)
+}
+
+// check reports whether the Nozzle is healthy, and why.
+func (h *Health[T]) check() (bool, string) {
+	n := h.nozzle
+	if n.passThrough() {
+		return true, "ok"
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	if h.below < h.options.Intervals {
+		return true, "ok"
+	}
+
+	return false, fmt.Sprintf("flow rate below %d%% for %d intervals", h.options.MinFlowRate, h.options.Intervals)
+}
+
+// observeHealth counts, for every Health, whether the interval that just ended was below its MinFlowRate.
+// The caller must hold the lock.
+func (n *Nozzle[T]) observeHealth(stats IntervalStats) {
+	for _, h := range n.healths {
+		if stats.FlowRate < h.options.MinFlowRate {
+			h.below++
+		} else {
+			h.below = 0
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package nozzle_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	health := noz.Health(nozzle.HealthOptions{MinFlowRate: 50, Intervals: 2})
+
+	tests := []struct {
+		close   bool
+		healthy bool
+	}{
+		{close: false, healthy: true},
+		// One interval below MinFlowRate is not enough.
+		{close: true, healthy: true},
+		{close: true, healthy: false},
+		{close: false, healthy: true},
+	}
+
+	for i, test := range tests {
+		if test.close {
+			noz.ForceClose("outage")
+		} else {
+			noz.Unforce()
+		}
+
+		clock.Advance(time.Second)
+		noz.Tick()
+
+		if h := health.Healthy(); h != test.healthy {
+			t.Errorf("test=%d Expected Healthy=%t Got=%t", i, test.healthy, h)
+		}
+
+		rec := httptest.NewRecorder()
+		health.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		expected := http.StatusOK
+		if !test.healthy {
+			expected = http.StatusServiceUnavailable
+		}
+
+		if rec.Code != expected {
+			t.Errorf("test=%d Expected status=%d Got=%d %s", i, expected, rec.Code, rec.Body)
+		}
+
+		if !test.healthy && !strings.Contains(rec.Body.String(), "flow rate below 50% for 2 intervals") {
+			t.Errorf("test=%d Expected the reason Got=%s", i, rec.Body)
+		}
+	}
+
+	var unset *nozzle.Nozzle[any]
+	if !unset.Health(nozzle.HealthOptions{}).Healthy() {
+		t.Error("Expected a nil Nozzle to be healthy")
+	}
+}
+
+func TestHealthBeyondHistory(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// A budget this small keeps almost no History.
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+		MemoryBudget:          1,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	health := noz.Health(nozzle.HealthOptions{MinFlowRate: 50, Intervals: 5})
+
+	noz.ForceClose("outage")
+
+	for i := range 5 {
+		if !health.Healthy() {
+			t.Errorf("test=%d Expected Healthy=true after %d intervals", i, i)
+		}
+
+		clock.Advance(time.Second)
+		noz.Tick()
+	}
+
+	if len(noz.History()) >= 5 {
+		t.Fatalf("Expected History shorter than 5 intervals Got=%d", len(noz.History()))
+	}
+
+	if health.Healthy() {
+		t.Error("Expected Healthy=false after 5 intervals below MinFlowRate")
+	}
+}
@@ -0,0 +1,188 @@
+package nozzle
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/justindfuller/nozzle/engine"
+)
+
+// historySize is the number of intervals a Nozzle remembers.
+// Example: With an Interval of one second, a Nozzle remembers the last two minutes.
+const historySize = 120
+
+// IntervalStats describes a single completed interval.
+type IntervalStats struct {
+	// Start is when the interval started.
+	Start time.Time
+
+	// End is when the interval ended.
+	End time.Time
+
+	// IntervalSeq numbers the interval, starting at 1 for the Nozzle's first interval.
+	// It increases by exactly one per interval, so a gap or a repeat means an interval was missed or duplicated, regardless of clock jitter.
+	IntervalSeq uint64
+
+	// FlowRate is the flow rate that was in effect during the interval.
+	FlowRate int64
+
+	// FailureRate is the percentage of allowed calls that failed during the interval.
+	FailureRate int64
+
+	// Allowed is the number of calls allowed during the interval.
+	Allowed int64
+
+	// Blocked is the number of calls blocked during the interval.
+	Blocked int64
+
+	// Successes is the number of allowed calls that succeeded during the interval.
+	Successes int64
+
+	// Failures is the number of allowed calls that failed during the interval.
+	Failures int64
+
+	// FirstDecisionID and LastDecisionID are the range of decision IDs made during the interval, inclusive.
+	// Both are zero when no calls were attempted.
+	// See nozzle.DecisionID.
+	FirstDecisionID uint64
+	LastDecisionID  uint64
+
+	// ExpectedAllowed is how many calls FlowRate should have allowed, given every call attempted during the interval.
+	// Example: With 10 attempts at a FlowRate of 25, ExpectedAllowed is 2.5.
+	ExpectedAllowed float64
+
+	// AdmissionDeviation is Allowed minus ExpectedAllowed.
+	//
+	// Admission is ratio-based, so with plenty of traffic the deviation stays within one call.
+	// With very little traffic it cannot: the first call of every interval is allowed whenever FlowRate is above 0.
+	// A deviation that is large compared to ExpectedAllowed means the interval saw too few calls for FlowRate to be honored.
+	// Example: One attempt at a FlowRate of 10 gives ExpectedAllowed 0.1, Allowed 1, and AdmissionDeviation 0.9.
+	AdmissionDeviation float64
+
+	// Maintenance reports whether the interval overlapped a window declared by Options.Maintenance.
+	// Its outcomes did not move the flow rate, and are not remembered by later intervals.
+	Maintenance bool
+}
+
+// intervalStats builds the IntervalStats of the current interval, ending now.
+// The caller must hold the lock.
+func (n *Nozzle[T]) intervalStats() IntervalStats {
+	o := n.engine.Observe()
+	flowRate := o.FlowRate
+
+	switch n.override {
+	case ForcedOpen:
+		flowRate = 100
+	case ForcedClosed:
+		flowRate = 0
+	}
+
+	expected := float64(o.Allowed+o.Blocked) * float64(flowRate) / 100
+
+	var first, last uint64
+	if n.decisions > n.decisionsAtStart {
+		first, last = n.decisionsAtStart+1, n.decisions
+	}
+
+	return IntervalStats{
+		Start:              n.start,
+		End:                n.now(),
+		IntervalSeq:        n.intervals + 1,
+		FlowRate:           flowRate,
+		FailureRate:        engine.FailureRate(o.Successes, o.Failures),
+		Allowed:            o.Allowed,
+		Blocked:            o.Blocked,
+		Successes:          o.Successes,
+		Failures:           o.Failures,
+		FirstDecisionID:    first,
+		LastDecisionID:     last,
+		ExpectedAllowed:    expected,
+		AdmissionDeviation: float64(o.Allowed) - expected,
+	}
+}
+
+// record appends a completed interval to the history, discarding the oldest entry once historySize is reached.
+func (n *Nozzle[T]) record(stats IntervalStats) {
+	if len(n.history) < historySize {
+		n.history = append(n.history, stats)
+
+		return
+	}
+
+	copy(n.history, n.history[1:])
+	n.history[len(n.history)-1] = stats
+}
+
+// History reports the most recently completed intervals, oldest first.
+// A Nozzle remembers up to two minutes of intervals at a one second Interval (120 intervals), or fewer when Options.MemoryBudget is set.
+//
+// Example:
+//
+//	for _, i := range n.History() {
+//		fmt.Printf("flowRate=%d allowed=%d expected=%.1f\n", i.FlowRate, i.Allowed, i.ExpectedAllowed)
+//	}
+func (n *Nozzle[T]) History() []IntervalStats {
+	if n.passThrough() {
+		return nil
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	history := make([]IntervalStats, len(n.history))
+	copy(history, n.history)
+
+	return history
+}
+
+// sparks are the characters HistoryChart uses to draw values from 0 to 100.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// HistoryChart renders the flow rate and failure rate of the most recent intervals as sparklines.
+// Each character is one interval, oldest on the left. At most width intervals are drawn.
+// The value of the most recent interval is printed after each line.
+// It returns an empty string until the first interval completes.
+//
+// The chart is plain text, so it can be pasted into chat messages, incident tickets, or crash logs.
+//
+// Example:
+//
+//	fmt.Println(n.HistoryChart(20))
+//
+//	// Output:
+//	// flow    ██████▇▆▄▂▁▁▂▃▅▇ 85%
+//	// failure ▁▁▁▁▁▇████▇▆▄▂▁▁ 0%
+func (n *Nozzle[T]) HistoryChart(width int) string {
+	if n.passThrough() {
+		return ""
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	history := n.history
+	if width < len(history) {
+		history = history[len(history)-max(width, 0):]
+	}
+
+	if len(history) == 0 {
+		return ""
+	}
+
+	var flow, failure strings.Builder
+
+	for _, i := range history {
+		flow.WriteRune(spark(i.FlowRate))
+		failure.WriteRune(spark(i.FailureRate))
+	}
+
+	last := history[len(history)-1]
+
+	return fmt.Sprintf("flow    %s %d%%\nfailure %s %d%%", flow.String(), last.FlowRate, failure.String(), last.FailureRate)
+}
+
+// spark picks the sparkline character for a percentage.
+func spark(percent int64) rune {
+	return sparks[(engine.Clamp(percent)*int64(len(sparks)-1)+50)/100]
+}
@@ -0,0 +1,79 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{}, 10)
+
+	for i := range 20 {
+		noz.engine.Tally(i < 4)
+	}
+
+	noz.engine.Record(3, 1)
+
+	noz.calculate()
+
+	history := noz.History()
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 interval Got=%d", len(history))
+	}
+
+	i := history[0]
+
+	if i.FlowRate != 10 || i.Allowed != 4 || i.Blocked != 16 || i.FailureRate != 25 {
+		t.Errorf("Unexpected IntervalStats=%+v", i)
+	}
+
+	if i.ExpectedAllowed != 2 {
+		t.Errorf("Expected ExpectedAllowed=2 Got=%f", i.ExpectedAllowed)
+	}
+
+	if i.AdmissionDeviation != 2 {
+		t.Errorf("Expected AdmissionDeviation=2 Got=%f", i.AdmissionDeviation)
+	}
+}
+
+func TestHistoryChart(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{}, 100)
+
+	if chart := noz.HistoryChart(10); chart != "" {
+		t.Errorf("Expected empty chart Got=%q", chart)
+	}
+
+	for _, rate := range []int64{100, 100, 50, 0, 0, 50} {
+		noz.record(IntervalStats{
+			FlowRate:    rate,
+			FailureRate: 100 - rate,
+		})
+	}
+
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{
+			width:    10,
+			expected: "flow    ██▅▁▁▅ 50%\nfailure ▁▁▅██▅ 50%",
+		},
+		{
+			width:    3,
+			expected: "flow    ▁▁▅ 50%\nfailure ██▅ 50%",
+		},
+		{
+			width:    0,
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		if chart := noz.HistoryChart(test.width); chart != test.expected {
+			t.Errorf("width=%d Expected:\n%s\nGot:\n%s", test.width, test.expected, chart)
+		}
+	}
+}
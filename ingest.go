@@ -0,0 +1,44 @@
+package nozzle
+
+import (
+	"math"
+	"time"
+)
+
+// IngestAggregate folds outcomes collected outside of the Nozzle into the current interval.
+// Use it when something else, such as a proxy or a sidecar, already aggregates outcomes for the dependency.
+// The Nozzle then adapts to those statistics while still controlling admission for local calls.
+//
+// window is the period the aggregate covers.
+// If it is longer than Options.Interval, the counts are scaled down to one Interval's worth, so a long window does not outweigh local outcomes.
+// Example: An aggregate of 600 successes over one minute, ingested by a Nozzle with a one second Interval, counts as 10 successes.
+//
+// Negative counts are treated as zero.
+// Ingested outcomes count toward the rates and Stats, but not toward Allowed or Blocked, since the Nozzle did not admit those calls.
+//
+// Example:
+//
+//	for agg := range proxyAggregates {
+//		n.IngestAggregate(agg.Successes, agg.Failures, time.Second)
+//	}
+func (n *Nozzle[T]) IngestAggregate(successes, failures int64, window time.Duration) {
+	if n.passThrough() {
+		return
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	weight := 1.0
+	if window > n.options().Interval && n.options().Interval > 0 {
+		weight = float64(n.options().Interval) / float64(window)
+	}
+
+	successes = int64(math.Round(float64(max(successes, 0)) * weight))
+	failures = int64(math.Round(float64(max(failures, 0)) * weight))
+
+	n.engine.Record(successes, failures)
+	n.totals.Successes += successes
+	n.totals.Failures += failures
+	n.unadmitted += successes + failures
+}
@@ -0,0 +1,59 @@
+package nozzle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestIngestAggregate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		successes   int64
+		failures    int64
+		window      time.Duration
+		failureRate int64
+		stats       nozzle.Stats
+	}{
+		{
+			successes:   75,
+			failures:    25,
+			window:      time.Second,
+			failureRate: 25,
+			stats:       nozzle.Stats{Successes: 75, Failures: 25},
+		},
+		{
+			successes:   600,
+			failures:    1200,
+			window:      time.Minute,
+			failureRate: 66,
+			stats:       nozzle.Stats{Successes: 10, Failures: 20},
+		},
+		{
+			successes:   -5,
+			failures:    10,
+			window:      0,
+			failureRate: 100,
+			stats:       nozzle.Stats{Successes: 0, Failures: 10},
+		},
+	}
+
+	for i, test := range tests {
+		noz := nozzle.New(nozzle.Options[any]{
+			Interval:              time.Second,
+			AllowedFailurePercent: 50,
+		})
+
+		noz.IngestAggregate(test.successes, test.failures, test.window)
+
+		if fr := noz.FailureRate(); fr != test.failureRate {
+			t.Errorf("test=%d Expected FailureRate=%d Got=%d", i, test.failureRate, fr)
+		}
+
+		if stats := noz.Stats(); stats != test.stats {
+			t.Errorf("test=%d Expected Stats=%+v Got=%+v", i, test.stats, stats)
+		}
+	}
+}
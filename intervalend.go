@@ -0,0 +1,48 @@
+package nozzle
+
+// deliver passes pending intervals to Options.OnIntervalEnd, oldest first.
+// It stops at the first error, leaving that interval and every later one pending, and returns the error.
+// The caller must not hold the lock.
+func (n *Nozzle[T]) deliver() error {
+	n.delivering.Lock()
+	defer n.delivering.Unlock()
+
+	return n.drain()
+}
+
+// tryDeliver delivers pending intervals unless a delivery is already in progress, which picks up the new ones itself.
+// Unlike deliver, it may be called from OnIntervalEnd.
+func (n *Nozzle[T]) tryDeliver() {
+	if !n.delivering.TryLock() {
+		return
+	}
+	defer n.delivering.Unlock()
+
+	n.drain() //nolint:errcheck // undelivered intervals are retried at the end of the next interval.
+}
+
+// drain calls OnIntervalEnd for each pending interval, oldest first, until one fails.
+// The caller must hold n.delivering.
+func (n *Nozzle[T]) drain() error {
+	for {
+		n.mut.Lock()
+
+		if len(n.pending) == 0 {
+			n.mut.Unlock()
+
+			return nil
+		}
+
+		next := n.pending[0]
+
+		n.mut.Unlock()
+
+		if err := n.options().OnIntervalEnd(next); err != nil {
+			return err
+		}
+
+		n.mut.Lock()
+		n.pending = n.pending[1:]
+		n.mut.Unlock()
+	}
+}
@@ -0,0 +1,162 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestOnIntervalEnd(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("billing unavailable")
+
+	var mut sync.Mutex
+	var attempts int
+	var delivered []nozzle.IntervalStats
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+		OnIntervalEnd: func(s nozzle.IntervalStats) error {
+			mut.Lock()
+			defer mut.Unlock()
+
+			attempts++
+
+			// Fail the first two attempts, so the first interval is retried twice.
+			if attempts <= 2 {
+				return errUnavailable
+			}
+
+			delivered = append(delivered, s)
+
+			return nil
+		},
+	})
+
+	for range 5 {
+		noz.DoBool(func() (any, bool) {
+			return nil, true
+		})
+
+		noz.Wait()
+	}
+
+	noz.DoBool(func() (any, bool) {
+		return nil, true
+	})
+
+	if err := noz.Close(); err != nil {
+		t.Fatalf("Expected Close err=nil Got=%v", err)
+	}
+
+	if err := noz.Close(); err != nil {
+		t.Errorf("Expected second Close err=nil Got=%v", err)
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if len(delivered) < 6 {
+		t.Fatalf("Expected at least 6 intervals Got=%d", len(delivered))
+	}
+
+	var allowed int64
+
+	for i, s := range delivered {
+		allowed += s.Allowed
+
+		if i > 0 && !s.Start.After(delivered[i-1].Start) {
+			t.Errorf("interval=%d was delivered out of order or twice", i)
+		}
+
+		if expected := uint64(i + 1); s.IntervalSeq != expected {
+			t.Errorf("interval=%d Expected IntervalSeq=%d Got=%d", i, expected, s.IntervalSeq)
+		}
+	}
+
+	if allowed != 6 {
+		t.Errorf("Expected Allowed=6 across all intervals Got=%d", allowed)
+	}
+}
+
+func TestCloseUndelivered(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("billing unavailable")
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		OnIntervalEnd: func(nozzle.IntervalStats) error {
+			return errUnavailable
+		},
+	})
+
+	if err := noz.Close(); !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected err=%v Got=%v", errUnavailable, err)
+	}
+}
+
+func TestCloseFromCallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []func(options *nozzle.Options[any], closeNozzle func()){
+		func(options *nozzle.Options[any], closeNozzle func()) {
+			options.OnStateChange = func(*nozzle.Nozzle[any]) { closeNozzle() }
+		},
+		func(options *nozzle.Options[any], closeNozzle func()) {
+			options.OnIntervalEnd = func(nozzle.IntervalStats) error {
+				closeNozzle()
+
+				return nil
+			}
+		},
+	}
+
+	for i, configure := range tests {
+		var noz *nozzle.Nozzle[any]
+		var once sync.Once
+
+		closed := make(chan struct{})
+
+		options := nozzle.Options[any]{
+			Interval:              time.Millisecond * 10,
+			AllowedFailurePercent: 50,
+		}
+
+		// Close is called on the tick goroutine, which it must not wait for.
+		configure(&options, func() {
+			once.Do(func() {
+				noz.Close() //nolint:errcheck
+				close(closed)
+			})
+		})
+
+		noz = nozzle.New(options)
+
+		// Failures change the state at the end of the first interval.
+		for range 10 {
+			noz.DoBool(func() (any, bool) { return nil, false })
+		}
+
+		select {
+		case <-closed:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("test=%d Expected Close to return from the callback", i)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		// Closing finishes once the interval has ended.
+		if _, err := noz.WaitSnapshot(ctx); !errors.Is(err, nozzle.ErrClosed) {
+			t.Errorf("test=%d Expected err=%v Got=%v", i, nozzle.ErrClosed, err)
+		}
+	}
+}
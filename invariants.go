@@ -0,0 +1,78 @@
+package nozzle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvariantViolated is wrapped by every error passed to Options.OnInvariantViolation.
+var ErrInvariantViolated = errors.New("nozzle: invariant violated")
+
+// checkInvariants verifies the Nozzle's bookkeeping at the end of an interval, before the counters are reset.
+// It returns one error per violated invariant, or nil when Options.OnInvariantViolation is not set.
+// The caller must hold the lock.
+//
+// The invariants are:
+//
+//   - No counter is negative.
+//   - The interval's allowed and blocked calls add up to the weight of the decisions made during it.
+//   - Outcomes never outnumber admitted calls. This uses the cumulative totals, since a call admitted in one interval may complete in the next.
+//   - The flow rate and the rates are within [0, 100].
+func (n *Nozzle[T]) checkInvariants() []error {
+	if n.options().OnInvariantViolation == nil {
+		return nil
+	}
+
+	var violations []error
+
+	violate := func(format string, args ...any) {
+		violations = append(violations, fmt.Errorf("%w: "+format, append([]any{ErrInvariantViolated}, args...)...))
+	}
+
+	o := n.observe()
+
+	counters := []struct {
+		name  string
+		value int64
+	}{
+		{"allowed", o.Allowed},
+		{"blocked", o.Blocked},
+		{"successes", o.Successes},
+		{"failures", o.Failures},
+		{"total allowed", n.totals.Allowed},
+		{"total blocked", n.totals.Blocked},
+		{"total successes", n.totals.Successes},
+		{"total failures", n.totals.Failures},
+	}
+
+	for _, c := range counters {
+		if c.value < 0 {
+			violate("%s=%d is negative", c.name, c.value)
+		}
+	}
+
+	if units := n.units - n.unitsAtStart; o.Allowed+o.Blocked != units {
+		violate("allowed=%d + blocked=%d does not equal decided weight=%d", o.Allowed, o.Blocked, units)
+	}
+
+	if outcomes := n.totals.Successes + n.totals.Failures; outcomes > n.totals.Allowed+n.unadmitted {
+		violate("total successes + failures=%d exceeds total allowed=%d + unadmitted outcomes=%d", outcomes, n.totals.Allowed, n.unadmitted)
+	}
+
+	rates := []struct {
+		name  string
+		value int64
+	}{
+		{"flow rate", o.FlowRate},
+		{"failure rate", o.FailureRate},
+		{"success rate", o.SuccessRate},
+	}
+
+	for _, r := range rates {
+		if r.value < 0 || r.value > 100 {
+			violate("%s=%d is outside [0, 100]", r.name, r.value)
+		}
+	}
+
+	return violations
+}
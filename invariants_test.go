@@ -0,0 +1,98 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		corrupt  func(noz *Nozzle[any])
+		expected []string
+	}{
+		{
+			corrupt:  func(*Nozzle[any]) {},
+			expected: nil,
+		},
+		{
+			corrupt: func(noz *Nozzle[any]) {
+				noz.engine.Record(0, -1)
+			},
+			expected: []string{
+				"failures=-1 is negative",
+				"failure rate=-100 is outside [0, 100]",
+				"success rate=200 is outside [0, 100]",
+			},
+		},
+		{
+			corrupt: func(noz *Nozzle[any]) {
+				noz.engine.Tally(false)
+			},
+			expected: []string{"allowed=2 + blocked=1 does not equal decided weight=2"},
+		},
+		{
+			corrupt: func(noz *Nozzle[any]) {
+				noz.totals.Successes += 5
+			},
+			expected: []string{"total successes + failures=7 exceeds total allowed=2"},
+		},
+		{
+			corrupt: func(noz *Nozzle[any]) {
+				noz.IngestAggregate(5, 0, 0)
+			},
+			expected: nil,
+		},
+		{
+			corrupt: func(noz *Nozzle[any]) {
+				noz.DoErrorN(5, func() (any, error) {
+					return nil, nil
+				})
+			},
+			expected: nil,
+		},
+		{
+			corrupt: func(noz *Nozzle[any]) {
+				noz.ReserveN(3).Cancel()
+			},
+			expected: nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			var violations []error
+
+			noz := newTestNozzle(Options[any]{
+				AllowedFailurePercent: 50,
+				OnInvariantViolation: func(err error) {
+					violations = append(violations, err)
+				},
+			}, 100)
+
+			for range 2 {
+				noz.DoBool(func() (any, bool) {
+					return nil, true
+				})
+			}
+
+			test.corrupt(noz)
+			noz.calculate()
+
+			if len(violations) != len(test.expected) {
+				t.Fatalf("Expected %d violations Got=%q", len(test.expected), violations)
+			}
+
+			for j, expected := range test.expected {
+				if !errors.Is(violations[j], ErrInvariantViolated) || !strings.Contains(violations[j].Error(), expected) {
+					t.Errorf("Expected violation containing %q Got=%q", expected, violations[j])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package nozzle
+
+import (
+	"time"
+
+	"github.com/justindfuller/nozzle/engine"
+)
+
+// Outcome is the result of a call that the Nozzle allowed.
+// The zero Outcome is not valid, and is ignored.
+type Outcome int
+
+const (
+	// Success means the call succeeded.
+	Success Outcome = iota + 1
+
+	// Failure means the call failed.
+	Failure
+)
+
+// ReportLate records the outcome of an allowed call that was only discovered after the fact, such as through an asynchronous NACK or a webhook.
+// The outcome is folded into the interval in which the call occurred, instead of polluting the current interval.
+//
+// A late outcome affects the Nozzle's decisions only while its interval is still inside Options.FailureWindow or Options.SlowFailureWindow,
+// and never when its interval overlapped Options.Maintenance, since those outcomes were planned.
+// It is always added to the interval's entry in History and to Stats.
+// Intervals already passed to Options.OnIntervalEnd are not delivered again.
+//
+// Example:
+//
+//	func onNack(msg *Message) {
+//		n.ReportLate(nozzle.Failure, msg.SentAt)
+//	}
+func (n *Nozzle[T]) ReportLate(outcome Outcome, occurredAt time.Time) {
+	if n.passThrough() {
+		return
+	}
+
+	var successes, failures int64
+
+	switch outcome {
+	case Success:
+		successes = 1
+	case Failure:
+		failures = 1
+	default:
+		return
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	n.totals.Successes += successes
+	n.totals.Failures += failures
+	n.unadmitted += successes + failures
+
+	if !occurredAt.Before(n.start) {
+		n.engine.Record(successes, failures)
+
+		return
+	}
+
+	// The engine forgets maintenance intervals, so they are not counted among the intervals it remembers.
+	intervalsAgo := 0
+
+	for i := len(n.history) - 1; i >= 0; i-- {
+		interval := &n.history[i]
+
+		if !interval.Maintenance {
+			intervalsAgo++
+		}
+
+		if occurredAt.Before(interval.Start) {
+			continue
+		}
+
+		interval.Successes += successes
+		interval.Failures += failures
+		interval.FailureRate = engine.FailureRate(interval.Successes, interval.Failures)
+
+		if !interval.Maintenance {
+			n.engine.RecordLate(intervalsAgo, successes, failures)
+		}
+
+		return
+	}
+}
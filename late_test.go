@@ -0,0 +1,109 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportLate(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 20,
+		FailureWindow:         3,
+	}, 100)
+
+	noz.start = time.Now()
+	noz.engine.Record(4, 0)
+	noz.calculate()
+
+	occurredAt := noz.History()[0].Start
+
+	for range 3 {
+		noz.ReportLate(Failure, occurredAt)
+	}
+
+	noz.ReportLate(Failure, occurredAt.Add(-time.Hour))
+	noz.ReportLate(0, time.Now())
+
+	if f := noz.History()[0].Failures; f != 3 {
+		t.Errorf("Expected History Failures=3 Got=%d", f)
+	}
+
+	if f := noz.Stats().Failures; f != 4 {
+		t.Errorf("Expected Stats Failures=4 Got=%d", f)
+	}
+
+	noz.engine.Record(4, 0)
+
+	if fr := noz.FailureRate(); fr != 0 {
+		t.Errorf("Expected FailureRate=0 in the current interval Got=%d", fr)
+	}
+
+	noz.calculate()
+
+	if s := noz.State(); s != Closing {
+		t.Errorf("Expected State=%s Got=%s", Closing, s)
+	}
+}
+
+func TestReportLateMaintenance(t *testing.T) {
+	t.Parallel()
+
+	// Each Nozzle ends a normal interval, then one during maintenance, which the engine does not remember.
+	setup := func() (*Nozzle[any], []IntervalStats) {
+		noz := newTestNozzle(Options[any]{
+			AllowedFailurePercent: 20,
+			FailureWindow:         3,
+		}, 100)
+
+		start := time.Now().Add(-time.Hour)
+
+		noz.start = start
+		noz.engine.Record(4, 0)
+		noz.calculate()
+
+		noz.start = start.Add(time.Minute)
+		noz.maintenanceWindows = []MaintenanceWindow{{Start: start, End: time.Now().Add(time.Hour)}}
+		noz.engine.Record(0, 4)
+		noz.calculate()
+
+		noz.maintenanceWindows = nil
+
+		return noz, noz.History()
+	}
+
+	tests := []struct {
+		interval int
+		state    State
+	}{
+		// Late failures of the interval before maintenance still count, since it is still in the window.
+		{interval: 0, state: Closing},
+
+		// Late failures of the maintenance interval were planned, so they count for nothing.
+		{interval: 1, state: Opening},
+	}
+
+	for i, test := range tests {
+		noz, history := setup()
+
+		if !history[1].Maintenance {
+			t.Fatalf("test=%d Expected the second interval to be a maintenance interval Got=%+v", i, history[1])
+		}
+
+		for range 3 {
+			noz.ReportLate(Failure, history[test.interval].Start)
+		}
+
+		if f := noz.History()[test.interval].Failures; f < 3 {
+			t.Errorf("test=%d Expected History Failures>=3 Got=%d", i, f)
+		}
+
+		noz.engine.Record(4, 0)
+		noz.calculate()
+
+		if s := noz.State(); s != test.state {
+			t.Errorf("test=%d Expected State=%s Got=%s", i, test.state, s)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package nozzle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultMaintenanceRefresh is used by MaintenanceURL when refresh is zero.
+const DefaultMaintenanceRefresh = time.Minute
+
+// ErrMaintenanceSchedule is wrapped by errors returned from ReadMaintenanceSchedule and MaintenanceURL.
+var ErrMaintenanceSchedule = errors.New("nozzle: invalid maintenance schedule")
+
+// MaintenanceWindow is a declared period of planned downtime for a dependency.
+type MaintenanceWindow struct {
+	// Start is when the maintenance begins.
+	Start time.Time `json:"start"`
+
+	// End is when the maintenance is over. It must be after Start.
+	End time.Time `json:"end"`
+
+	// Reason explains the maintenance, such as a change ticket.
+	// It is reported in the Nozzle's Reason and Events.
+	Reason string `json:"reason"`
+}
+
+// MaintenanceSource declares the maintenance windows of a dependency, such as a JSON schedule or a calendar endpoint.
+// See Options.Maintenance.
+// Implementations must be safe for concurrent use.
+type MaintenanceSource interface {
+	// Windows returns the declared maintenance windows. Windows that are over may be left out.
+	// It is called at the end of every interval, so a source that reads from the network should cache, like MaintenanceURL does.
+	Windows(ctx context.Context) ([]MaintenanceWindow, error)
+}
+
+// MaintenanceSchedule is a fixed MaintenanceSource, such as one read from a file with ReadMaintenanceSchedule.
+//
+// Example:
+//
+//	Maintenance: nozzle.MaintenanceSchedule{
+//		{Start: start, End: start.Add(time.Hour), Reason: "CHG-1234 database upgrade"},
+//	},
+type MaintenanceSchedule []MaintenanceWindow
+
+// Windows returns the schedule.
+func (s MaintenanceSchedule) Windows(_ context.Context) ([]MaintenanceWindow, error) {
+	return s, nil
+}
+
+// ReadMaintenanceSchedule reads a JSON array of MaintenanceWindow, such as:
+//
+//	[{"start": "2024-01-01T02:00:00Z", "end": "2024-01-01T03:00:00Z", "reason": "CHG-1234 database upgrade"}]
+//
+// It returns an error if a window does not end after it starts.
+func ReadMaintenanceSchedule(r io.Reader) (MaintenanceSchedule, error) {
+	var s MaintenanceSchedule
+
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMaintenanceSchedule, err)
+	}
+
+	for i, w := range s {
+		if !w.End.After(w.Start) {
+			return nil, fmt.Errorf("%w: window %d ends at %s, before it starts at %s", ErrMaintenanceSchedule, i, w.End, w.Start)
+		}
+	}
+
+	return s, nil
+}
+
+// MaintenanceURL creates a MaintenanceSource that reads a schedule from url, in the format of ReadMaintenanceSchedule.
+// The schedule is fetched again at most once per refresh, or DefaultMaintenanceRefresh if refresh is zero.
+// This lets a team publish its maintenance calendar once, for every service that depends on it.
+//
+// Example:
+//
+//	nozzle.New(nozzle.Options[any]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//		Maintenance:           nozzle.MaintenanceURL("http://calendar.internal/payments.json", time.Minute),
+//	})
+func MaintenanceURL(url string, refresh time.Duration) MaintenanceSource {
+	if refresh <= 0 {
+		refresh = DefaultMaintenanceRefresh
+	}
+
+	return &maintenanceURL{url: url, refresh: refresh}
+}
+
+// maintenanceURL is the MaintenanceSource returned by MaintenanceURL.
+type maintenanceURL struct {
+	url     string
+	refresh time.Duration
+
+	mut       sync.Mutex
+	schedule  MaintenanceSchedule
+	fetchedAt time.Time
+}
+
+// Windows returns the cached schedule, fetching it first if it is older than refresh.
+func (m *maintenanceURL) Windows(ctx context.Context) ([]MaintenanceWindow, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if !m.fetchedAt.IsZero() && time.Since(m.fetchedAt) < m.refresh {
+		return m.schedule, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMaintenanceSchedule, err)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMaintenanceSchedule, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: unexpected status %d", ErrMaintenanceSchedule, res.StatusCode)
+	}
+
+	schedule, err := ReadMaintenanceSchedule(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	m.schedule = schedule
+	m.fetchedAt = time.Now()
+
+	return schedule, nil
+}
+
+// refreshMaintenance asks Options.Maintenance for its windows, if the current interval is due to end.
+// When it fails, the windows it returned last are kept.
+// The caller must not hold the lock.
+func (n *Nozzle[T]) refreshMaintenance() {
+	source := n.options().Maintenance
+	if source == nil || !n.due() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.options().Interval/2)
+	windows, err := source.Windows(ctx)
+
+	cancel()
+
+	if err != nil && n.options().Logger != nil {
+		n.options().Logger.Warn("nozzle: maintenance source failed; using the last known windows", "error", err)
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if err != nil {
+		n.totals.MaintenanceErrors++
+
+		return
+	}
+
+	n.maintenanceWindows = slices.Clone(windows)
+}
+
+// maintain decides whether the interval in stats overlaps a maintenance window, and marks it if it does.
+// It returns the Event to report when maintenance starts or ends, or nil.
+// The caller must hold the lock.
+func (n *Nozzle[T]) maintain(stats *IntervalStats) *Event {
+	previous := n.maintenance
+	n.maintenance = ""
+
+	for _, w := range n.maintenanceWindows {
+		if w.Start.Before(stats.End) && w.End.After(stats.Start) {
+			n.maintenance = w.Reason
+			if n.maintenance == "" {
+				n.maintenance = "maintenance"
+			}
+
+			break
+		}
+	}
+
+	if n.maintenance != "" {
+		stats.Maintenance = true
+		n.totals.MaintenanceIntervals++
+	}
+
+	switch {
+	case previous == "" && n.maintenance != "":
+		return &Event{Type: EventMaintenanceStarted, Time: n.now(), State: n.engine.State(), Reason: n.maintenance}
+	case previous != "" && n.maintenance == "":
+		return &Event{Type: EventMaintenanceEnded, Time: n.now(), State: n.engine.State(), Reason: previous}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// flakySource is a MaintenanceSource that fails while err is set.
+type flakySource struct {
+	mut     sync.Mutex
+	windows []nozzle.MaintenanceWindow
+	err     error
+}
+
+func (f *flakySource) Windows(_ context.Context) ([]nozzle.MaintenanceWindow, error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	return f.windows, f.err
+}
+
+func (f *flakySource) fail(err error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.err = err
+}
+
+func TestMaintenance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := nozzle.NewManualClock(start)
+	source := &flakySource{windows: []nozzle.MaintenanceWindow{
+		{Start: start.Add(2 * time.Second), End: start.Add(4 * time.Second), Reason: "CHG-1234"},
+	}}
+
+	var mut sync.Mutex
+	var events []nozzle.Event
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		FailureWindow:         3,
+		Clock:                 clock,
+		ManualTick:            true,
+		Maintenance:           source,
+		OnEvent: func(e nozzle.Event) {
+			if e.Type == nozzle.EventCompatLevel {
+				return
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			events = append(events, e)
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	tests := []struct {
+		failures    int
+		maintenance bool
+		err         error
+	}{
+		{failures: 0, maintenance: false},
+		{failures: 0, maintenance: false},
+		// Planned downtime: every call fails, but the flow rate holds.
+		{failures: 10, maintenance: true},
+		{failures: 10, maintenance: true, err: errors.New("calendar unavailable")},
+		// Healthy again: the failures during maintenance are not remembered by FailureWindow.
+		{failures: 0, maintenance: false},
+	}
+
+	for i, test := range tests {
+		source.fail(test.err)
+
+		for j := range 10 {
+			if j < test.failures {
+				noz.ReportFailure()
+			} else {
+				noz.ReportSuccess()
+			}
+		}
+
+		clock.Advance(time.Second)
+		noz.Tick()
+
+		if s := noz.Snapshot(); s.Maintenance != test.maintenance || s.FlowRate != 100 {
+			t.Errorf("test=%d Expected Maintenance=%t FlowRate=100 Got Maintenance=%t FlowRate=%d Reason=%q", i, test.maintenance, s.Maintenance, s.FlowRate, s.Reason)
+		}
+	}
+
+	history := noz.History()
+	for i, test := range tests {
+		if history[i].Maintenance != test.maintenance {
+			t.Errorf("interval=%d Expected Maintenance=%t Got=%t", i, test.maintenance, history[i].Maintenance)
+		}
+	}
+
+	if s := noz.Stats(); s.MaintenanceIntervals != 2 || s.MaintenanceErrors != 1 {
+		t.Errorf("Expected MaintenanceIntervals=2 MaintenanceErrors=1 Got MaintenanceIntervals=%d MaintenanceErrors=%d", s.MaintenanceIntervals, s.MaintenanceErrors)
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if len(events) != 2 || events[0].Type != nozzle.EventMaintenanceStarted || events[1].Type != nozzle.EventMaintenanceEnded || events[0].Reason != "CHG-1234" {
+		t.Errorf("Expected maintenance to start and end for CHG-1234 Got=%+v", events)
+	}
+}
+
+func TestReadMaintenanceSchedule(t *testing.T) {
+	t.Parallel()
+
+	body := `[{"start":"2024-01-01T02:00:00Z","end":"2024-01-01T03:00:00Z","reason":"CHG-1234"}]`
+
+	schedule, err := nozzle.ReadMaintenanceSchedule(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schedule) != 1 || schedule[0].Reason != "CHG-1234" || schedule[0].End.Sub(schedule[0].Start) != time.Hour {
+		t.Errorf("Expected one hour long window for CHG-1234 Got=%+v", schedule)
+	}
+
+	for i, invalid := range []string{`{}`, `[{"start":"2024-01-01T03:00:00Z","end":"2024-01-01T02:00:00Z"}]`} {
+		if _, err := nozzle.ReadMaintenanceSchedule(strings.NewReader(invalid)); !errors.Is(err, nozzle.ErrMaintenanceSchedule) {
+			t.Errorf("test=%d Expected err=%v Got=%v", i, nozzle.ErrMaintenanceSchedule, err)
+		}
+	}
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Write([]byte(body)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	source := nozzle.MaintenanceURL(server.URL, time.Hour)
+
+	for range 2 {
+		windows, err := source.Windows(context.Background())
+		if err != nil || len(windows) != 1 {
+			t.Errorf("Expected one window Got=%+v err=%v", windows, err)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected the schedule to be cached Got requests=%d", requests)
+	}
+}
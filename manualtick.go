@@ -0,0 +1,36 @@
+package nozzle
+
+import "context"
+
+// Tick ends the current interval if Options.Interval has elapsed, the same way the Nozzle's own ticker would.
+// It is how a Nozzle created with Options.ManualTick adapts: without it, the flow rate never changes.
+// On other Nozzles it only ends intervals early when they are already due, so it is rarely useful there.
+//
+// With Options.ManualTick, Tick also runs Options.Verify, once per interval, before ending it.
+//
+// Example:
+//
+//	for range frames {
+//		n.Tick()
+//	}
+func (n *Nozzle[T]) Tick() {
+	if n.passThrough() {
+		return
+	}
+
+	if n.options().ManualTick && n.options().Verify != nil && n.due() {
+		if err := n.options().Verify(context.Background()); err != nil {
+			n.verificationFailed(err)
+		}
+	}
+
+	n.calculate()
+}
+
+// due reports whether the current interval has lasted at least Options.Interval.
+func (n *Nozzle[T]) due() bool {
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return !n.closed && n.since(n.start) >= n.options().Interval
+}
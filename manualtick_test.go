@@ -0,0 +1,68 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestManualTick(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Now())
+
+	var verified int
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+		Verify: func(context.Context) error {
+			verified++
+
+			return errors.New("unhealthy")
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	// Nothing ticks on its own, however much time passes.
+	clock.Advance(time.Hour)
+
+	if intervals := len(noz.History()); intervals != 0 {
+		t.Errorf("Expected intervals=0 Got=%d", intervals)
+	}
+
+	noz.Tick()
+
+	if intervals := len(noz.History()); intervals != 1 {
+		t.Errorf("Expected intervals=1 Got=%d", intervals)
+	}
+
+	// The failed verification closes the Nozzle.
+	if noz.State() != nozzle.Closing {
+		t.Errorf("Expected State=%s Got=%s", nozzle.Closing, noz.State())
+	}
+
+	if verified != 1 {
+		t.Errorf("Expected verified=1 Got=%d", verified)
+	}
+
+	// The next interval is not due yet, so Tick does nothing.
+	noz.Tick()
+
+	if intervals := len(noz.History()); intervals != 1 {
+		t.Errorf("Expected intervals=1 Got=%d", intervals)
+	}
+
+	if verified != 1 {
+		t.Errorf("Expected verified=1 Got=%d", verified)
+	}
+
+	if !noz.DescribeConfig().ManualTick {
+		t.Error("Expected DescribeConfig().ManualTick=true")
+	}
+}
@@ -0,0 +1,117 @@
+package nozzle
+
+import (
+	"math"
+	"time"
+)
+
+// Trend windows, the same as a Unix load average.
+const (
+	trendOneMinute      = time.Minute
+	trendFiveMinutes    = 5 * time.Minute
+	trendFifteenMinutes = 15 * time.Minute
+)
+
+// Trend is a percentage averaged over the last one, five, and fifteen minutes, like a Unix load average.
+// Each average decays exponentially, so recent intervals weigh the most, and older ones never quite drop out.
+// Example: A OneMinute well above FifteenMinutes means things got worse recently.
+type Trend struct {
+	OneMinute      float64
+	FiveMinutes    float64
+	FifteenMinutes float64
+}
+
+// Metrics are trends of a Nozzle's rates, and measurements of its own work, maintained at the end of each interval.
+// They let dashboards show short, medium, and long term trends without an external metrics database.
+type Metrics struct {
+	// FailureRate is the trend of the percentage of allowed calls that failed.
+	// Intervals without allowed calls leave it unchanged.
+	FailureRate Trend
+
+	// ShedRate is the trend of the percentage of attempted calls that were blocked.
+	// Intervals without attempted calls leave it unchanged.
+	ShedRate Trend
+
+	// CalculateDuration is how long the Nozzle's own work at the end of the last interval took, including its callbacks.
+	// When it approaches the Interval, the Nozzle itself is becoming the bottleneck. See Options.CalculateBudget.
+	CalculateDuration time.Duration
+}
+
+// Metrics reports the decayed trends of the failure and shed rates, and how long the last interval end took.
+// The trends start from the first completed interval, so they are zero until then.
+//
+// Example:
+//
+//	m := n.Metrics()
+//	fmt.Printf("failures %.1f %.1f %.1f\n", m.FailureRate.OneMinute, m.FailureRate.FiveMinutes, m.FailureRate.FifteenMinutes)
+func (n *Nozzle[T]) Metrics() Metrics {
+	if n.passThrough() {
+		return Metrics{}
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return n.metrics
+}
+
+// decay folds a completed interval into the Metrics.
+// The caller must hold the lock.
+func (n *Nozzle[T]) decay(stats IntervalStats) {
+	elapsed := stats.End.Sub(stats.Start)
+
+	if stats.Allowed > 0 {
+		n.metrics.FailureRate = n.metrics.FailureRate.add(float64(stats.FailureRate), elapsed, !n.failureTrended)
+		n.failureTrended = true
+	}
+
+	if attempted := stats.Allowed + stats.Blocked; attempted > 0 {
+		n.metrics.ShedRate = n.metrics.ShedRate.add(float64(stats.Blocked)*100/float64(attempted), elapsed, !n.shedTrended)
+		n.shedTrended = true
+	}
+}
+
+// measure records how long the interval end that began at began took, and whether it was over Options.CalculateBudget.
+// The caller must hold the lock.
+func (n *Nozzle[T]) measure(began time.Time) {
+	duration := time.Since(began)
+	budget := n.calculateBudget()
+
+	n.metrics.CalculateDuration = duration
+	n.overBudget = budget > 0 && duration > budget
+
+	if n.overBudget {
+		n.totals.CalculateOverBudget++
+	}
+}
+
+// calculateBudget returns Options.CalculateBudget, or its default of a tenth of Interval.
+func (n *Nozzle[T]) calculateBudget() time.Duration {
+	if budget := n.options().CalculateBudget; budget > 0 {
+		return budget
+	}
+
+	return n.options().Interval / 10
+}
+
+// add folds value, observed over elapsed, into the Trend.
+// The first value seeds every window, so the Trend does not have to climb from zero.
+func (t Trend) add(value float64, elapsed time.Duration, first bool) Trend {
+	if first {
+		return Trend{OneMinute: value, FiveMinutes: value, FifteenMinutes: value}
+	}
+
+	return Trend{
+		OneMinute:      decayed(t.OneMinute, value, elapsed, trendOneMinute),
+		FiveMinutes:    decayed(t.FiveMinutes, value, elapsed, trendFiveMinutes),
+		FifteenMinutes: decayed(t.FifteenMinutes, value, elapsed, trendFifteenMinutes),
+	}
+}
+
+// decayed moves average toward value by the share of window that elapsed covers.
+// Example: After one window of a constant value, about 63% of the gap has closed.
+func decayed(average, value float64, elapsed, window time.Duration) float64 {
+	weight := 1 - math.Exp(-elapsed.Seconds()/window.Seconds())
+
+	return average + (value-average)*weight
+}
@@ -0,0 +1,124 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{AllowedFailurePercent: 50}, 100)
+
+	start := time.Now()
+	interval := func(successes, failures, blocked int64) IntervalStats {
+		stats := IntervalStats{
+			Start:     start,
+			End:       start.Add(time.Minute),
+			Allowed:   successes + failures,
+			Blocked:   blocked,
+			Successes: successes,
+			Failures:  failures,
+		}
+		if stats.Allowed > 0 {
+			stats.FailureRate = failures * 100 / stats.Allowed
+		}
+
+		return stats
+	}
+
+	// The first interval seeds every window.
+	noz.decay(interval(50, 50, 100))
+
+	metrics := noz.Metrics()
+	if metrics.FailureRate != (Trend{50, 50, 50}) || metrics.ShedRate != (Trend{50, 50, 50}) {
+		t.Errorf("Expected FailureRate=%v ShedRate=%v Got FailureRate=%v ShedRate=%v", Trend{50, 50, 50}, Trend{50, 50, 50}, metrics.FailureRate, metrics.ShedRate)
+	}
+
+	// An idle interval changes nothing.
+	noz.decay(interval(0, 0, 0))
+
+	if noz.Metrics() != metrics {
+		t.Errorf("Expected Metrics=%v Got=%v", metrics, noz.Metrics())
+	}
+
+	// A minute without failures or blocked calls closes 63% of the one minute gap, and less of the longer ones.
+	noz.decay(interval(100, 0, 0))
+
+	metrics = noz.Metrics()
+
+	for _, test := range []struct {
+		name     string
+		got      float64
+		expected float64
+	}{
+		{"FailureRate.OneMinute", metrics.FailureRate.OneMinute, 50 * math.Exp(-1)},
+		{"FailureRate.FiveMinutes", metrics.FailureRate.FiveMinutes, 50 * math.Exp(-1.0/5)},
+		{"FailureRate.FifteenMinutes", metrics.FailureRate.FifteenMinutes, 50 * math.Exp(-1.0/15)},
+		{"ShedRate.OneMinute", metrics.ShedRate.OneMinute, 50 * math.Exp(-1)},
+	} {
+		if math.Abs(test.got-test.expected) > 0.001 {
+			t.Errorf("Expected %s=%.3f Got=%.3f", test.name, test.expected, test.got)
+		}
+	}
+
+	if metrics.FailureRate.OneMinute >= metrics.FailureRate.FiveMinutes || metrics.FailureRate.FiveMinutes >= metrics.FailureRate.FifteenMinutes {
+		t.Errorf("Expected shorter windows to recover faster Got=%v", metrics.FailureRate)
+	}
+}
+
+func TestCalculateBudget(t *testing.T) {
+	t.Parallel()
+
+	var slow atomic.Bool
+
+	slow.Store(true)
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		CalculateBudget:       time.Millisecond,
+		MaxQueueDepth:         100,
+		QueueDepth: func() int64 {
+			if slow.Load() {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			return 0
+		},
+	}, 100)
+
+	noz.DoBool(func() (any, bool) { return nil, true })
+	noz.calculate()
+
+	if over := noz.Stats().CalculateOverBudget; over != 1 {
+		t.Errorf("Expected CalculateOverBudget=1 Got=%d", over)
+	}
+
+	if duration := noz.Metrics().CalculateDuration; duration < 5*time.Millisecond {
+		t.Errorf("Expected CalculateDuration>=5ms Got=%s", duration)
+	}
+
+	// The previous interval end was over budget, so this one skips the trends.
+	slow.Store(false)
+
+	noz.DoBool(func() (any, bool) { return nil, false })
+	noz.calculate()
+
+	if rate := noz.Metrics().FailureRate; rate != (Trend{}) {
+		t.Errorf("Expected FailureRate=%v Got=%v", Trend{}, rate)
+	}
+
+	// Back within budget, the trends resume.
+	noz.DoBool(func() (any, bool) { return nil, false })
+	noz.calculate()
+
+	if rate := noz.Metrics().FailureRate; rate.OneMinute <= 0 {
+		t.Errorf("Expected FailureRate.OneMinute>0 Got=%v", rate.OneMinute)
+	}
+
+	if over := noz.Stats().CalculateOverBudget; over != 1 {
+		t.Errorf("Expected CalculateOverBudget=1 Got=%d", over)
+	}
+}
@@ -1,9 +1,16 @@
 package nozzle
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/justindfuller/nozzle/engine"
 )
 
 // ErrBlocked is returned when a call is blocked by the Nozzle.
@@ -26,39 +33,92 @@ import (
 //	}
 var ErrBlocked = errors.New("nozzle: blocked")
 
+// BlockedError is the error DoError and DoErrorContext return when a call is blocked.
+// It matches ErrBlocked, so errors.Is(err, nozzle.ErrBlocked) keeps working, and it carries details about the decision.
+// They are enough to build a meaningful 503 response, or to back off a client.
+//
+// Example:
+//
+//	var blocked *nozzle.BlockedError
+//	if errors.As(err, &blocked) {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+//		http.Error(w, blocked.Error(), http.StatusServiceUnavailable)
+//	}
+type BlockedError struct {
+	// DecisionID identifies the decision that blocked the call.
+	// It is zero when the call was blocked by Options.MaxConcurrent, which is not a flow rate decision.
+	// See nozzle.DecisionID.
+	DecisionID uint64
+
+	// Bulkhead reports whether the call was blocked because Options.MaxConcurrent calls were already running, rather than by the flow rate.
+	Bulkhead bool
+
+	// FlowRate is the flow rate callers experienced when the call was blocked.
+	FlowRate int64
+
+	// State is the Nozzle's state when the call was blocked, including ForcedClosed.
+	State State
+
+	// RetryAfter estimates how long until the Nozzle next decides whether to open.
+	// It accounts for the end of the current interval, Options.ReopenCooldown, and a manual override that expires.
+	// It is an estimate: the Nozzle may stay closed after it, and at a flow rate above 0, an immediate retry may be allowed.
+	// It is zero when there is no estimate, such as for an override that does not expire.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *BlockedError) Error() string {
+	if e.Bulkhead {
+		return fmt.Sprintf("%s (bulkhead full, flow rate %d%%)", ErrBlocked, e.FlowRate)
+	}
+
+	return fmt.Sprintf("%s (decision %d, flow rate %d%%, retry after %s)", ErrBlocked, e.DecisionID, e.FlowRate, e.RetryAfter)
+}
+
+// Unwrap returns ErrBlocked.
+func (e *BlockedError) Unwrap() error {
+	return ErrBlocked
+}
+
+// ErrReentrant is returned when a callback calls back into the same Nozzle and Options.Reentrancy is ReentrancyError.
+// See nozzle.ReentrancyPolicy for how nested calls are detected.
+var ErrReentrant = errors.New("nozzle: reentrant call")
+
 // Nozzle manages the rate of allowed operations and adapts based on success and failure rates.
 // It uses a flow rate to control the percentage of allowed operations and adjusts its state based on the observed failure rate.
 // see nozzle.New docs for how to create a Nozzle.
 // see nozzle.Options docs for how to modify a Nozzle's behavior.
+// Options are only read by nozzle.New; use SetInterval and SetAllowedFailurePercent to change them afterward.
 type Nozzle[T any] struct {
-	// Options controls how the Nozzle works.
-	// See the nozzle.Options docs for how it works.
-	Options Options[T]
+	// config is the Options in effect.
+	// It is never modified in place: changes, such as SetInterval, store a modified copy.
+	// That lets hot paths read it without the lock, while configuration changes at runtime.
+	config atomic.Pointer[Options[T]]
 
-	// decreaseBy adjusts the rate at which the flowRate changes.
-	// It determines how quickly the Nozzle opens or closes.
-	// Example: If decreaseBy is -2, flowRate decreases faster than if decreaseBy is -1
-	decreaseBy int64
+	// engine decides which calls to admit and adapts the flow rate at the end of each interval.
+	// The Nozzle serializes access to it with mut, and tells it when each interval ends.
+	// See the engine package for details.
+	engine *engine.Engine
 
-	// flowRate indicates the percentage of allowed operations at any given time.
-	// Example: A flowRate of 100 means all operations are allowed, while a flowRate of 0 means none are allowed.
-	flowRate int64
+	// created records when the Nozzle was created.
+	// See Options.WarmUp for usage.
+	created time.Time
 
-	// successes counts the number of successful operations since the last reset.
-	// Example: If 50 operations succeeded, successes will be 50.
-	successes int64
+	// startingFlowRate is the flow rate the Nozzle started with, after WarmStart and WarmUp were applied.
+	// See nozzle.DescribeConfig for usage.
+	startingFlowRate int64
 
-	// failures counts the number of failed operations since the last reset.
-	// Example: If 20 operations failed, failures will be 20.
-	failures int64
+	// fullyClosed is set when the flow rate reaches 0, and cleared once it is back at 100.
+	// See nozzle.edge() for usage.
+	fullyClosed bool
 
-	// allowed counts the number of operations that were allowed in the current interval.
-	// Example: If 70 operations were allowed, allowed will be 70.
-	allowed int64
+	// maintenanceWindows are the windows most recently returned by Options.Maintenance.
+	// See nozzle.refreshMaintenance() for usage.
+	maintenanceWindows []MaintenanceWindow
 
-	// blocked counts the number of operations that were blocked in the current interval.
-	// Example: If 30 operations were blocked, blocked will be 30.
-	blocked int64
+	// maintenance is the reason of the maintenance window the last completed interval overlapped, or empty.
+	// See nozzle.maintain() for usage.
+	maintenance string
 
 	// start records the time when the current interval started.
 	// Example: If the interval started at 10:00 AM, start will be the time corresponding to 10:00 AM.
@@ -68,14 +128,159 @@ type Nozzle[T any] struct {
 	// Example: It prevents concurrent read and write operations from causing inconsistencies when multiple goroutines interact with Nozzle.
 	mut sync.RWMutex
 
-	// state represents whether the Nozzle is currently opening or closing.
-	// Example: If the Nozzle is adjusting to increase the flow rate, state will be Opening.
-	state State
+	// closedAt records when the flow rate last reached 0.
+	// See Options.ReopenCooldown for usage.
+	closedAt time.Time
+
+	// latest is the StateSnapshot built by the most recent tick or override change.
+	// It is read without the lock; see nozzle.Snapshot.
+	latest atomic.Pointer[StateSnapshot]
+
+	// waiters are the channels of callers blocked in WaitSnapshot.
+	// Each tick sends them its snapshot, and Close closes them.
+	// See nozzle.WaitSnapshot() for usage and nozzle.calculate() for where they are released.
+	waiters map[chan StateSnapshot]struct{}
+
+	// subscribers are the channels returned by Subscribe.
+	// Each change of the flow rate, state, or pause is sent to them, and Close closes them.
+	// See nozzle.Subscribe() for usage and nozzle.store() for where they are sent to.
+	subscribers map[chan StateSnapshot]struct{}
+
+	// decisions counts the admission decisions made since the Nozzle was created.
+	// Each decision's ID is the value of decisions right after it was made, so IDs start at 1.
+	// See nozzle.DecisionID for usage.
+	decisions uint64
+
+	// decisionsAtStart is the value of decisions when the current interval started.
+	// The current interval's decisions are those after it.
+	decisionsAtStart uint64
+
+	// units counts the total weight of the admission decisions made since the Nozzle was created.
+	// It only differs from decisions when calls are weighted, see nozzle.DoBoolN.
+	units int64
+
+	// unitsAtStart is the value of units when the current interval started.
+	unitsAtStart int64
+
+	// failureTrace is the trace ID of the most recent failed call in the current interval.
+	// See Options.TraceID for usage.
+	failureTrace string
+
+	// nextInterval is the Interval set by SetInterval, applied at the next tick.
+	// It is zero when there is none.
+	nextInterval time.Duration
+
+	// nextAllowedFailurePercent is the AllowedFailurePercent set by SetAllowedFailurePercent, applied at the next tick.
+	// It is nil when there is none.
+	nextAllowedFailurePercent *int64
+
+	// paused is set by Pause, and cleared by Resume.
+	// While it is set, intervals end without moving the flow rate.
+	paused bool
+
+	// intervals counts the intervals that have ended.
+	// The current interval's IntervalSeq is one more.
+	intervals uint64
+
+	// inFlight counts the admitted calls whose outcome has not been recorded yet.
+	// See Options.MaxConcurrent for usage.
+	inFlight int64
+
+	// uses counts the calls admitted and finished since the Nozzle was created, and running mirrors inFlight.
+	// Both are atomic, so a Registry can tell the Nozzle is in use without taking its lock.
+	// See RegistryOptions.IdleTTL for usage.
+	uses    atomic.Uint64
+	running atomic.Int64
+
+	// bulkheadBlocked counts the calls blocked by Options.MaxConcurrent in the current interval.
+	bulkheadBlocked int64
+
+	// retries counts the weight of the retries attempted in the current interval.
+	// See Options.Retry for usage.
+	retries int64
+
+	// trimmed counts the History intervals dropped to stay within Options.MemoryBudget.
+	// See nozzle.RuntimeStats for usage.
+	trimmed int64
+
+	// unadmitted counts the outcomes reported without being admitted, by IngestAggregate and ReportLate.
+	// Unlike the other counters, it is never reset.
+	// See nozzle.checkInvariants() for usage.
+	unadmitted int64
 
-	// ticker is a channel used to signal the occurrence of a new tick.
-	// Example: It allows other parts of the code to react to time-based events, such as triggering a status update.
-	// See nozzle.Wait() for usage and nozzle.Calculate() for where it is called.
-	ticker chan struct{}
+	// reentrant counts the nested calls detected since the Nozzle was created.
+	// Unlike the other counters, it is never reset.
+	// Example: If a callback calls back into the same Nozzle twice, reentrant will be 2.
+	reentrant int64
+
+	// totals accumulates the counters across every interval since the Nozzle was created.
+	// Unlike the engine's counters, it is never reset.
+	// See nozzle.Stats() for usage.
+	totals Stats
+
+	// override is the state a manual override pins the Nozzle to, or empty when there is none.
+	// Example: After ForceCloseFor, override will be ForcedClosed until it expires.
+	override State
+
+	// overrideReason is the reason given for the current override.
+	overrideReason string
+
+	// overrideUntil is when the current override expires.
+	// The zero time means the override never expires.
+	overrideUntil time.Time
+
+	// pending holds completed intervals that have not been delivered to Options.OnIntervalEnd yet, oldest first.
+	// See nozzle.deliver() for usage.
+	pending []IntervalStats
+
+	// delivering ensures only one goroutine delivers pending intervals at a time, so none is delivered twice.
+	// It is separate from mut so OnIntervalEnd can call the Nozzle's methods.
+	delivering sync.Mutex
+
+	// done is closed by Close to stop the tick and verify goroutines.
+	// It is nil when New starts neither, such as with Options.ManualTick.
+	done chan struct{}
+
+	// closed is set by Close, so a tick that races with Close does not adapt afterwards.
+	closed bool
+
+	// ticking tracks the tick and verify goroutines, so Close can wait for them to stop.
+	ticking sync.WaitGroup
+
+	// ending counts the interval ends and verifications in progress.
+	// Close cannot wait for them while one of their callbacks is calling it, so it finishes in the background instead.
+	ending atomic.Int32
+
+	// closeOnce ensures Close only runs once.
+	closeOnce sync.Once
+
+	// history records the most recent intervals, oldest first.
+	// It holds at most historySize entries.
+	// See nozzle.History() and nozzle.HistoryChart() for usage.
+	history []IntervalStats
+
+	// healths are the Healths created by Health, which count the intervals below their MinFlowRate as each interval ends.
+	healths []*Health[T]
+
+	// metrics are the decayed trends of the failure and shed rates.
+	// See nozzle.Metrics() for usage.
+	metrics Metrics
+
+	// callers counts the admissions of each caller labeled with WithCaller, while the flow rate is below 100.
+	// See Options.TrackFairness for usage.
+	callers map[string]CallerAdmission
+
+	// reservations maps the decision ID of every open Reservation to the interval it was made in, counted like intervals.
+	// It is only kept with Options.ReservationTTL. See nozzle.expireReservations() for usage.
+	reservations map[uint64]uint64
+
+	// overBudget reports whether the last interval end took longer than Options.CalculateBudget.
+	// See nozzle.measure() for usage.
+	overBudget bool
+
+	// failureTrended and shedTrended report whether metrics.FailureRate and metrics.ShedRate have been seeded.
+	failureTrended bool
+	shedTrended    bool
 }
 
 // Options controls the behavior of the Nozzle.
@@ -89,6 +294,9 @@ type Options[T any] struct {
 	//
 	// The best interval depends on the needs of your application.
 	// If you are unsure, start with 1 second.
+	//
+	// An Interval below MinInterval is raised to MinInterval, with a warning and an EventIntervalClamped event.
+	// If zero or negative, the Nozzle never ticks.
 	Interval time.Duration
 
 	// AllowedFailurePercent sets the threshold for the failure rate at which the Nozzle should open or close.
@@ -114,6 +322,582 @@ type Options[T any] struct {
 	//		},
 	//	}
 	OnStateChange func(*Nozzle[T])
+
+	// OnFullyClosed is called when the flow rate reaches 0, whether the Nozzle closed on its own or was forced closed.
+	// Use it to stop work that only makes sense while the dependency is reachable, such as prefetchers.
+	// Example:
+	//
+	//	OnFullyClosed: prefetcher.Stop,
+	//
+	// It is edge-triggered: it is not called again until OnFullyOpened has been.
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	OnFullyClosed func()
+
+	// OnFullyOpened is called when the flow rate is back at 100 after OnFullyClosed was called.
+	// Use it to resume what OnFullyClosed stopped.
+	// Example:
+	//
+	//	OnFullyOpened: prefetcher.Start,
+	//
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	OnFullyOpened func()
+
+	// Reentrancy controls what happens when a callback calls back into the same Nozzle.
+	// Nested calls can only be detected by the context-aware methods, such as DoErrorContext.
+	// Example:
+	//
+	//	Reentrancy: nozzle.ReentrancyAllow      // Nested calls are treated like any other call (default)
+	//	Reentrancy: nozzle.ReentrancyCountOnce  // Nested calls run without being admitted or counted again
+	//	Reentrancy: nozzle.ReentrancyError      // Nested calls fail with nozzle.ErrReentrant
+	//
+	// If you are unsure, use ReentrancyCountOnce so one logical operation only counts once.
+	Reentrancy ReentrancyPolicy
+
+	// ThresholdInclusive closes the Nozzle when the failure rate equals AllowedFailurePercent, instead of only when it exceeds it.
+	// A failure rate of 0 never closes the Nozzle, so an AllowedFailurePercent of 0 still reopens once nothing fails.
+	// Example:
+	//
+	//	AllowedFailurePercent: 50,
+	//	ThresholdInclusive:    true, // Exactly 50% failures closes the Nozzle
+	//
+	// The comparison used is included in the Nozzle's Reason.
+	ThresholdInclusive bool
+
+	// DisableClosing prevents the Nozzle from ever closing, regardless of the failure rate.
+	// The Nozzle still tracks and reports its rates, so it can be used purely for observability.
+	// Combined with MaxConcurrent, it makes a best-effort Nozzle: failures are tolerated, but slow calls cannot pile up.
+	// An AllowedFailurePercent of 100 behaves the same way, but DisableClosing makes the intent explicit.
+	// Example:
+	//
+	//	DisableClosing: true // The flow rate stays at 100
+	DisableClosing bool
+
+	// StrictMode closes the Nozzle on any failure, regardless of AllowedFailurePercent.
+	// Expect the Nozzle to toggle between opening and closing whenever failures are intermittent.
+	// An AllowedFailurePercent of 0 behaves the same way, but StrictMode makes the intent explicit.
+	// If DisableClosing is also set, DisableClosing takes precedence.
+	// Example:
+	//
+	//	StrictMode: true // A single failure in an interval closes the Nozzle
+	StrictMode bool
+
+	// Logger receives warnings about Options that are likely to be mistakes.
+	// The Options are checked once, when the Nozzle is created.
+	// If nil, nothing is logged.
+	// Example:
+	//
+	//	Logger: slog.Default()
+	Logger *slog.Logger
+
+	// InitialFlowRate sets the flow rate a new Nozzle starts with.
+	// Zero means the Nozzle starts fully open, at 100.
+	// Example:
+	//
+	//	InitialFlowRate: 50 // Starts by allowing half of all calls
+	//
+	// It is also the fallback when WarmStart fails.
+	InitialFlowRate int64
+
+	// WarmStart looks up the flow rate a new Nozzle should start with, such as the current average across a fleet of instances.
+	// It is called once, by New, and bounded by WarmStartTimeout.
+	// If it fails or times out, the Nozzle starts at InitialFlowRate and a warning is logged to Logger.
+	// Example:
+	//
+	//	WarmStart: nozzle.WarmStartURL("http://flow-rates.internal/payments")
+	//
+	// Because New waits for WarmStart, keep WarmStartTimeout short.
+	WarmStart func(context.Context) (int64, error)
+
+	// WarmStartTimeout bounds how long New waits for WarmStart.
+	// If zero, DefaultWarmStartTimeout is used.
+	WarmStartTimeout time.Duration
+
+	// WarmUp makes a new Nozzle start at WarmUpFlowRate and ramp linearly to 100 over the given duration.
+	// Cold caches and fresh connections make the first moments after a deploy the most failure-prone, so starting fully open can overwhelm a dependency.
+	// The ramp only caps the flow rate: failures still close the Nozzle as usual, so it only reaches 100 as long as calls succeed.
+	// Example:
+	//
+	//	WarmUp: 30 * time.Second // Reaches 100 no sooner than 30 seconds after New
+	//
+	// If zero, the Nozzle starts at its initial flow rate without a ramp.
+	WarmUp time.Duration
+
+	// WarmUpFlowRate is the flow rate a Nozzle with WarmUp starts at.
+	// If zero, DefaultWarmUpFlowRate is used.
+	WarmUpFlowRate int64
+
+	// Strategy decides how far the flow rate moves at the end of each interval.
+	// The Nozzle still decides whether it is Opening or Closing; the Strategy decides by how much.
+	// Example:
+	//
+	//	Strategy: &nozzle.Exponential{} // Doubles each step in the same direction (default)
+	//
+	// A Strategy may keep state between intervals, so give each Nozzle its own instance.
+	// If nil, a new Exponential strategy is used.
+	Strategy Strategy
+
+	// OnEvent is called for notable events in the Nozzle's lifecycle, such as a manual override starting or ending.
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	// See nozzle.Event for the events that are reported.
+	// Example:
+	//
+	//	OnEvent: func(e nozzle.Event) {
+	//		slog.Info("nozzle event", "type", e.Type, "reason", e.Reason)
+	//	},
+	OnEvent func(Event)
+
+	// SLAImpacting classifies each blocked call as impacting your SLA or not.
+	// It receives the context of the call, so it can inspect whatever metadata you attach to it, such as the customer tier or endpoint.
+	// Calls without a context, such as DoBool and DoError, receive context.Background().
+	// The tallies are reported by Stats as ShedSLAImpacting and ShedNotSLAImpacting, so you can tell how much of your error budget the Nozzle itself consumed.
+	// Example:
+	//
+	//	SLAImpacting: func(ctx context.Context) bool {
+	//		return ctx.Value(tierKey{}) == "paid"
+	//	},
+	//
+	// If nil, blocked calls are not classified.
+	SLAImpacting func(context.Context) bool
+
+	// IsFailure decides which errors returned to DoError and DoErrorContext count against the failure rate.
+	// Not every error means the dependency is unhealthy: a validation error or a 404 is the caller's problem, and closing the Nozzle over it only sheds healthy traffic.
+	// Errors it rejects are counted as successes, since the dependency responded.
+	// The error is still returned to the caller either way.
+	// Example:
+	//
+	//	IsFailure: func(err error) bool {
+	//		return !errors.Is(err, ErrNotFound)
+	//	},
+	//
+	// If nil, every non-nil error is a failure.
+	IsFailure func(error) bool
+
+	// TraceID extracts the trace ID from the context of a call, such as the OpenTelemetry trace ID.
+	// When the Nozzle decides to close, the trace ID of the most recent failed call in that interval is added to the Reason,
+	// so an alert about the Nozzle closing can link straight to a representative failing trace.
+	// Only DoBoolContext and DoErrorContext have a context to extract it from.
+	// Example:
+	//
+	//	TraceID: func(ctx context.Context) string {
+	//		return trace.SpanContextFromContext(ctx).TraceID().String()
+	//	},
+	//
+	// It is called without holding the Nozzle's lock, and only for failed calls. An empty trace ID is ignored.
+	// If nil, no trace IDs are captured.
+	TraceID func(context.Context) string
+
+	// HysteresisBand widens AllowedFailurePercent into a band, so a failure rate sitting on the threshold does not flip the state every interval.
+	// While opening, the Nozzle only starts closing once the failure rate exceeds AllowedFailurePercent + HysteresisBand.
+	// While closing, it only starts opening once the failure rate is at or below AllowedFailurePercent - HysteresisBand.
+	// Example:
+	//
+	//	AllowedFailurePercent: 20,
+	//	HysteresisBand:        5,  // Closes above 25%, reopens at or below 15%
+	//
+	// If zero, the Nozzle reverses as soon as the failure rate crosses AllowedFailurePercent.
+	HysteresisBand int64
+
+	// MinIntervalsBeforeReverse is the minimum number of consecutive intervals the Nozzle stays in a state after reversing, before it may reverse again.
+	// A new Nozzle has not reversed yet, so it may start closing immediately.
+	// It damps flapping between Opening and Closing, at the cost of reacting more slowly.
+	// Because a fully closed Nozzle keeps closing until it may reverse, it also gives the dependency quiet time before reopening.
+	// Example:
+	//
+	//	MinIntervalsBeforeReverse: 3 // Once closing starts, it continues for at least 3 intervals
+	//
+	// If zero, the Nozzle may reverse every interval.
+	MinIntervalsBeforeReverse int
+
+	// ReopenCooldown is how long a fully closed Nozzle waits before it starts opening again.
+	// Without it, a Nozzle that reaches a flow rate of 0 starts probing on the very next interval, which can hammer a dependency that needs quiet time to recover.
+	// During the cooldown the Nozzle stays at 0 and reports Closing.
+	// Example:
+	//
+	//	ReopenCooldown: 30 * time.Second // Stay fully closed for 30 seconds before probing
+	//
+	// If zero, the Nozzle starts opening on the next interval.
+	ReopenCooldown time.Duration
+
+	// FailureWindow is the number of intervals, including the current one, whose outcomes are combined to decide whether to close.
+	// A single interval makes the Nozzle jumpy when traffic is bursty; a window remembers recent failures after the interval resets.
+	// Example:
+	//
+	//	FailureWindow: 5 // Closes when more than AllowedFailurePercent of the calls in the last 5 intervals failed
+	//
+	// The rates reported by the Nozzle's getters still describe only the current interval.
+	// If zero or one, only the current interval is used.
+	FailureWindow int
+
+	// FailureWindowWeights weighs the intervals of FailureWindow, newest first, starting with the current interval.
+	// Recent intervals can then dominate the failure rate, while older ones still dampen noise.
+	// Example:
+	//
+	//	FailureWindow:        5,
+	//	FailureWindowWeights: nozzle.ExponentialWeights(5, 0.5), // 1, 0.5, 0.25, 0.125, 0.0625
+	//
+	// Intervals without a weight weigh 1, and negative weights count as 0.
+	// If empty, every interval of FailureWindow weighs the same.
+	FailureWindowWeights []float64
+
+	// SlowFailureWindow is the number of intervals in a second, longer window evaluated alongside the current interval (or FailureWindow).
+	// The Nozzle closes if either window exceeds AllowedFailurePercent.
+	// The short window reacts quickly to an outage, while the long window keeps the Nozzle from reopening until it has been healthy for a while.
+	// This mirrors multi-window burn-rate alerting for SLOs.
+	// Example:
+	//
+	//	FailureWindow:     1,  // Closes as soon as a single interval fails too much
+	//	SlowFailureWindow: 30, // Keeps closing until the last 30 intervals are healthy overall
+	//
+	// It should be larger than FailureWindow. If zero or one, only a single window is used.
+	SlowFailureWindow int
+
+	// FailureSmoothing decides whether to close using an exponentially weighted moving average of the failure rate, instead of the current interval alone.
+	// It is the weight of the current interval, between 0 and 1; smaller values react more slowly.
+	// Example:
+	//
+	//	FailureSmoothing: 0.3 // 30% the current interval, 70% the previous average
+	//
+	// If FailureWindow is also set, FailureWindow takes precedence.
+	// If zero, no smoothing is applied.
+	FailureSmoothing float64
+
+	// QueueDepth reports the depth of a queue the Nozzle guards, such as a local job queue that calls are enqueued onto.
+	// It is sampled at the end of each interval; when it is above MaxQueueDepth, the Nozzle closes even if no call has failed.
+	// This sheds enqueues while the queue backs up, instead of waiting for its timeouts to show up as failures.
+	// Example:
+	//
+	//	QueueDepth:    func() int64 { return int64(len(jobs)) },
+	//	MaxQueueDepth: 1000,
+	//
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	// If nil, only the failure rate decides.
+	QueueDepth func() int64
+
+	// MaxQueueDepth is the deepest QueueDepth may be before the Nozzle closes.
+	// A depth equal to it is acceptable.
+	MaxQueueDepth int64
+
+	// OnInvariantViolation is called when the Nozzle's internal bookkeeping is inconsistent, which always indicates a bug.
+	// The invariants are checked at the end of every interval, but only when OnInvariantViolation is set, so enable it in tests and debug builds.
+	// Each violation is an error wrapping ErrInvariantViolated.
+	// Example:
+	//
+	//	OnInvariantViolation: func(err error) {
+	//		t.Error(err)
+	//	},
+	//
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	OnInvariantViolation func(error)
+
+	// Scheduler ticks the Nozzle from a goroutine shared with other Nozzles, instead of the Nozzle's own goroutine and ticker.
+	// Use it when creating many Nozzles, such as one per tenant, to reduce their memory footprint.
+	// Example:
+	//
+	//	Scheduler: scheduler, // Created once with nozzle.NewScheduler(100 * time.Millisecond)
+	//
+	// See nozzle.Scheduler for details. If nil, the Nozzle starts its own goroutine.
+	Scheduler *Scheduler
+
+	// ManualTick stops the Nozzle from starting any goroutine or ticker: intervals only end when you call Tick.
+	// Use it where a hidden goroutine is not tolerated, such as embedded and wasm environments, or in deterministic simulations.
+	// Example:
+	//
+	//	ManualTick: true, // Call n.Tick() from your own loop
+	//
+	// Tick still honors Interval, so it may be called more often than that; with an Interval of zero, every Tick ends an interval.
+	// Verify runs from Tick too, once per interval, and VerifyInterval is ignored.
+	// It takes precedence over Scheduler.
+	ManualTick bool
+
+	// OnIntervalEnd is called exactly once for every completed interval, in order.
+	// Unlike OnStateChange, it is called whether or not anything changed, so it suits exact accounting such as billing.
+	// If it returns an error, the interval is kept and retried at the end of the next interval, followed by any intervals that completed since.
+	// Close ends the current interval and makes a final attempt.
+	// Example:
+	//
+	//	OnIntervalEnd: func(s nozzle.IntervalStats) error {
+	//		return billing.Record(ctx, s.Start, s.Allowed)
+	//	},
+	//
+	// Undelivered intervals are kept in memory until they are delivered, so it should not fail for long.
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	OnIntervalEnd func(IntervalStats) error
+
+	// MemoryBudget caps the estimated bytes held by History and by intervals waiting for OnIntervalEnd.
+	// When a completed interval puts the Nozzle over budget, the oldest History intervals are dropped first.
+	// Intervals waiting for OnIntervalEnd are counted, but never dropped, so a failing OnIntervalEnd can still exceed the budget.
+	// Example:
+	//
+	//	MemoryBudget: 4 << 10 // 4 KiB, about 30 intervals of History
+	//
+	// See nozzle.RuntimeStats for the current estimate.
+	// If zero, History keeps its full size.
+	MemoryBudget int64
+
+	// CalculateBudget is how long the Nozzle's own work at the end of an interval, including its callbacks, should take.
+	// When it takes longer, the next interval end skips optional work: the invariant checks and the Metrics trends.
+	// Example:
+	//
+	//	CalculateBudget: 5 * time.Millisecond
+	//
+	// It is measured in real time, even with a Clock. See Metrics.CalculateDuration and Stats.CalculateOverBudget to tell when it is exceeded.
+	// If zero, it is a tenth of Interval.
+	CalculateBudget time.Duration
+
+	// TrackFairness records, per caller, how calls are admitted while the flow rate is below 100.
+	// Label calls with nozzle.WithCaller, and read the result with Fairness, to check that shedding a shared Nozzle does not systematically punish one caller.
+	// Example:
+	//
+	//	TrackFairness: true,
+	TrackFairness bool
+
+	// ReservationTTL is how many intervals an allowed Reservation may stay open before it is counted as leaked.
+	// A leaked Reservation frees its MaxConcurrent slot, and its late Commit or Cancel is ignored, so a lost Reservation cannot hold a slot or skew a later interval.
+	// Example:
+	//
+	//	ReservationTTL: 3 // A Reservation must end within 3 intervals
+	//
+	// See Stats.ReservationsLeaked and Stats.ReservationsLate.
+	// If zero, Reservations never expire.
+	ReservationTTL int
+
+	// ProfileLabel names the Nozzle in pprof labels.
+	// When set, every admitted callback runs with the labels "nozzle" (this name) and "flow_band" (see nozzle.FlowBand), and so do goroutines it starts.
+	// CPU profiles taken during an incident can then be broken down by how much work ran while the Nozzle was degraded:
+	//
+	//	go tool pprof -tagfocus=flow_band=degraded profile.pb.gz
+	//
+	// Example:
+	//
+	//	ProfileLabel: "payments",
+	//
+	// If empty, no labels are set, and callbacks run without the small cost of setting them.
+	ProfileLabel string
+
+	// Verify runs a deeper check of the dependency, such as a checksum or a canary query, every VerifyInterval.
+	// It catches failures that calls cannot see, like silently corrupted responses, even while every call succeeds.
+	// When it returns an error, the current interval counts as overloaded, so the Nozzle closes at its end.
+	// Example:
+	//
+	//	Verify: func(ctx context.Context) error {
+	//		return db.QueryRowContext(ctx, "SELECT checksum FROM canary").Scan(&sum)
+	//	},
+	//
+	// Each failure is counted in Stats.VerificationFailures and reported to Options.OnEvent as EventVerificationFailed.
+	// It runs on its own goroutine, without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	// Its context is canceled when the Nozzle is closed.
+	Verify func(context.Context) error
+
+	// VerifyInterval is how often Verify runs.
+	// If zero, it runs once per Interval.
+	VerifyInterval time.Duration
+
+	// MaxConcurrent caps how many admitted calls may run at once, in addition to the flow rate.
+	// The flow rate bounds the share of calls that run, but not how many pile up when they are slow; MaxConcurrent bounds both.
+	// Example:
+	//
+	//	MaxConcurrent: 64,
+	//
+	// A call is running from the moment it is admitted until its outcome is recorded, so calls permitted by Allow or Reserve hold their slot until they are reported.
+	// Calls blocked by it are not flow rate decisions: they are counted as BulkheadBlocked instead of Blocked, and their BlockedError has Bulkhead set.
+	// It applies during manual overrides too.
+	// If zero, concurrency is not capped.
+	MaxConcurrent int64
+
+	// ClosedPanic makes calls made after Close panic instead of being admitted at the final flow rate.
+	// A call after Close usually means a shutdown ordering bug, and DoBool cannot report it: its false result looks like any blocked or failed call.
+	// The panic value is an error that wraps nozzle.ErrClosed.
+	// Example:
+	//
+	//	ClosedPanic: true // Catch calls made after Close during development
+	ClosedPanic bool
+
+	// Clock tells the Nozzle the time and schedules its ticks, including those of Verify and Retry.Backoff.
+	// Set it in tests to control time, instead of sleeping through real intervals.
+	// Example:
+	//
+	//	clock := nozzle.NewManualClock(time.Now())
+	//
+	//	Clock: clock, // clock.Advance(time.Second) ends a one second Interval
+	//
+	// A Scheduler ticks with its own, real, ticker; the Clock still decides when each interval ends.
+	// If nil, the system clock is used.
+	Clock Clock
+
+	// Audit records every flow rate change and manual override, for environments where traffic shedding must be provable after the fact.
+	// Example:
+	//
+	//	sink, err := nozzleaudit.Open("/var/log/nozzle/payments.audit")
+	//	if err != nil {
+	//		// handle error
+	//	}
+	//
+	//	Audit: sink,
+	//
+	// It is called without holding the Nozzle's lock, in the order changes are made on each goroutine.
+	// Errors it returns are logged to Options.Logger.
+	Audit AuditSink
+
+	// Retry retries failed calls made with DoError, DoErrorN, and DoErrorContext.
+	// Each retry is admitted by the Nozzle like any other call and counts toward the interval, so retries back off as the Nozzle closes instead of amplifying an incident.
+	// Example:
+	//
+	//	Retry: nozzle.Retry{
+	//		MaxAttempts: 3,
+	//		Backoff:     func(attempt int) time.Duration { return time.Duration(attempt) * 50 * time.Millisecond },
+	//		Budget:      10,
+	//	},
+	//
+	// See nozzle.Retry for details. If zero, calls are never retried.
+	Retry Retry
+
+	// MaxWait is the longest DoWaitContext waits for a blocked call to be allowed, whatever the deadline of its context.
+	// A call that waited MaxWait fails with a *WaitError, so it can be told apart from the caller's own context ending.
+	// Example:
+	//
+	//	MaxWait: 30 * time.Second // Give up on a batch job after 30 seconds of waiting
+	//
+	// If zero, DoWaitContext waits until its context is done.
+	MaxWait time.Duration
+
+	// Cluster decides on the failure rate of every replica of the service, instead of this one alone.
+	// See nozzle.Cluster for details. If zero, only this Nozzle's outcomes are used.
+	Cluster Cluster
+
+	// CompatLevel pins the behaviors that changed between versions, so upgrading does not silently change how a tuned Nozzle sheds.
+	// Example:
+	//
+	//	CompatLevel: nozzle.Compat2, // Keep today's behaviors until we re-tune
+	//
+	// Each level documents what it changed; see nozzle.CompatLevel. New reports the active level with EventCompatLevel.
+	// If zero, the newest behaviors are used.
+	CompatLevel CompatLevel
+
+	// Maintenance declares the dependency's planned downtime, so it is not learned as organic failure.
+	// Example:
+	//
+	//	Maintenance: nozzle.MaintenanceURL("http://calendar.internal/payments.json", time.Minute),
+	//
+	// Intervals that overlap a maintenance window hold the flow rate and state, like Pause, and their outcomes are forgotten instead of weighing on the decisions after the window.
+	// They are marked Maintenance in History and snapshots, and the start and end of maintenance are reported to Options.OnEvent.
+	// If nil, the Nozzle always adapts.
+	Maintenance MaintenanceSource
+
+	// PanicPolicy decides how a panic in a callback is counted, and whether it is recovered.
+	// Example:
+	//
+	//	PanicPolicy: nozzle.PanicRecover, // Return panics as *nozzle.PanicError
+	//
+	// See nozzle.PanicPolicy. If zero, a panic counts as a failure and is panicked again.
+	PanicPolicy PanicPolicy
+
+	// OnPanic is called with every panic in a callback, whatever the PanicPolicy, before it is panicked again or returned.
+	// Example:
+	//
+	//	OnPanic: func(p *nozzle.PanicError) {
+	//		logger.Error("nozzle callback panicked", "panic", p.Value, "stack", string(p.Stack))
+	//	},
+	//
+	// It is called without holding the Nozzle's lock, so it may call the Nozzle's methods.
+	OnPanic func(*PanicError)
+}
+
+// ReentrancyPolicy describes how a Nozzle treats a call made from inside one of its own callbacks.
+//
+// The context-aware methods (DoBoolContext and DoErrorContext) mark the context they pass to the callback.
+// When that context, or one derived from it, is passed back to the same Nozzle, the call is reentrant.
+// Each reentrant call is counted, see nozzle.ReentrantCalls.
+type ReentrancyPolicy int
+
+const (
+	// ReentrancyAllow admits and counts nested calls like any other call.
+	// The nested call counts toward the statistics in addition to the call that contains it.
+	ReentrancyAllow ReentrancyPolicy = iota
+
+	// ReentrancyCountOnce runs nested calls without admitting or counting them.
+	// Only the outermost call affects the Nozzle's statistics.
+	ReentrancyCountOnce
+
+	// ReentrancyError rejects nested calls with nozzle.ErrReentrant without running the callback.
+	ReentrancyError
+)
+
+// Stats contains cumulative counters since the Nozzle was created.
+// Unlike the rates, which describe the current interval, these values only ever grow.
+// They are useful for reporters that compute their own deltas between flushes.
+type Stats struct {
+	// Allowed is the number of calls the Nozzle has allowed.
+	Allowed int64
+
+	// Blocked is the number of calls the Nozzle has blocked.
+	Blocked int64
+
+	// Successes is the number of allowed calls that succeeded.
+	Successes int64
+
+	// Failures is the number of allowed calls that failed.
+	Failures int64
+
+	// ShedSLAImpacting is the number of blocked calls that Options.SLAImpacting classified as impacting your SLA.
+	ShedSLAImpacting int64
+
+	// ShedNotSLAImpacting is the number of blocked calls that Options.SLAImpacting classified as not impacting your SLA.
+	ShedNotSLAImpacting int64
+
+	// CallerCanceled is the number of allowed calls to DoErrorContext that failed because the caller's own context was done.
+	// They count as neither successes nor failures.
+	CallerCanceled int64
+
+	// VerificationFailures is the number of times Options.Verify returned an error.
+	VerificationFailures int64
+
+	// Retries is the number of retries Options.Retry attempted, whether or not the Nozzle allowed them.
+	Retries int64
+
+	// ClusterErrors is the number of intervals decided on local outcomes alone, because Options.Cluster could not exchange counts.
+	ClusterErrors int64
+
+	// MaintenanceIntervals is the number of intervals that overlapped a window declared by Options.Maintenance.
+	MaintenanceIntervals int64
+
+	// MaintenanceErrors is the number of intervals at which Options.Maintenance failed, so the windows it returned last were used.
+	MaintenanceErrors int64
+
+	// Panics is the number of admitted calls whose callback panicked. See Options.PanicPolicy.
+	Panics int64
+
+	// BulkheadBlocked is the number of calls blocked because Options.MaxConcurrent calls were already running.
+	// They are not included in Blocked.
+	BulkheadBlocked int64
+
+	// CalculateOverBudget is the number of interval ends that took longer than Options.CalculateBudget.
+	CalculateOverBudget int64
+
+	// StateChanges is the number of interval ends at which the Nozzle changed direction, between Opening and Closing.
+	// Manual overrides are not counted.
+	StateChanges int64
+
+	// ReducedFlowTime is how long the flow rate has been below 100, over the completed intervals.
+	// Example: A Nozzle that closed for 3 one second intervals, then reopened, reports 3 seconds.
+	ReducedFlowTime time.Duration
+
+	// ReservationsLeaked is the number of allowed Reservations that did not end within Options.ReservationTTL intervals.
+	ReservationsLeaked int64
+
+	// ReservationsLate is the number of Commit and Cancel calls ignored because their Reservation had leaked, or the Nozzle was closed.
+	ReservationsLate int64
+
+	// ReservationsReused is the number of Commit and Cancel calls ignored because their Reservation had already ended.
+	ReservationsReused int64
+
+	// UnmatchedReports is the number of outcomes reported while no admitted call was running, such as ReportSuccess without Allow.
+	// They are still recorded, but a growing value points at an integration that reports the same call more than once.
+	UnmatchedReports int64
+}
+
+// reentrancyKey marks a context as being inside a callback of a specific Nozzle.
+type reentrancyKey struct {
+	nozzle any
 }
 
 // State describes the current direction the Nozzle is moving.
@@ -121,16 +905,29 @@ type Options[T any] struct {
 // If the Nozzle is fully open and below the allowed error rate, it will continue to try to open, but this is a no-op.
 // If the Nozzle is fully closed, it will revert to trying to open. This allows it to continually check for opportunities to re-open.
 // If the Nozzle is on the edge of the AllowedFailurePercent, you will observe it toggle between opening/closing as it explores if it can re-open.
-type State string
+type State = engine.State
 
 const (
 	// Opening means the FlowRate is increasing.
-	Opening State = "opening"
+	Opening = engine.Opening
 
 	// Closing means the FlowRate is decreasing.
-	Closing State = "closing"
+	Closing = engine.Closing
+
+	// ForcedOpen means a manual override is allowing every call.
+	// See nozzle.ForceOpenFor.
+	ForcedOpen State = "forced-open"
+
+	// ForcedClosed means a manual override is blocking every call.
+	// See nozzle.ForceCloseFor.
+	ForcedClosed State = "forced-closed"
 )
 
+// MinInterval is the smallest Options.Interval a Nozzle uses.
+// Shorter intervals see too few calls to measure a failure rate, and a ticker that fires every few nanoseconds keeps a CPU core busy doing nothing else.
+// See BenchmarkNozzle_TickerOverhead for the cost of small intervals.
+const MinInterval = time.Millisecond
+
 // New creates a new Nozzle with Options.
 //
 // A Nozzle starts fully open.
@@ -149,23 +946,307 @@ const (
 //
 // See docs of nozzle.Options for details about each Option field.
 func New[T any](options Options[T]) *Nozzle[T] {
-	n := Nozzle[T]{
-		flowRate: 100,
-		Options:  options,
-		state:    Opening,
+	requested := options.Interval
+	if requested > 0 && requested < MinInterval {
+		options.Interval = MinInterval
+	}
+
+	n := &Nozzle[T]{}
+
+	n.config.Store(&options)
+
+	n.created = n.now()
+	n.start = n.created
+
+	n.validate()
+
+	flowRate := n.initialFlowRate()
+	if options.WarmUp > 0 {
+		flowRate = min(flowRate, n.warmUpFlowRate())
+	}
+
+	n.startingFlowRate = flowRate
+	n.fullyClosed = flowRate <= 0
+	n.engine = engine.New(engineConfig(options), flowRate)
+	n.publish()
+
+	if options.Interval != requested {
+		n.clampedInterval(requested)
+	}
+
+	n.startedCompat()
+
+	if options.ManualTick {
+		return n
+	}
+
+	// Only the goroutines started below wait for done, so a Nozzle ticked by a Scheduler without Verify does without it.
+	if options.Verify != nil || options.Scheduler == nil {
+		n.done = make(chan struct{})
+	}
+
+	if options.Verify != nil {
+		n.ticking.Add(1)
+
+		go n.verify(options.Interval)
 	}
 
+	if options.Scheduler != nil {
+		options.Scheduler.add(n)
+
+		return n
+	}
+
+	n.ticking.Add(1)
+
 	go n.tick()
 
-	return &n
+	return n
+}
+
+// options returns the Options in effect.
+// The result must not be modified; store a modified copy in n.config instead.
+// A zero-value Nozzle, which was not created by nozzle.New, uses the zero Options.
+func (n *Nozzle[T]) options() *Options[T] {
+	if o := n.config.Load(); o != nil {
+		return o
+	}
+
+	return &Options[T]{}
+}
+
+// validate warns, through Options.Logger, about option values that are valid but probably unintended.
+// It never changes the Options, so the Nozzle behaves exactly as configured.
+func (n *Nozzle[T]) validate() {
+	if n.options().Logger == nil {
+		return
+	}
+
+	o := *n.options()
+
+	if o.AllowedFailurePercent < 0 || o.AllowedFailurePercent > 100 {
+		o.Logger.Warn("nozzle: AllowedFailurePercent should be between 0 and 100", "allowedFailurePercent", o.AllowedFailurePercent)
+	}
+
+	if o.AllowedFailurePercent >= 100 && !o.DisableClosing {
+		o.Logger.Warn("nozzle: AllowedFailurePercent of 100 means the nozzle never closes; set DisableClosing if that is intended", "allowedFailurePercent", o.AllowedFailurePercent)
+	}
+
+	if o.AllowedFailurePercent <= 0 && !o.StrictMode && !o.DisableClosing {
+		o.Logger.Warn("nozzle: AllowedFailurePercent of 0 closes the nozzle on any failure; set StrictMode if that is intended", "allowedFailurePercent", o.AllowedFailurePercent)
+	}
+
+	if o.DisableClosing && o.StrictMode {
+		o.Logger.Warn("nozzle: DisableClosing and StrictMode are both set; DisableClosing takes precedence")
+	}
+
+	if o.FailureSmoothing < 0 || o.FailureSmoothing > 1 {
+		o.Logger.Warn("nozzle: FailureSmoothing should be between 0 and 1; it is ignored", "failureSmoothing", o.FailureSmoothing)
+	}
+
+	if o.SlowFailureWindow > 1 && o.SlowFailureWindow <= o.FailureWindow {
+		o.Logger.Warn("nozzle: SlowFailureWindow should be larger than FailureWindow", "failureWindow", o.FailureWindow, "slowFailureWindow", o.SlowFailureWindow)
+	}
+
+	if len(o.FailureWindowWeights) > 0 && len(o.FailureWindowWeights) != max(o.FailureWindow, 1) {
+		o.Logger.Warn("nozzle: FailureWindowWeights should have one weight per interval of FailureWindow", "failureWindow", o.FailureWindow, "weights", len(o.FailureWindowWeights))
+	}
+
+	if o.FailureWindow > 1 && o.FailureSmoothing != 0 {
+		o.Logger.Warn("nozzle: FailureWindow and FailureSmoothing are both set; FailureWindow takes precedence")
+	}
+
+	if o.QueueDepth != nil && o.MaxQueueDepth <= 0 {
+		o.Logger.Warn("nozzle: MaxQueueDepth should be positive when QueueDepth is set; any queued item will close the nozzle", "maxQueueDepth", o.MaxQueueDepth)
+	}
+
+	if o.Retry.Budget < 0 || o.Retry.Budget > 100 {
+		o.Logger.Warn("nozzle: Retry.Budget should be between 0 and 100", "budget", o.Retry.Budget)
+	}
+
+	if o.Retry.MaxAttempts <= 1 && (o.Retry.Backoff != nil || o.Retry.Budget != 0) {
+		o.Logger.Warn("nozzle: Retry is configured, but MaxAttempts of 1 or less never retries", "maxAttempts", o.Retry.MaxAttempts)
+	}
+
+	if o.VerifyInterval != 0 && o.Verify == nil {
+		o.Logger.Warn("nozzle: VerifyInterval is set without Verify; it is ignored", "verifyInterval", o.VerifyInterval)
+	}
+
+	if o.ManualTick && o.Scheduler != nil {
+		o.Logger.Warn("nozzle: ManualTick and Scheduler are both set; ManualTick takes precedence")
+	}
+
+	if o.ManualTick && o.VerifyInterval != 0 {
+		o.Logger.Warn("nozzle: VerifyInterval is ignored with ManualTick; Verify runs once per interval", "verifyInterval", o.VerifyInterval)
+	}
+
+	if o.Cluster.Transport != nil && o.Cluster.Replica == "" {
+		o.Logger.Warn("nozzle: Cluster.Replica should name this replica; replicas without a name ignore each other's counts")
+	}
+
+	if o.PanicPolicy < PanicRethrow || o.PanicPolicy > PanicUncounted {
+		o.Logger.Warn("nozzle: unknown PanicPolicy; panics are counted as failures and panicked again", "panicPolicy", o.PanicPolicy)
+	}
+
+	if o.CompatLevel < CompatLatest || o.CompatLevel > CurrentCompatLevel {
+		o.Logger.Warn("nozzle: unknown CompatLevel; using the newest behaviors", "compatLevel", o.CompatLevel, "newest", CurrentCompatLevel)
+	}
+
+	if o.ReservationTTL < 0 {
+		o.Logger.Warn("nozzle: ReservationTTL should not be negative; Reservations never expire", "reservationTTL", o.ReservationTTL)
+	}
+
+	if o.MaxWait < 0 {
+		o.Logger.Warn("nozzle: MaxWait should not be negative; DoWaitContext waits until its context is done", "maxWait", o.MaxWait)
+	}
+}
+
+// clampedInterval reports that the requested Options.Interval was raised to MinInterval.
+func (n *Nozzle[T]) clampedInterval(requested time.Duration) {
+	reason := fmt.Sprintf("Interval %s is below the minimum of %s", requested, MinInterval)
+
+	if n.options().Logger != nil {
+		n.options().Logger.Warn("nozzle: "+reason+"; using the minimum", "interval", requested)
+	}
+
+	n.emit(Event{
+		Type:     EventIntervalClamped,
+		Time:     n.now(),
+		Reason:   reason,
+		Duration: MinInterval,
+	})
+}
+
+// engineConfig extracts the parts of options that control the engine.
+func engineConfig[T any](options Options[T]) engine.Config {
+	return engine.Config{
+		AllowedFailurePercent:     options.AllowedFailurePercent,
+		ThresholdInclusive:        options.ThresholdInclusive,
+		DisableClosing:            options.DisableClosing,
+		StrictMode:                options.StrictMode,
+		HysteresisBand:            options.HysteresisBand,
+		MinIntervalsBeforeReverse: options.MinIntervalsBeforeReverse,
+		Window:                    options.FailureWindow,
+		WindowWeights:             slices.Clone(options.FailureWindowWeights),
+		SlowWindow:                options.SlowFailureWindow,
+		Smoothing:                 options.FailureSmoothing,
+		Strategy:                  options.Strategy,
+	}
 }
 
 // tick periodically invokes the calculate method based on the Nozzle's interval.
-// It ensures the Nozzle processes its state updates at regular intervals.
+// It ensures the Nozzle processes its state updates at regular intervals, until Close is called.
+// A Nozzle without a positive Interval never ticks.
 func (n *Nozzle[T]) tick() {
-	for range time.Tick(n.Options.Interval) {
-		n.calculate()
+	defer n.ticking.Done()
+
+	if n.options().Interval <= 0 {
+		<-n.done
+
+		return
+	}
+
+	interval := n.options().Interval
+
+	ticker := n.clock().NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C():
+			n.calculate()
+		}
+
+		// SetInterval takes effect at a tick, so follow it.
+		if next := n.interval(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// Close stops the Nozzle from adapting and ends the current interval.
+// The current interval is delivered to Options.OnIntervalEnd, along with any intervals whose delivery previously failed.
+// If some cannot be delivered, Close returns the error from OnIntervalEnd and they are discarded.
+//
+// Close may be called from any callback. Called while an interval is ending, such as from OnStateChange or OnIntervalEnd,
+// it returns right away, and finishes once the interval has ended; it then cannot report undelivered intervals.
+//
+// After Close, the flow rate no longer changes; calls are still admitted at the final flow rate, unless Options.ClosedPanic is set.
+// Calling Close more than once does nothing.
+//
+// Example:
+//
+//	defer func() {
+//		if err := n.Close(); err != nil {
+//			// some intervals were never recorded.
+//		}
+//	}()
+func (n *Nozzle[T]) Close() error {
+	if n.passThrough() {
+		return nil
 	}
+
+	var err error
+
+	n.closeOnce.Do(func() {
+		if n.options().Scheduler != nil {
+			n.options().Scheduler.remove(n)
+		}
+
+		if n.done != nil {
+			close(n.done)
+		}
+
+		// Close may be called by a callback of an ending interval, such as OnStateChange,
+		// which would wait forever for the goroutine it is running on.
+		if n.ending.Load() > 0 {
+			// Closed right away, so nothing waits for, or adapts at, a tick after this one.
+			n.mut.Lock()
+			n.closed = true
+			n.mut.Unlock()
+
+			go n.finish() //nolint:errcheck // nobody is left to report it to.
+
+			return
+		}
+
+		err = n.finish()
+	})
+
+	return err
+}
+
+// finish waits for the tick and verify goroutines to stop, then delivers the current interval and any undelivered ones.
+// It is the part of Close that must not run on those goroutines.
+func (n *Nozzle[T]) finish() error {
+	n.ticking.Wait()
+
+	n.mut.Lock()
+
+	n.closed = true
+	n.closeWaiters()
+	n.closeSubscribers()
+
+	if n.options().OnIntervalEnd != nil {
+		n.pending = append(n.pending, n.intervalStats())
+	}
+
+	n.mut.Unlock()
+
+	err := n.deliver()
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if undelivered := len(n.pending); undelivered > 0 {
+		n.pending = nil
+		err = fmt.Errorf("nozzle: %d intervals not delivered to OnIntervalEnd: %w", undelivered, err)
+	}
+
+	return err
 }
 
 // DoBool executes a callback function while respecting the Nozzle's state.
@@ -190,301 +1271,882 @@ func (n *Nozzle[T]) tick() {
 //
 // If the callback function does not return true or false, Nozzle's behavior will not be affected.
 func (n *Nozzle[T]) DoBool(callback func() (T, bool)) (T, bool) {
-	n.mut.Lock()
+	res, ok, _ := n.doBool(1, callback)
+
+	return res, ok
+}
+
+// DoBool2 is like DoBool, but it also reports whether the call was blocked.
+// With DoBool, a blocked call and a failed call both return false.
+// DoBool2 returns blocked=true only when the Nozzle refused to run the callback, so you can handle both cases separately.
+//
+// Example:
+//
+//	res, ok, blocked := n.DoBool2(func() (*example, bool) {
+//		result, err := someFuncThatCanFail()
+//		return result, err == nil
+//	})
+//	if blocked {
+//		// handle blocked, the callback never ran.
+//	}
+//
+//	if !ok {
+//		// handle failure.
+//	}
+func (n *Nozzle[T]) DoBool2(callback func() (T, bool)) (T, bool, bool) {
+	return n.doBool(1, callback)
+}
 
-	var allowRate int64
+// DoBoolN is like DoBool, but the call counts as weight calls toward allowed, blocked, successes, and failures.
+// Use it for calls that cost the dependency more than others, such as batch endpoints and bulk writes, so they weigh accordingly in the flow and failure rates.
+// A weight below 1 counts as 1.
+//
+// Example:
+//
+//	res, ok := n.DoBoolN(int64(len(batch)), func() (*example, bool) {
+//		result, err := writeBatch(batch)
+//		return result, err == nil
+//	})
+func (n *Nozzle[T]) DoBoolN(weight int64, callback func() (T, bool)) (T, bool) {
+	res, ok, _ := n.doBool(weight, callback)
 
-	if n.allowed != 0 {
-		allowRate = int64((float64(n.allowed) / float64(n.allowed+n.blocked)) * 100)
+	return res, ok
+}
+
+// doBool is the shared implementation of DoBool, DoBool2, and DoBoolN.
+// It returns the callback's result, whether it succeeded, and whether the call was blocked.
+func (n *Nozzle[T]) doBool(weight int64, callback func() (T, bool)) (T, bool, bool) {
+	if n.passedThrough() {
+		res, ok := callback()
+
+		return res, ok, false
 	}
 
-	var allow bool
+	weight = max(weight, 1)
 
-	if n.flowRate == 100 {
-		allow = true
-	} else if n.flowRate > 0 {
-		allow = allowRate < n.flowRate
+	if _, ok := n.admit(context.Background(), weight); !ok {
+		return *new(T), false, true
+	}
+
+	var res T
+	var ok bool
+
+	if p := n.guard(weight, func() {
+		n.profile(context.Background(), func(context.Context) {
+			res, ok = callback()
+		})
+	}); p != nil {
+		return *new(T), false, false
+	}
+
+	if ok {
+		n.success(weight)
+	} else {
+		n.failure(weight)
+	}
+
+	return res, ok, false
+}
+
+// DoError executes a callback function while respecting the Nozzle's state.
+// It monitors how many calls have been allowed and compares this with the flowRate to determine if this particular call will be permitted.
+//
+// The callback function receives no arguments and should return an error.
+// If the callback returns nil, the success method will be called. If the callback returns an error, the failure method will be called, unless Options.IsFailure says otherwise.
+//
+// Example:
+//
+//	var n nozzle.Nozzle[*example]
+//
+//	res, err := n.DoError(func() (*example, error) {
+//		ex, err := someFuncThatCanFail()
+//		return ex, err
+//	})
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	fmt.Print(res) // Use the result
+//
+// If the callback function does not return an error, Nozzle's behavior will be affected according to the success method.
+func (n *Nozzle[T]) DoError(callback func() (T, error)) (T, error) {
+	return n.doError(1, callback)
+}
+
+// DoErrorN is like DoError, but the call counts as weight calls toward allowed, blocked, successes, and failures.
+// See nozzle.DoBoolN.
+//
+// Example:
+//
+//	res, err := n.DoErrorN(int64(len(rows)), func() (*example, error) {
+//		return bulkInsert(rows)
+//	})
+func (n *Nozzle[T]) DoErrorN(weight int64, callback func() (T, error)) (T, error) {
+	return n.doError(weight, callback)
+}
+
+// Do is like DoError, for callbacks that return no result, such as fire-and-forget writes.
+// It saves declaring a Nozzle[any] and returning nil results; any Nozzle can use it, whatever its T.
+//
+// Example:
+//
+//	err := n.Do(func() error {
+//		return analytics.Track(event)
+//	})
+//	if errors.Is(err, nozzle.ErrBlocked) {
+//		// the callback never ran.
+//	}
+func (n *Nozzle[T]) Do(callback func() error) error {
+	_, err := n.doError(1, func() (T, error) {
+		return *new(T), callback()
+	})
+
+	return err
+}
+
+// DoContext is like DoErrorContext, for callbacks that return no result.
+// See Do.
+//
+// Example:
+//
+//	err := n.DoContext(ctx, func(ctx context.Context) error {
+//		return cache.Delete(ctx, key)
+//	})
+func (n *Nozzle[T]) DoContext(ctx context.Context, callback func(context.Context) error) error {
+	_, err := n.DoErrorContext(ctx, func(ctx context.Context) (T, error) {
+		return *new(T), callback(ctx)
+	})
+
+	return err
+}
+
+// doError is the shared implementation of DoError and DoErrorN.
+func (n *Nozzle[T]) doError(weight int64, callback func() (T, error)) (T, error) {
+	if n.passedThrough() {
+		return callback()
+	}
+
+	weight = max(weight, 1)
+
+	var res T
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		decision, ok := n.admit(context.Background(), weight)
+		if !ok && attempt == 1 {
+			return *new(T), n.blocked(decision)
+		}
+
+		if !ok {
+			// A blocked retry returns the failure it was retrying.
+			return res, err
+		}
+
+		if p := n.guard(weight, func() {
+			n.profile(context.Background(), func(context.Context) {
+				res, err = callback()
+			})
+		}); p != nil {
+			return *new(T), p
+		}
+
+		if !n.outcome(err, weight) || !n.retry(context.Background(), attempt, weight) {
+			return res, err
+		}
+	}
+}
+
+// DoBoolContext is like DoBool, but it passes a context to the callback.
+// The context passed to the callback is derived from ctx and marks the call as being inside this Nozzle.
+// If the callback uses that context to call the same Nozzle again, the nested call is handled according to Options.Reentrancy.
+// A nested call rejected by ReentrancyError returns false without running the callback.
+//
+// Example:
+//
+//	res, ok := n.DoBoolContext(ctx, func(ctx context.Context) (*example, bool) {
+//		result, err := someFuncThatCanFail(ctx)
+//		return result, err == nil
+//	})
+//	if !ok {
+//		// handle failure.
+//	}
+func (n *Nozzle[T]) DoBoolContext(ctx context.Context, callback func(context.Context) (T, bool)) (T, bool) {
+	if n.passedThrough() {
+		return callback(ctx)
 	}
 
-	if !allow {
-		n.blocked++
-		n.mut.Unlock()
+	if policy, ok := n.reentered(ctx); ok {
+		switch policy {
+		case ReentrancyError:
+			return *new(T), false
+		case ReentrancyCountOnce:
+			return callback(ctx)
+		case ReentrancyAllow:
+		}
+	}
 
+	decision, ok := n.admit(ctx, 1)
+	if !ok {
 		return *new(T), false
 	}
 
-	n.allowed++
-
-	n.mut.Unlock()
+	var res T
 
-	res, ok := callback()
+	if p := n.guard(1, func() {
+		n.profile(ctx, func(ctx context.Context) {
+			res, ok = callback(&callContext{Context: ctx, nozzle: n, decision: decision})
+		})
+	}); p != nil {
+		return *new(T), false
+	}
 
 	if ok {
-		n.success()
+		n.success(1)
 	} else {
-		n.failure()
+		n.failure(1)
+		n.traceFailure(ctx)
 	}
 
 	return res, ok
 }
 
-// DoError executes a callback function while respecting the Nozzle's state.
-// It monitors how many calls have been allowed and compares this with the flowRate to determine if this particular call will be permitted.
+// DoErrorContext is like DoError, but it passes a context to the callback.
+// The context passed to the callback is derived from ctx and marks the call as being inside this Nozzle.
+// If the callback uses that context to call the same Nozzle again, the nested call is handled according to Options.Reentrancy.
 //
-// The callback function receives no arguments and should return an error.
-// If the callback returns nil, the success method will be called. If the callback returns an error, the failure method will be called.
+// If the callback fails because ctx itself was canceled or ran out of time, the call counts as neither a success nor a failure.
+// A client hanging up says nothing about the health of the dependency, so it should not close the Nozzle.
+// Those calls are reported by Stats as CallerCanceled, unless Options.CompatLevel is Compat1.
+// If your dependency can be slow enough to exhaust your callers' deadlines, give calls to it their own shorter timeout, so that slowness still counts as a failure.
 //
 // Example:
 //
-//	var n nozzle.Nozzle[*example]
-//
-//	res, err := n.DoError(func() (*example, error) {
-//		ex, err := someFuncThatCanFail()
-//		return ex, err
+//	res, err := n.DoErrorContext(ctx, func(ctx context.Context) (*example, error) {
+//		return someFuncThatCanFail(ctx)
 //	})
+//	if errors.Is(err, nozzle.ErrReentrant) {
+//		// a callback called back into the same nozzle.
+//	}
+//
 //	if err != nil {
 //		// handle error
 //	}
-//
-//	fmt.Print(res) // Use the result
-//
-// If the callback function does not return an error, Nozzle's behavior will be affected according to the success method.
-func (n *Nozzle[T]) DoError(callback func() (T, error)) (T, error) {
+func (n *Nozzle[T]) DoErrorContext(ctx context.Context, callback func(context.Context) (T, error)) (T, error) {
+	if n.passedThrough() {
+		return callback(ctx)
+	}
+
+	if policy, ok := n.reentered(ctx); ok {
+		switch policy {
+		case ReentrancyError:
+			return *new(T), ErrReentrant
+		case ReentrancyCountOnce:
+			return callback(ctx)
+		case ReentrancyAllow:
+		}
+	}
+
+	var res T
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		decision, ok := n.admit(ctx, 1)
+		if !ok && attempt == 1 {
+			return *new(T), n.blocked(decision)
+		}
+
+		if !ok {
+			// A blocked retry returns the failure it was retrying.
+			return res, err
+		}
+
+		var panicked bool
+
+		res, panicked, err = n.call(ctx, decision, callback)
+		if panicked {
+			return res, err
+		}
+
+		if !n.outcomeContext(ctx, err) || !n.retry(ctx, attempt, 1) {
+			return res, err
+		}
+	}
+}
+
+// admit decides whether a call made with ctx and weighing weight may proceed, records the decision, and returns its ID.
+// The call's Priority is taken from ctx, see nozzle.WithPriority.
+// Blocked calls are classified with Options.SLAImpacting, outside of the lock.
+func (n *Nozzle[T]) admit(ctx context.Context, weight int64) (uint64, bool) {
+	decision, ok := n.allow(priorityOf(ctx), weight)
+
+	// A call blocked by Options.MaxConcurrent was not shed, so it says nothing about fairness.
+	if n.options().TrackFairness && (ok || decision != 0) {
+		n.trackFairness(ctx, ok, weight)
+	}
+
+	if ok {
+		return decision, true
+	}
+
+	if n.options().SLAImpacting == nil {
+		return decision, false
+	}
+
+	impacting := n.options().SLAImpacting(ctx)
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if impacting {
+		n.totals.ShedSLAImpacting += weight
+	} else {
+		n.totals.ShedNotSLAImpacting += weight
+	}
+
+	return decision, false
+}
+
+// blocked builds the BlockedError returned for the blocked decision.
+func (n *Nozzle[T]) blocked(decision uint64) *BlockedError {
+	if n.passThrough() {
+		return &BlockedError{DecisionID: decision, Bulkhead: decision == 0, FlowRate: 100, State: Opening}
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return &BlockedError{
+		DecisionID: decision,
+		Bulkhead:   decision == 0,
+		FlowRate:   n.effectiveFlowRate(),
+		State:      n.observe().State,
+		RetryAfter: n.retryAfter(),
+	}
+}
+
+// retryAfter estimates how long until the Nozzle next decides whether to open.
+// See BlockedError.RetryAfter.
+// The caller must hold the lock.
+func (n *Nozzle[T]) retryAfter() time.Duration {
+	if n.forced() != "" {
+		if n.overrideUntil.IsZero() {
+			return 0
+		}
+
+		return n.overrideUntil.Sub(n.now())
+	}
+
+	interval := n.options().Interval
+	if interval <= 0 {
+		return 0
+	}
+
+	// The next decision is made at the end of the current interval.
+	next := max(interval-n.since(n.start), 0)
+
+	if n.coolingDown() {
+		// While cooling down, the Nozzle only opens at the first interval that ends after the cooldown.
+		if cooldown := n.options().ReopenCooldown - n.since(n.closedAt); cooldown > next {
+			next += (cooldown - next + interval - 1) / interval * interval
+		}
+	}
+
+	return next
+}
+
+// allow decides whether a call of priority p may proceed, records the decision, and returns its ID.
+// The engine decides, unless a manual override is active.
+// A call blocked by Options.MaxConcurrent is not a decision, and returns the ID 0.
+func (n *Nozzle[T]) allow(p Priority, weight int64) (uint64, bool) {
 	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if n.closed && n.options().ClosedPanic {
+		panic(fmt.Errorf("%w: call made after Close", ErrClosed))
+	}
 
-	var allowRate int64
+	if n.options().MaxConcurrent > 0 && n.inFlight >= n.options().MaxConcurrent {
+		n.bulkheadBlocked++
+		n.totals.BulkheadBlocked++
 
-	if n.allowed != 0 {
-		allowRate = int64((float64(n.allowed) / float64(n.allowed+n.blocked)) * 100)
+		return 0, false
 	}
 
+	n.decisions++
+	n.units += weight
+
 	var allow bool
 
-	if n.flowRate == 100 {
+	switch n.forced() {
+	case ForcedOpen:
 		allow = true
-	} else if n.flowRate > 0 {
-		allow = allowRate < n.flowRate
+		n.engine.TallyN(allow, weight)
+	case ForcedClosed:
+		allow = false
+		n.engine.TallyN(allow, weight)
+	default:
+		allow = n.engine.AdmitPriority(p, weight)
 	}
 
 	if !allow {
-		n.blocked++
-		n.mut.Unlock()
+		n.totals.Blocked += weight
 
-		return *new(T), ErrBlocked
+		return n.decisions, false
 	}
 
-	n.allowed++
-	n.mut.Unlock()
+	n.totals.Allowed += weight
+	n.inFlight++
+	n.uses.Add(1)
+	n.running.Store(n.inFlight)
 
-	res, err := callback()
+	return n.decisions, true
+}
 
-	if err != nil {
-		n.failure()
-	} else {
-		n.success()
+// reentered reports whether ctx was passed in from inside one of this Nozzle's callbacks.
+// When it was, the nested call is counted and the configured policy is returned.
+func (n *Nozzle[T]) reentered(ctx context.Context) (ReentrancyPolicy, bool) {
+	if ctx.Value(reentrancyKey{nozzle: n}) == nil {
+		return ReentrancyAllow, false
 	}
 
-	return res, err
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	n.reentrant++
+
+	return n.options().Reentrancy, true
 }
 
 // calculate updates the Nozzle's state based on the elapsed time and failure rate.
-// It determines whether to open or close the Nozzle and triggers the ticker if necessary.
+// It determines whether to open or close the Nozzle and releases any callers waiting for the tick.
 func (n *Nozzle[T]) calculate() {
+	n.ending.Add(1)
+	defer n.ending.Add(-1)
+
+	n.refreshMaintenance()
+
 	n.mut.Lock()
 	defer n.mut.Unlock()
 
-	if time.Since(n.start) < n.Options.Interval {
+	if n.closed || n.since(n.start) < n.options().Interval {
 		return
 	}
 
-	originalFlowRate := n.flowRate
-	originalState := n.state
+	// Runs before the deferred Unlock, so with the lock held.
+	defer n.measure(time.Now())
 
-	if n.failureRate() > n.Options.AllowedFailurePercent {
-		n.close()
-		n.state = Closing
-	} else {
-		n.open()
-		n.state = Opening
+	// The previous interval end took too long, so skip the work the Nozzle can do without.
+	optional := !n.overBudget
+
+	originalFlowRate := n.engine.FlowRate()
+	originalState := n.engine.State()
+
+	stats := n.intervalStats()
+	maintained := n.maintain(&stats)
+
+	n.record(stats)
+	n.observeHealth(stats)
+
+	if stats.FlowRate < 100 {
+		n.totals.ReducedFlowTime += stats.End.Sub(stats.Start)
+	}
+
+	if optional && !stats.Maintenance {
+		n.decay(stats)
+	}
+
+	if n.options().OnIntervalEnd != nil {
+		n.pending = append(n.pending, stats)
+	}
+
+	n.trim()
+
+	var violations []error
+	if optional {
+		violations = n.checkInvariants()
+	}
+
+	if maintained != nil {
+		flowRate := n.effectiveFlowRate()
+
+		// Need to unlock so OnEvent and Audit can call public methods.
+		n.mut.Unlock()
+
+		n.emit(*maintained)
+		n.audit(ActorNozzle, *maintained, flowRate)
+
+		n.mut.Lock()
+	}
+
+	if n.override != "" && n.forced() == "" {
+		ended := n.endOverride()
+		flowRate := n.effectiveFlowRate()
+
+		// Need to unlock so OnEvent and Audit can call public methods.
+		n.mut.Unlock()
+
+		n.emit(ended)
+		n.audit(ActorNozzle, ended, flowRate)
+
+		n.mut.Lock()
+	}
+
+	if n.forced() == "" {
+		if n.options().QueueDepth != nil {
+			// Need to unlock so QueueDepth can call public methods.
+			n.mut.Unlock()
+
+			depth := n.options().QueueDepth()
+
+			n.mut.Lock()
+
+			if depth > n.options().MaxQueueDepth {
+				n.engine.Overload(fmt.Sprintf("queue depth %d > %d", depth, n.options().MaxQueueDepth))
+			}
+		}
+
+		if n.options().Cluster.Transport != nil {
+			n.exchange()
+		}
+
+		n.adapt()
 	}
 
+	snapshot := n.snapshot()
+	n.store(snapshot)
+
 	var changed bool
 
-	if n.flowRate != originalFlowRate {
+	if n.engine.FlowRate() != originalFlowRate {
 		changed = true
 	}
 
-	if n.state != originalState {
+	if n.engine.State() != originalState {
 		changed = true
+		n.totals.StateChanges++
+	}
+
+	if changed && n.options().Audit != nil {
+		e := Event{
+			Type:   EventFlowRateChanged,
+			Time:   n.now(),
+			State:  snapshot.State,
+			Reason: snapshot.Reason,
+		}
+
+		// Need to unlock so Audit can call public methods.
+		n.mut.Unlock()
+
+		n.audit(ActorNozzle, e, snapshot.FlowRate)
+
+		n.mut.Lock()
 	}
 
-	if changed && n.Options.OnStateChange != nil {
+	if changed && n.options().OnStateChange != nil {
 		// Need to unlock so OnStateChange can call public methods.
 		n.mut.Unlock()
 
-		n.Options.OnStateChange(n)
+		n.options().OnStateChange(n)
 
 		n.mut.Lock()
 	}
 
-	n.reset()
+	if hook := n.edge(snapshot.FlowRate); hook != nil {
+		// Need to unlock so OnFullyClosed and OnFullyOpened can call public methods.
+		n.mut.Unlock()
 
-	if n.ticker != nil {
-		select {
-		case n.ticker <- struct{}{}:
-		default:
+		hook()
+
+		n.mut.Lock()
+	}
+
+	if len(violations) > 0 {
+		// Need to unlock so OnInvariantViolation can call public methods.
+		n.mut.Unlock()
+
+		for _, violation := range violations {
+			n.options().OnInvariantViolation(violation)
 		}
+
+		n.mut.Lock()
 	}
-}
 
-// close reduces the flow rate and increases the multiplier to speed up the closing process.
-// It is called when the failure rate exceeds the allowed threshold.
-func (n *Nozzle[T]) close() {
-	mult := n.decreaseBy
-	if mult > -1 {
-		mult = -1
+	n.expireReservations()
+	n.tune()
+	n.reset()
+
+	if len(n.pending) > 0 {
+		// Need to unlock so OnIntervalEnd can call public methods.
+		n.mut.Unlock()
+
+		n.deliver() //nolint:errcheck // undelivered intervals are retried at the end of the next interval.
+
+		n.mut.Lock()
 	}
 
-	n.flowRate = clamp(n.flowRate + mult)
-	n.decreaseBy = (mult * 2)
+	n.release(snapshot)
 }
 
-// open increases the flow rate and doubles the multiplier to speed up the opening process.
-// It is called when the failure rate is within the allowed threshold.
-func (n *Nozzle[T]) open() {
-	if n.flowRate == 100 {
+// adapt ends the current interval in the engine, which moves the Nozzle's state and flow rate.
+// While the Nozzle is paused or in maintenance, or a fully closed Nozzle is cooling down, the engine holds it instead.
+func (n *Nozzle[T]) adapt() {
+	if n.paused {
+		n.engine.Hold(n.engine.State(), "paused")
+
+		return
+	}
+
+	if n.maintenance != "" {
+		n.engine.Hold(n.engine.State(), "maintenance: "+n.maintenance)
+
+		return
+	}
+
+	if n.coolingDown() {
+		remaining := n.options().ReopenCooldown - n.since(n.closedAt)
+		n.engine.Hold(Closing, fmt.Sprintf("reopen cooldown, %s remaining", remaining.Round(time.Millisecond)))
+
 		return
 	}
 
-	mult := n.decreaseBy
-	if mult < 1 {
-		mult = 1
+	previous := n.engine.FlowRate()
+
+	n.engine.Adapt()
+	n.warmUp()
+
+	if n.failureTrace != "" && n.engine.State() == Closing {
+		n.engine.Annotate("failing trace " + n.failureTrace)
+	}
+
+	if n.engine.FlowRate() == 0 && previous != 0 {
+		n.closedAt = n.now()
+	}
+}
+
+// coolingDown reports whether a fully closed Nozzle must wait before opening again.
+// See Options.ReopenCooldown.
+func (n *Nozzle[T]) coolingDown() bool {
+	if n.engine.FlowRate() != 0 || n.options().ReopenCooldown <= 0 || n.closedAt.IsZero() {
+		return false
 	}
 
-	n.flowRate = clamp(n.flowRate + mult)
-	n.decreaseBy = mult * 2
+	return n.since(n.closedAt) < n.options().ReopenCooldown
 }
 
 // reset reinitializes the Nozzle's state for the next interval.
-// It sets the start time to now and clears the counters for successes, failures, allowed, and blocked operations.
+// It sets the start time to now and clears the engine's counters for successes, failures, allowed, and blocked operations.
 func (n *Nozzle[T]) reset() {
-	n.start = time.Now()
-	n.successes = 0
-	n.failures = 0
-	n.allowed = 0
-	n.blocked = 0
+	n.start = n.now()
+	n.intervals++
+	n.decisionsAtStart = n.decisions
+	n.unitsAtStart = n.units
+	n.failureTrace = ""
+	n.retries = 0
+	n.bulkheadBlocked = 0
+
+	// Outcomes during maintenance were planned, so they must not weigh on the next decisions.
+	if n.maintenance != "" {
+		n.engine.Skip()
+	} else {
+		n.engine.Reset()
+	}
 }
 
-// success increments the count of successful operations.
+// success increments the count of successful operations by weight.
 // This contributes to calculating the success rate.
-func (n *Nozzle[T]) success() {
+func (n *Nozzle[T]) success(weight int64) {
 	n.mut.Lock()
 	defer n.mut.Unlock()
 
-	n.successes++
+	n.engine.Record(weight, 0)
+	n.totals.Successes += weight
+	n.finished()
 }
 
-// failure increments the count of failed operations.
+// failure increments the count of failed operations by weight.
 // This contributes to calculating the failure rate.
-func (n *Nozzle[T]) failure() {
+func (n *Nozzle[T]) failure(weight int64) {
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	n.engine.Record(0, weight)
+	n.totals.Failures += weight
+	n.finished()
+}
+
+// finished frees the Options.MaxConcurrent slot of a call that is no longer running.
+// The caller must hold the lock.
+func (n *Nozzle[T]) finished() {
+	if n.inFlight == 0 {
+		n.totals.UnmatchedReports++
+
+		return
+	}
+
+	n.inFlight--
+	n.uses.Add(1)
+	n.running.Store(n.inFlight)
+}
+
+// outcome records the result of a call that weighs weight and returned err, and reports whether it was a failure.
+// Options.IsFailure decides whether a non-nil err is a failure.
+func (n *Nozzle[T]) outcome(err error, weight int64) bool {
+	if err != nil && (n.options().IsFailure == nil || n.options().IsFailure(err)) {
+		n.failure(weight)
+
+		return true
+	}
+
+	n.success(weight)
+
+	return false
+}
+
+// outcomeContext is like outcome, but it ignores err when it was caused by the caller's ctx being done.
+func (n *Nozzle[T]) outcomeContext(ctx context.Context, err error) bool {
+	if cause := ctx.Err(); cause != nil && errors.Is(err, cause) && n.compatLevel() >= Compat2 {
+		n.mut.Lock()
+		defer n.mut.Unlock()
+
+		n.totals.CallerCanceled++
+		n.finished()
+
+		return false
+	}
+
+	if !n.outcome(err, 1) {
+		return false
+	}
+
+	n.traceFailure(ctx)
+
+	return true
+}
+
+// traceFailure remembers the trace of a failed call made with ctx, as a representative failure of the current interval.
+// See Options.TraceID.
+func (n *Nozzle[T]) traceFailure(ctx context.Context) {
+	if n.options().TraceID == nil {
+		return
+	}
+
+	id := n.options().TraceID(ctx)
+	if id == "" {
+		return
+	}
+
 	n.mut.Lock()
 	defer n.mut.Unlock()
 
-	n.failures++
+	n.failureTrace = id
 }
 
 // FlowRate reports the current flow rate.
 // The flow rate determines how many calls will be allowed.
 // Example: A flow rate of 100 will allow all calls, while a flow rate of 50 will allow 50% of calls.
+//
+// While a manual override is active, it reports 100 for ForcedOpen and 0 for ForcedClosed.
 func (n *Nozzle[T]) FlowRate() int64 {
+	if n.passThrough() {
+		return 100
+	}
+
 	n.mut.RLock()
 	defer n.mut.RUnlock()
 
-	return n.flowRate
-}
-
-// failureRate calculates the percentage of failed operations out of the total operations.
-// Example: With 500 failures and 500 successes, the failure rate will be 50%.
-func (n *Nozzle[T]) failureRate() int64 {
-	if n.failures == 0 && n.successes == 0 {
-		return 0
-	}
-
-	// Ex: 500 failures, 500 successes
-	// (500 / (500 + 500)) * 100 = 50
-	return int64((float64(n.failures) / float64(n.failures+n.successes)) * 100)
+	return n.effectiveFlowRate()
 }
 
 // SuccessRate reports the success rate of Nozzle calls.
 // It calculates the percentage of successful operations out of the total operations.
 // Example: With 90 successes and 10 failures, the success rate will be 90%.
 func (n *Nozzle[T]) SuccessRate() int64 {
-	n.mut.RLock()
-	defer n.mut.RUnlock()
-
-	if n.flowRate == 0 {
-		return 0
-	}
-
-	if n.failures == 0 && n.successes == 0 {
+	if n.passThrough() {
 		return 100
 	}
 
-	return 100 - n.failureRate()
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return n.observe().SuccessRate
 }
 
 // FailureRate reports the failure rate of Nozzle calls.
 // It calculates the percentage of failed operations out of the total operations.
 // Example: With 10 failures and 90 successes, the failure rate will be 10%.
 func (n *Nozzle[T]) FailureRate() int64 {
-	n.mut.RLock()
-	defer n.mut.RUnlock()
-
-	if n.flowRate == 0 {
+	if n.passThrough() {
 		return 0
 	}
 
-	if n.failures == 0 && n.successes == 0 {
-		return 0
-	}
+	n.mut.RLock()
+	defer n.mut.RUnlock()
 
-	return n.failureRate()
+	return n.observe().FailureRate
 }
 
 // State reports the current state of the Nozzle.
 // It reflects whether the Nozzle is currently in the process of opening or closing.
 // Example: If the Nozzle is increasing its flow rate, the state will be Opening.
+//
+// While a manual override is active, it reports ForcedOpen or ForcedClosed.
 func (n *Nozzle[T]) State() State {
+	if n.passThrough() {
+		return Opening
+	}
+
 	n.mut.RLock()
 	defer n.mut.RUnlock()
 
-	return n.state
+	return n.observe().State
 }
 
-// Wait blocks until the Nozzle processes the next tick.
-// This is useful for testing but should be avoided in production code.
-func (n *Nozzle[T]) Wait() {
-	n.mut.Lock()
+// Reason explains the Nozzle's most recent decision, including the comparison that decided its State.
+// Example: "failure rate 30% > 20%" while closing, or "failure rate 10% <= 20%" while opening.
+//
+// It is empty until the first interval ends.
+// While a manual override is active, it reports the reason given for the override.
+func (n *Nozzle[T]) Reason() string {
+	if n.passThrough() {
+		return ""
+	}
 
-	if n.ticker == nil {
-		n.ticker = make(chan struct{})
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return n.observe().Reason
+}
+
+// Stats reports cumulative counters since the Nozzle was created.
+// Example: After 70 allowed and 30 blocked calls across any number of intervals, Allowed will be 70 and Blocked will be 30.
+func (n *Nozzle[T]) Stats() Stats {
+	if n.passThrough() {
+		return Stats{}
 	}
 
-	n.mut.Unlock()
+	n.mut.RLock()
+	defer n.mut.RUnlock()
 
-	<-n.ticker
+	return n.totals
 }
 
-// clamp constrains the flowRate to be within the range [0, 100].
-// It ensures the flowRate stays within valid bounds to prevent unexpected behavior.
-func clamp(i int64) int64 {
-	if i < 0 {
+// ReentrantCalls reports how many nested calls have been detected since the Nozzle was created.
+// A nested call is a call made with the context passed to one of this Nozzle's callbacks.
+// Example: A steadily increasing value means some callback is accidentally calling back into its own Nozzle.
+func (n *Nozzle[T]) ReentrantCalls() int64 {
+	if n.passThrough() {
 		return 0
 	}
 
-	if i > 100 {
-		return 100
-	}
+	n.mut.RLock()
+	defer n.mut.RUnlock()
 
-	return i
+	return n.reentrant
+}
+
+// Wait blocks until the Nozzle processes the next tick, or until it is closed.
+// This is useful for testing but should be avoided in production code.
+// See nozzle.WaitSnapshot to learn what the tick decided.
+func (n *Nozzle[T]) Wait() {
+	n.WaitSnapshot(context.Background()) //nolint:errcheck // Wait only cares that the tick happened.
 }
@@ -1,6 +1,7 @@
 package nozzle_test
 
 import (
+	"runtime"
 	"testing"
 	"time"
 
@@ -96,3 +97,61 @@ func BenchmarkNozzle_DoBool_Control(b *testing.B) {
 		continue
 	}
 }
+
+func BenchmarkNozzle_Footprint(b *testing.B) {
+	benchmarkFootprint(b, nil)
+}
+
+func BenchmarkNozzle_Footprint_Scheduler(b *testing.B) {
+	scheduler := nozzle.NewScheduler(time.Second)
+	defer scheduler.Stop()
+
+	benchmarkFootprint(b, scheduler)
+}
+
+// benchmarkFootprint reports the memory held by each new Nozzle, including its goroutine's stack.
+func benchmarkFootprint(b *testing.B, scheduler *nozzle.Scheduler) {
+	b.Helper()
+
+	nozzles := make([]*nozzle.Nozzle[any], b.N)
+
+	var before, after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	for i := range nozzles {
+		nozzles[i] = nozzle.New(nozzle.Options[any]{
+			Interval:              time.Second,
+			AllowedFailurePercent: 50,
+			Scheduler:             scheduler,
+		})
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	used := int64(after.HeapAlloc+after.StackInuse) - int64(before.HeapAlloc+before.StackInuse)
+	b.ReportMetric(float64(used)/float64(b.N), "bytes/nozzle")
+
+	for _, n := range nozzles {
+		n.Close()
+	}
+}
+
+// BenchmarkNozzle_TickerOverhead shows how much a Nozzle's own ticker slows its calls down at small intervals.
+// Intervals below nozzle.MinInterval are raised to it, so the smallest case runs at MinInterval.
+func BenchmarkNozzle_TickerOverhead(b *testing.B) {
+	for _, interval := range []time.Duration{time.Nanosecond, time.Millisecond, 10 * time.Millisecond, time.Second} {
+		b.Run(interval.String(), func(b *testing.B) {
+			noz := nozzle.New(nozzle.Options[any]{Interval: interval, AllowedFailurePercent: 50})
+			defer noz.Close() //nolint:errcheck
+
+			for i := 0; i < b.N; i++ {
+				noz.DoBool(func() (any, bool) {
+					return nil, true
+				})
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package nozzle_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -315,6 +316,9 @@ func TestNozzleDoBoolBlackbox(t *testing.T) { //nolint:tparallel // sub-tests sh
 	noz := nozzle.New(nozzle.Options[any]{
 		Interval:              time.Second,
 		AllowedFailurePercent: 50,
+		OnInvariantViolation: func(err error) {
+			t.Error(err)
+		},
 	})
 
 	if fr := noz.FlowRate(); fr != 100 {
@@ -382,6 +386,9 @@ func TestNozzleDoErrorBlackbox(t *testing.T) { //nolint:tparallel // sub-tests s
 	noz := nozzle.New(nozzle.Options[any]{
 		Interval:              time.Second,
 		AllowedFailurePercent: 50,
+		OnInvariantViolation: func(err error) {
+			t.Error(err)
+		},
 	})
 
 	if fr := noz.FlowRate(); fr != 100 {
@@ -460,3 +467,37 @@ func within(a, b int64) (int64, bool) {
 
 	return 0, true
 }
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[string]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	if err := noz.Do(func() error { return nil }); err != nil {
+		t.Errorf("Expected err=nil Got=%v", err)
+	}
+
+	if err := noz.Do(func() error { return ErrNotAllowed }); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Expected err=%v Got=%v", ErrNotAllowed, err)
+	}
+
+	if err := noz.DoContext(context.Background(), func(context.Context) error { return ErrNotAllowed }); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Expected err=%v Got=%v", ErrNotAllowed, err)
+	}
+
+	if s := noz.Stats(); s.Successes != 1 || s.Failures != 2 {
+		t.Errorf("Expected Successes=1 Failures=2 Got Successes=%d Failures=%d", s.Successes, s.Failures)
+	}
+
+	noz.ForceClose("test")
+
+	var ran bool
+
+	if err := noz.DoContext(context.Background(), func(context.Context) error { ran = true; return nil }); !errors.Is(err, nozzle.ErrBlocked) || ran {
+		t.Errorf("Expected err=%v without running the callback Got=%v ran=%t", nozzle.ErrBlocked, err, ran)
+	}
+}
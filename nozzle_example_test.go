@@ -1,6 +1,8 @@
 package nozzle_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -55,6 +57,39 @@ func ExampleNozzle_DoBool() {
 	// Result=1 OK=true Success=50 Failure=50
 }
 
+func ExampleNozzle_DoBool2() {
+	noz := nozzle.New(nozzle.Options[int]{
+		Interval:              time.Millisecond * 50,
+		AllowedFailurePercent: 0,
+	})
+
+	for noz.FlowRate() > 0 {
+		noz.DoBool(func() (int, bool) {
+			return 0, false
+		})
+
+		noz.Wait()
+	}
+
+	res, ok, blocked := noz.DoBool2(func() (int, bool) {
+		return 1, true
+	})
+
+	fmt.Printf("Result=%d OK=%v Blocked=%v\n", res, ok, blocked)
+
+	noz.Wait()
+
+	res, ok, blocked = noz.DoBool2(func() (int, bool) {
+		return 1, false
+	})
+
+	fmt.Printf("Result=%d OK=%v Blocked=%v\n", res, ok, blocked)
+
+	// Output:
+	// Result=0 OK=false Blocked=true
+	// Result=1 OK=false Blocked=false
+}
+
 func ExampleNozzle_DoError() {
 	noz := nozzle.New(nozzle.Options[string]{
 		Interval:              time.Millisecond * 100,
@@ -233,3 +268,170 @@ func ExampleOptions() {
 	// Success Rate: 100
 	// Flow Rate: 100
 }
+
+func ExampleOptions_bestEffort() {
+	errUnavailable := errors.New("analytics unavailable")
+
+	// Failures are tolerated, but at most one call runs at a time.
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:       time.Second,
+		DisableClosing: true,
+		MaxConcurrent:  1,
+	})
+
+	for range 10 {
+		_, _ = noz.DoError(func() (any, error) {
+			return nil, errUnavailable
+		})
+	}
+
+	noz.Wait()
+
+	fmt.Printf("Flow Rate: %d\n", noz.FlowRate())
+
+	// Hold the only slot, as a slow call would.
+	noz.Allow()
+
+	_, err := noz.DoError(func() (any, error) {
+		return nil, nil
+	})
+
+	fmt.Printf("Blocked: %t\n", errors.Is(err, nozzle.ErrBlocked))
+
+	// Output:
+	// Flow Rate: 100
+	// Blocked: true
+}
+
+// dependency simulates a service that fails every call while it is down.
+type dependency struct {
+	down bool
+}
+
+func (d *dependency) call() (any, error) {
+	if d.down {
+		return nil, errors.New("unavailable")
+	}
+
+	return nil, nil
+}
+
+// simulate makes calls through noz for one second of simulated time, then ends the interval.
+// It reports how many calls were allowed.
+func simulate(noz *nozzle.Nozzle[any], clock *nozzle.ManualClock, dep *dependency, calls int) int {
+	var allowed int
+
+	for range calls {
+		if _, err := noz.DoError(dep.call); !errors.Is(err, nozzle.ErrBlocked) {
+			allowed++
+		}
+	}
+
+	clock.Advance(time.Second)
+	noz.Tick()
+
+	return allowed
+}
+
+// simulatedOptions are the Options of a Nozzle that only moves when simulate drives it.
+func simulatedOptions(clock *nozzle.ManualClock) nozzle.Options[any] {
+	return nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+	}
+}
+
+// A dependency that goes down closes the Nozzle, and once it comes back, the Nozzle reopens.
+// The clock is simulated, so every interval ends exactly when the example says so.
+func Example_closingAndRecovery() {
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	noz := nozzle.New(simulatedOptions(clock))
+	defer noz.Close() //nolint:errcheck
+
+	dep := &dependency{down: true}
+
+	for second := range 7 {
+		allowed := simulate(noz, clock, dep, 100)
+		fmt.Printf("second=%d allowed=%d state=%s flowRate=%d\n", second, allowed, noz.State(), noz.FlowRate())
+	}
+
+	dep.down = false
+
+	for second := 7; second < 15; second++ {
+		allowed := simulate(noz, clock, dep, 100)
+		fmt.Printf("second=%d allowed=%d state=%s flowRate=%d\n", second, allowed, noz.State(), noz.FlowRate())
+	}
+
+	// Output:
+	// second=0 allowed=100 state=closing flowRate=99
+	// second=1 allowed=99 state=closing flowRate=97
+	// second=2 allowed=97 state=closing flowRate=93
+	// second=3 allowed=93 state=closing flowRate=85
+	// second=4 allowed=85 state=closing flowRate=69
+	// second=5 allowed=69 state=closing flowRate=37
+	// second=6 allowed=37 state=closing flowRate=0
+	// second=7 allowed=0 state=opening flowRate=1
+	// second=8 allowed=1 state=opening flowRate=3
+	// second=9 allowed=3 state=opening flowRate=7
+	// second=10 allowed=7 state=opening flowRate=15
+	// second=11 allowed=15 state=opening flowRate=31
+	// second=12 allowed=31 state=opening flowRate=63
+	// second=13 allowed=63 state=opening flowRate=100
+	// second=14 allowed=100 state=opening flowRate=100
+}
+
+// As the flow rate drops, low priority calls are shed first, and critical calls last.
+func Example_priorities() {
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	noz := nozzle.New(simulatedOptions(clock))
+	defer noz.Close() //nolint:errcheck
+
+	dep := &dependency{down: true}
+
+	for noz.FlowRate() > 50 {
+		simulate(noz, clock, dep, 100)
+	}
+
+	fmt.Printf("flowRate=%d\n", noz.FlowRate())
+
+	for _, priority := range []nozzle.Priority{nozzle.PriorityLow, nozzle.PriorityNormal, nozzle.PriorityCritical} {
+		ctx := nozzle.WithPriority(context.Background(), priority)
+
+		var allowed int
+
+		for range 100 {
+			if _, err := noz.DoErrorContext(ctx, func(context.Context) (any, error) { return nil, nil }); err == nil {
+				allowed++
+			}
+		}
+
+		fmt.Printf("priority=%s allowed=%d\n", priority, allowed)
+	}
+
+	// Output:
+	// flowRate=37
+	// priority=low allowed=0
+	// priority=normal allowed=37
+	// priority=critical allowed=74
+}
+
+// An operator can override the Nozzle, for example during an incident, and hand control back afterwards.
+func Example_overrides() {
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	noz := nozzle.New(simulatedOptions(clock))
+	defer noz.Close() //nolint:errcheck
+
+	dep := &dependency{}
+
+	noz.ForceClose("database failover")
+	fmt.Printf("allowed=%d state=%s\n", simulate(noz, clock, dep, 10), noz.State())
+
+	noz.Unforce()
+	fmt.Printf("allowed=%d state=%s\n", simulate(noz, clock, dep, 10), noz.State())
+
+	// Output:
+	// allowed=0 state=forced-closed
+	// allowed=10 state=opening
+}
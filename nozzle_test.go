@@ -1,12 +1,38 @@
 package nozzle //nolint:testpackage // meant to NOT be a blackbox test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/justindfuller/nozzle/engine"
 )
 
+// newTestNozzle creates a Nozzle at flowRate without starting its tick goroutine, so tests can call calculate directly.
+func newTestNozzle(options Options[any], flowRate int64) *Nozzle[any] {
+	noz := &Nozzle[any]{
+		engine: engine.New(engineConfig(options), flowRate),
+	}
+
+	noz.config.Store(&options)
+
+	return noz
+}
+
+// configure changes the Options of a test Nozzle the same way SetInterval does: by storing a modified copy.
+func configure(noz *Nozzle[any], change func(*Options[any])) {
+	options := *noz.options()
+	change(&options)
+	noz.config.Store(&options)
+}
+
 func TestSuccessRate(t *testing.T) {
 	t.Parallel()
 
@@ -52,13 +78,9 @@ func TestSuccessRate(t *testing.T) {
 		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
 			t.Parallel()
 
-			noz := Nozzle[any]{
-				flowRate: 100,
-			}
+			noz := newTestNozzle(Options[any]{}, test.flowRate)
 
-			noz.flowRate = test.flowRate
-			noz.failures = test.failures
-			noz.successes = test.successes
+			noz.engine.Record(test.successes, test.failures)
 
 			if sr := noz.SuccessRate(); sr != test.expected {
 				t.Errorf("Expected SuccessRate=%d Got=%d", test.expected, sr)
@@ -166,3 +188,744 @@ func TestConcurrencyError(t *testing.T) {
 		t.Errorf("Expected last=2 Got=%d", last)
 	}
 }
+
+func TestReentrancy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy    ReentrancyPolicy
+		err       error
+		innerRuns int
+		allowed   int64
+	}{
+		{
+			policy:    ReentrancyAllow,
+			err:       nil,
+			innerRuns: 1,
+			allowed:   2,
+		},
+		{
+			policy:    ReentrancyCountOnce,
+			err:       nil,
+			innerRuns: 1,
+			allowed:   1,
+		},
+		{
+			policy:    ReentrancyError,
+			err:       ErrReentrant,
+			innerRuns: 0,
+			allowed:   1,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := newTestNozzle(Options[any]{Reentrancy: test.policy}, 100)
+
+			var innerRuns int
+
+			_, err := noz.DoErrorContext(context.Background(), func(ctx context.Context) (any, error) {
+				return noz.DoErrorContext(ctx, func(context.Context) (any, error) {
+					innerRuns++
+
+					return nil, nil
+				})
+			})
+
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected err=%v Got=%v", test.err, err)
+			}
+
+			if innerRuns != test.innerRuns {
+				t.Errorf("Expected innerRuns=%d Got=%d", test.innerRuns, innerRuns)
+			}
+
+			if allowed := noz.engine.Observe().Allowed; allowed != test.allowed {
+				t.Errorf("Expected allowed=%d Got=%d", test.allowed, allowed)
+			}
+
+			if rc := noz.ReentrantCalls(); rc != 1 {
+				t.Errorf("Expected ReentrantCalls=1 Got=%d", rc)
+			}
+		})
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		options  Options[any]
+		failures int64
+		expected State
+		reason   string
+	}{
+		{
+			options:  Options[any]{AllowedFailurePercent: 50},
+			failures: 60,
+			expected: Closing,
+			reason:   "failure rate 60% > 50%",
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, DisableClosing: true},
+			failures: 100,
+			expected: Opening,
+			reason:   "closing is disabled",
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, StrictMode: true},
+			failures: 1,
+			expected: Closing,
+			reason:   "failure rate 1% > 0%",
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, StrictMode: true, DisableClosing: true},
+			failures: 1,
+			expected: Opening,
+			reason:   "closing is disabled",
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, ThresholdInclusive: true},
+			failures: 50,
+			expected: Closing,
+			reason:   "failure rate 50% >= 50%",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := newTestNozzle(test.options, 100)
+
+			noz.engine.Record(100-test.failures, test.failures)
+
+			noz.calculate()
+
+			if s := noz.State(); s != test.expected {
+				t.Errorf("Expected State=%s Got=%s", test.expected, s)
+			}
+
+			if r := noz.Reason(); r != test.reason {
+				t.Errorf("Expected Reason=%q Got=%q", test.reason, r)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		options  Options[any]
+		expected []string
+	}{
+		{
+			options:  Options[any]{AllowedFailurePercent: 50},
+			expected: nil,
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 100},
+			expected: []string{"set DisableClosing"},
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 100, DisableClosing: true},
+			expected: nil,
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 0},
+			expected: []string{"set StrictMode"},
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 0, StrictMode: true},
+			expected: nil,
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 150},
+			expected: []string{"between 0 and 100", "set DisableClosing"},
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, StrictMode: true, DisableClosing: true},
+			expected: []string{"DisableClosing takes precedence"},
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, FailureSmoothing: 1.5},
+			expected: []string{"FailureSmoothing should be between 0 and 1"},
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, FailureWindow: 5, SlowFailureWindow: 5},
+			expected: []string{"SlowFailureWindow should be larger than FailureWindow"},
+		},
+		{
+			options:  Options[any]{AllowedFailurePercent: 50, FailureWindow: 5, FailureSmoothing: 0.5},
+			expected: []string{"FailureWindow takes precedence"},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			test.options.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+
+			var noz Nozzle[any]
+
+			noz.config.Store(&test.options)
+			noz.validate()
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if buf.Len() == 0 {
+				lines = nil
+			}
+
+			if len(lines) != len(test.expected) {
+				t.Fatalf("Expected %d warnings Got=%q", len(test.expected), lines)
+			}
+
+			for j, expected := range test.expected {
+				if !strings.Contains(lines[j], expected) {
+					t.Errorf("Expected warning containing %q Got=%q", expected, lines[j])
+				}
+			}
+		})
+	}
+}
+
+func TestSLAImpacting(t *testing.T) {
+	t.Parallel()
+
+	type tierKey struct{}
+
+	noz := newTestNozzle(Options[any]{
+		SLAImpacting: func(ctx context.Context) bool {
+			return ctx.Value(tierKey{}) == "paid"
+		},
+	}, 0)
+
+	paid := context.WithValue(context.Background(), tierKey{}, "paid")
+
+	for range 3 {
+		noz.DoErrorContext(paid, func(context.Context) (any, error) {
+			return nil, nil
+		})
+	}
+
+	noz.DoError(func() (any, error) {
+		return nil, nil
+	})
+
+	stats := noz.Stats()
+
+	if stats.Blocked != 4 {
+		t.Errorf("Expected Blocked=4 Got=%d", stats.Blocked)
+	}
+
+	if stats.ShedSLAImpacting != 3 {
+		t.Errorf("Expected ShedSLAImpacting=3 Got=%d", stats.ShedSLAImpacting)
+	}
+
+	if stats.ShedNotSLAImpacting != 1 {
+		t.Errorf("Expected ShedNotSLAImpacting=1 Got=%d", stats.ShedNotSLAImpacting)
+	}
+}
+
+func TestHysteresis(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		options      Options[any]
+		failureRates []int64
+		expected     []State
+	}{
+		{
+			options:      Options[any]{AllowedFailurePercent: 20},
+			failureRates: []int64{21, 19, 21, 19},
+			expected:     []State{Closing, Opening, Closing, Opening},
+		},
+		{
+			options:      Options[any]{AllowedFailurePercent: 20, HysteresisBand: 5},
+			failureRates: []int64{21, 26, 19, 15, 21, 25},
+			expected:     []State{Opening, Closing, Closing, Opening, Opening, Opening},
+		},
+		{
+			options:      Options[any]{AllowedFailurePercent: 20, MinIntervalsBeforeReverse: 2},
+			failureRates: []int64{21, 19, 19, 21, 21, 19},
+			expected:     []State{Closing, Closing, Opening, Opening, Closing, Closing},
+		},
+		{
+			options:      Options[any]{AllowedFailurePercent: 5, HysteresisBand: 10},
+			failureRates: []int64{100, 0},
+			expected:     []State{Closing, Opening},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := newTestNozzle(test.options, 100)
+
+			for j, failureRate := range test.failureRates {
+				noz.engine.Record(100-failureRate, failureRate)
+
+				noz.calculate()
+
+				if s := noz.State(); s != test.expected[j] {
+					t.Errorf("interval=%d Expected State=%s Got=%s", j, test.expected[j], s)
+				}
+			}
+		})
+	}
+}
+
+func TestReopenCooldown(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		ReopenCooldown:        time.Hour,
+	}, 1)
+
+	noz.engine.Record(0, 10)
+	noz.calculate()
+
+	if fr := noz.FlowRate(); fr != 0 {
+		t.Fatalf("Expected FlowRate=0 Got=%d", fr)
+	}
+
+	for range 3 {
+		noz.calculate()
+
+		if fr, s := noz.FlowRate(), noz.State(); fr != 0 || s != Closing {
+			t.Errorf("Expected FlowRate=0 State=%s Got FlowRate=%d State=%s", Closing, fr, s)
+		}
+	}
+
+	noz.closedAt = time.Now().Add(-time.Hour)
+	noz.calculate()
+
+	if fr, s := noz.FlowRate(), noz.State(); fr != 1 || s != Opening {
+		t.Errorf("Expected FlowRate=1 State=%s Got FlowRate=%d State=%s", Opening, fr, s)
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := errors.New("not found")
+	errUnavailable := errors.New("unavailable")
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, errNotFound)
+		},
+	}, 100)
+
+	for _, e := range []error{nil, errNotFound, errNotFound, errUnavailable} {
+		_, err := noz.DoError(func() (any, error) {
+			return nil, e
+		})
+
+		if !errors.Is(err, e) {
+			t.Errorf("Expected err=%v Got=%v", e, err)
+		}
+	}
+
+	_, _ = noz.DoErrorContext(context.Background(), func(context.Context) (any, error) {
+		return nil, fmt.Errorf("wrapped: %w", errNotFound)
+	})
+
+	if s := noz.Stats(); s.Successes != 4 || s.Failures != 1 {
+		t.Errorf("Expected Successes=4 Failures=1 Got Successes=%d Failures=%d", s.Successes, s.Failures)
+	}
+}
+
+func TestCallerCanceled(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+	}, 100)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		ctx context.Context //nolint:containedctx // each test runs with its own context.
+		err error
+	}{
+		{ctx: canceled, err: context.Canceled},
+		{ctx: canceled, err: fmt.Errorf("request failed: %w", context.Canceled)},
+		{ctx: canceled, err: errUnavailable},
+		{ctx: context.Background(), err: context.Canceled},
+		{ctx: context.Background(), err: nil},
+	}
+
+	for _, test := range tests {
+		_, err := noz.DoErrorContext(test.ctx, func(context.Context) (any, error) {
+			return nil, test.err
+		})
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("Expected err=%v Got=%v", test.err, err)
+		}
+	}
+
+	s := noz.Stats()
+
+	if s.CallerCanceled != 2 || s.Failures != 2 || s.Successes != 1 {
+		t.Errorf("Expected CallerCanceled=2 Failures=2 Successes=1 Got CallerCanceled=%d Failures=%d Successes=%d", s.CallerCanceled, s.Failures, s.Successes)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	t.Parallel()
+
+	cb := NewBreaker(newTestNozzle(Options[any]{AllowedFailurePercent: 50}, 30))
+
+	if s := cb.State(); s != BreakerHalfOpen {
+		t.Errorf("Expected State=%s Got=%s", BreakerHalfOpen, s)
+	}
+}
+
+func TestDoBoolN(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{AllowedFailurePercent: 50}, 50)
+
+	// The batch is allowed and counts as 10, so the next 11 calls are blocked to bring the share allowed below 50.
+	noz.DoBoolN(10, func() (any, bool) {
+		return nil, false
+	})
+
+	for range 11 {
+		noz.DoBool(func() (any, bool) {
+			return nil, true
+		})
+	}
+
+	noz.DoBoolN(0, func() (any, bool) {
+		return nil, true
+	})
+
+	s := noz.Stats()
+
+	if s.Allowed != 11 || s.Blocked != 11 {
+		t.Errorf("Expected Allowed=11 Blocked=11 Got Allowed=%d Blocked=%d", s.Allowed, s.Blocked)
+	}
+
+	if s.Successes != 1 || s.Failures != 10 {
+		t.Errorf("Expected Successes=1 Failures=10 Got Successes=%d Failures=%d", s.Successes, s.Failures)
+	}
+
+	if fr := noz.FailureRate(); fr != 90 {
+		t.Errorf("Expected FailureRate=90 Got=%d", fr)
+	}
+}
+
+func TestQueueDepth(t *testing.T) {
+	t.Parallel()
+
+	var depth atomic.Int64
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		QueueDepth:            depth.Load,
+		MaxQueueDepth:         100,
+	}, 100)
+
+	tests := []struct {
+		depth int64
+		state State
+	}{
+		{depth: 100, state: Opening},
+		{depth: 101, state: Closing},
+		{depth: 50, state: Opening},
+	}
+
+	for i, test := range tests {
+		depth.Store(test.depth)
+
+		noz.DoBool(func() (any, bool) {
+			return nil, true
+		})
+		noz.calculate()
+
+		if s := noz.State(); s != test.state {
+			t.Errorf("interval=%d Expected State=%s Got=%s (%s)", i, test.state, s, noz.Reason())
+		}
+	}
+}
+
+func TestBlockedError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		prepare    func(noz *Nozzle[any])
+		flowRate   int64
+		state      State
+		retryAfter time.Duration
+	}{
+		{
+			prepare:    func(*Nozzle[any]) {},
+			flowRate:   0,
+			state:      Opening,
+			retryAfter: time.Minute,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				configure(noz, func(options *Options[any]) { options.ReopenCooldown = 150 * time.Second })
+				noz.closedAt = noz.start
+			},
+			flowRate:   0,
+			state:      Opening,
+			retryAfter: 3 * time.Minute,
+		},
+		{
+			prepare: func(noz *Nozzle[any]) {
+				noz.ForceCloseFor(time.Hour, "maintenance")
+			},
+			flowRate:   0,
+			state:      ForcedClosed,
+			retryAfter: time.Hour,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := newTestNozzle(Options[any]{
+				Interval:              time.Minute,
+				AllowedFailurePercent: 50,
+			}, 0)
+			noz.start = time.Now()
+
+			test.prepare(noz)
+
+			_, err := noz.DoError(func() (any, error) {
+				return nil, nil
+			})
+
+			var blocked *BlockedError
+			if !errors.As(err, &blocked) {
+				t.Fatalf("Expected a BlockedError Got=%v", err)
+			}
+
+			if blocked.FlowRate != test.flowRate || blocked.State != test.state {
+				t.Errorf("Expected FlowRate=%d State=%s Got FlowRate=%d State=%s", test.flowRate, test.state, blocked.FlowRate, blocked.State)
+			}
+
+			// Allow for the time the test takes to run.
+			if blocked.RetryAfter > test.retryAfter || blocked.RetryAfter < test.retryAfter-time.Second {
+				t.Errorf("Expected RetryAfter=%s Got=%s", test.retryAfter, blocked.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	t.Parallel()
+
+	var events []Event
+
+	noz := New(Options[any]{
+		Interval:              time.Nanosecond,
+		AllowedFailurePercent: 50,
+		OnEvent: func(e Event) {
+			if e.Type != EventCompatLevel {
+				events = append(events, e)
+			}
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	if noz.options().Interval != MinInterval {
+		t.Errorf("Expected Interval=%s Got=%s", MinInterval, noz.options().Interval)
+	}
+
+	if len(events) != 1 || events[0].Type != EventIntervalClamped || events[0].Duration != MinInterval {
+		t.Errorf("Expected one %s event with Duration=%s Got=%+v", EventIntervalClamped, MinInterval, events)
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	t.Parallel()
+
+	type traceKey struct{}
+
+	errUnavailable := errors.New("unavailable")
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		TraceID: func(ctx context.Context) string {
+			id, _ := ctx.Value(traceKey{}).(string)
+
+			return id
+		},
+	}, 100)
+
+	for _, id := range []string{"trace-1", "trace-2", ""} {
+		_, _ = noz.DoErrorContext(context.WithValue(context.Background(), traceKey{}, id), func(context.Context) (any, error) {
+			return nil, errUnavailable
+		})
+	}
+
+	noz.calculate()
+
+	if r := noz.Reason(); !strings.HasSuffix(r, ", failing trace trace-2") {
+		t.Errorf("Expected Reason to end with the last failing trace Got=%q", r)
+	}
+
+	// Opening decisions are not annotated, and the trace does not carry over to the next interval.
+	noz.DoBool(func() (any, bool) {
+		return nil, true
+	})
+	noz.calculate()
+
+	if r := noz.Reason(); strings.Contains(r, "trace") {
+		t.Errorf("Expected no trace in Reason=%q", r)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	t.Parallel()
+
+	noz := New(Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		MaxConcurrent:         2,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	release := make(chan struct{})
+	running := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		_, _ = noz.DoError(func() (any, error) {
+			running <- struct{}{}
+			<-release
+
+			return nil, nil
+		})
+	}()
+
+	<-running
+
+	// A reservation holds its slot until it ends.
+	r := noz.Reserve()
+	if !r.OK() {
+		t.Fatal("Expected the second call to be allowed")
+	}
+
+	_, err := noz.DoError(func() (any, error) {
+		t.Error("Expected the callback not to run while the bulkhead is full")
+
+		return nil, nil
+	})
+
+	var blocked *BlockedError
+	if !errors.As(err, &blocked) || !blocked.Bulkhead {
+		t.Errorf("Expected a BlockedError with Bulkhead=true Got=%v", err)
+	}
+
+	noz.mut.Lock()
+	s := noz.snapshot()
+	noz.mut.Unlock()
+
+	if s.InFlight != 2 || s.BulkheadBlocked != 1 || s.Blocked != 0 {
+		t.Errorf("Expected InFlight=2 BulkheadBlocked=1 Blocked=0 Got InFlight=%d BulkheadBlocked=%d Blocked=%d", s.InFlight, s.BulkheadBlocked, s.Blocked)
+	}
+
+	r.Cancel()
+	close(release)
+	wg.Wait()
+
+	if _, err := noz.DoError(func() (any, error) {
+		return nil, nil
+	}); err != nil {
+		t.Errorf("Expected err=nil once the slots are free Got=%v", err)
+	}
+
+	if stats := noz.Stats(); stats.BulkheadBlocked != 1 || stats.Blocked != 0 {
+		t.Errorf("Expected BulkheadBlocked=1 Blocked=0 Got BulkheadBlocked=%d Blocked=%d", stats.BulkheadBlocked, stats.Blocked)
+	}
+}
+
+func TestLifetimeStats(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(time.Now())
+
+	noz := newTestNozzle(Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+	}, 100)
+	noz.start = clock.Now()
+
+	for _, ok := range []bool{false, false, true} {
+		noz.DoBool(func() (any, bool) { return nil, ok })
+		clock.Advance(time.Second)
+		noz.calculate()
+	}
+
+	// Closing, closing, then opening again: two changes, and two seconds below 100.
+	stats := noz.Stats()
+
+	if stats.StateChanges != 2 {
+		t.Errorf("Expected StateChanges=2 Got=%d", stats.StateChanges)
+	}
+
+	if stats.ReducedFlowTime != 2*time.Second {
+		t.Errorf("Expected ReducedFlowTime=%s Got=%s", 2*time.Second, stats.ReducedFlowTime)
+	}
+
+	if stats.Allowed != 3 || stats.Failures != 2 || stats.Successes != 1 {
+		t.Errorf("Expected Allowed=3 Failures=2 Successes=1 Got Allowed=%d Failures=%d Successes=%d", stats.Allowed, stats.Failures, stats.Successes)
+	}
+}
+
+func TestDone(t *testing.T) {
+	t.Parallel()
+
+	scheduler := NewScheduler(time.Second)
+	defer scheduler.Stop()
+
+	verify := func(context.Context) error { return nil }
+
+	tests := []struct {
+		options Options[any]
+		done    bool
+	}{
+		{options: Options[any]{Interval: time.Hour}, done: true},
+		{options: Options[any]{Interval: time.Hour, ManualTick: true}, done: false},
+		{options: Options[any]{Interval: time.Hour, Scheduler: scheduler}, done: false},
+		{options: Options[any]{Interval: time.Hour, Scheduler: scheduler, Verify: verify}, done: true},
+	}
+
+	for i, test := range tests {
+		noz := New(test.options)
+
+		if done := noz.done != nil; done != test.done {
+			t.Errorf("test=%d Expected done=%t Got=%t", i, test.done, done)
+		}
+
+		if err := noz.Close(); err != nil {
+			t.Errorf("test=%d Expected err=nil Got=%v", i, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+// Package nozzleaudit records a Nozzle's changes to a tamper-evident file.
+//
+// Each Record includes the hash of the Record before it, so editing, removing, or reordering any Record breaks the chain from that point on.
+// Verify walks the chain to prove a file is intact.
+//
+// Example:
+//
+//	sink, err := nozzleaudit.Open("/var/log/nozzle/payments.audit")
+//	if err != nil {
+//		// handle error
+//	}
+//	defer sink.Close()
+//
+//	noz := nozzle.New(nozzle.Options[any]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//		Audit:                 sink,
+//	})
+package nozzleaudit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// ErrBrokenChain is returned when a Record's hashes do not match the Records before it.
+var ErrBrokenChain = errors.New("nozzleaudit: broken hash chain")
+
+// Record is one line of an audit file: a nozzle.AuditEntry, chained to the Record before it.
+type Record struct {
+	// Seq numbers the Record, starting at 1.
+	Seq uint64 `json:"seq"`
+
+	// Time is when the change happened, in UTC.
+	Time time.Time `json:"time"`
+
+	// Actor is nozzle.ActorNozzle or nozzle.ActorOperator.
+	Actor string `json:"actor"`
+
+	// Type is the kind of change.
+	Type nozzle.EventType `json:"type"`
+
+	// State is the state the change relates to.
+	State nozzle.State `json:"state"`
+
+	// FlowRate is the flow rate callers experience after the change.
+	FlowRate int64 `json:"flowRate"`
+
+	// Reason explains the change.
+	Reason string `json:"reason"`
+
+	// Duration is how long the change lasts, such as for an override that expires.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// PrevHash is the Hash of the previous Record, or empty for the first.
+	PrevHash string `json:"prevHash"`
+
+	// Hash is the hex-encoded SHA-256 of the Record, computed with Hash empty.
+	Hash string `json:"hash"`
+}
+
+// hash computes the Hash of r.
+func (r Record) hash() (string, error) {
+	r.Hash = ""
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("nozzleaudit: encoding record %d: %w", r.Seq, err)
+	}
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// FileSink is a nozzle.AuditSink that appends Records to a file, one JSON object per line.
+// It is safe for use by multiple goroutines, and by multiple Nozzles sharing one chain.
+type FileSink struct {
+	// mut guards the fields below, and orders writes to file.
+	mut sync.Mutex
+
+	// file is the audit file, opened for appending.
+	file *os.File
+
+	// seq is the Seq of the last Record.
+	seq uint64
+
+	// prev is the Hash of the last Record.
+	prev string
+}
+
+// Open opens the audit file at path, creating it if needed, and continues its chain.
+// It returns an error matching ErrBrokenChain if the existing Records are not intact.
+func Open(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("nozzleaudit: opening %s: %w", path, err)
+	}
+
+	last, err := verify(file)
+	if err != nil {
+		return nil, errors.Join(err, file.Close())
+	}
+
+	return &FileSink{file: file, seq: last.Seq, prev: last.Hash}, nil
+}
+
+// Audit implements nozzle.AuditSink.
+// Each Record is synced to disk before Audit returns.
+func (s *FileSink) Audit(e nozzle.AuditEntry) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	r := Record{
+		Seq:      s.seq + 1,
+		Time:     e.Time.UTC(),
+		Actor:    e.Actor,
+		Type:     e.Type,
+		State:    e.State,
+		FlowRate: e.FlowRate,
+		Reason:   e.Reason,
+		Duration: e.Duration,
+		PrevHash: s.prev,
+	}
+
+	hash, err := r.hash()
+	if err != nil {
+		return err
+	}
+
+	r.Hash = hash
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("nozzleaudit: encoding record %d: %w", r.Seq, err)
+	}
+
+	if _, err := s.file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("nozzleaudit: writing record %d: %w", r.Seq, err)
+	}
+
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("nozzleaudit: syncing record %d: %w", r.Seq, err)
+	}
+
+	s.seq, s.prev = r.Seq, r.Hash
+
+	return nil
+}
+
+// Close closes the audit file.
+func (s *FileSink) Close() error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.file.Close()
+}
+
+// Verify checks that every Record read from r is intact and in order, and returns how many there are.
+// It returns an error matching ErrBrokenChain at the first Record that is not.
+//
+// Example:
+//
+//	f, err := os.Open("/var/log/nozzle/payments.audit")
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	n, err := nozzleaudit.Verify(f)
+//	if errors.Is(err, nozzleaudit.ErrBrokenChain) {
+//		// the file was tampered with.
+//	}
+func Verify(r io.Reader) (uint64, error) {
+	last, err := verify(r)
+
+	return last.Seq, err
+}
+
+// verify checks the Records read from r, and returns the last one.
+func verify(r io.Reader) (Record, error) {
+	var last Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	for scanner.Scan() {
+		var record Record
+
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return last, fmt.Errorf("%w: record %d is not valid JSON: %w", ErrBrokenChain, last.Seq+1, err)
+		}
+
+		if record.Seq != last.Seq+1 || record.PrevHash != last.Hash {
+			return last, fmt.Errorf("%w: record %d does not follow record %d", ErrBrokenChain, record.Seq, last.Seq)
+		}
+
+		hash, err := record.hash()
+		if err != nil {
+			return last, err
+		}
+
+		if hash != record.Hash {
+			return last, fmt.Errorf("%w: record %d was modified", ErrBrokenChain, record.Seq)
+		}
+
+		last = record
+	}
+
+	if err := scanner.Err(); err != nil {
+		return last, fmt.Errorf("nozzleaudit: reading records: %w", err)
+	}
+
+	return last, nil
+}
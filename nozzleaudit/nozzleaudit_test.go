@@ -0,0 +1,111 @@
+package nozzleaudit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzleaudit"
+)
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "payments.audit")
+
+	sink, err := nozzleaudit.Open(path)
+	if err != nil {
+		t.Fatalf("Expected Open err=nil Got=%v", err)
+	}
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+		Audit:                 sink,
+	})
+
+	noz.DoBool(func() (any, bool) {
+		return nil, false
+	})
+
+	// The failure closes the Nozzle at the end of the interval.
+	if _, err := noz.WaitSnapshot(context.Background()); err != nil {
+		t.Fatalf("Expected WaitSnapshot err=nil Got=%v", err)
+	}
+
+	if err := noz.Close(); err != nil {
+		t.Fatalf("Expected Close err=nil Got=%v", err)
+	}
+
+	noz.ForceCloseFor(time.Hour, "INC-42 (alice)")
+	noz.Unforce()
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected sink Close err=nil Got=%v", err)
+	}
+
+	// Reopening continues the chain.
+	sink, err = nozzleaudit.Open(path)
+	if err != nil {
+		t.Fatalf("Expected reopen err=nil Got=%v", err)
+	}
+
+	other := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Audit:                 sink,
+	})
+	defer other.Close() //nolint:errcheck
+
+	other.Pause()
+
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Expected sink Close err=nil Got=%v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected ReadFile err=nil Got=%v", err)
+	}
+
+	if n, err := nozzleaudit.Verify(bytes.NewReader(b)); n != 4 || err != nil {
+		t.Errorf("Expected 4 intact records Got=%d err=%v", n, err)
+	}
+
+	for i, expected := range []string{
+		`"actor":"nozzle","type":"flow-rate-changed"`,
+		`"actor":"operator","type":"override-started","state":"forced-closed","flowRate":0,"reason":"INC-42 (alice)"`,
+		`"actor":"operator","type":"override-ended"`,
+		`"actor":"operator","type":"paused"`,
+	} {
+		line := bytes.Split(b, []byte("\n"))[i]
+		if !bytes.Contains(line, []byte(expected)) {
+			t.Errorf("record=%d Expected to contain %s Got=%s", i+1, expected, line)
+		}
+	}
+
+	tampered := bytes.Replace(b, []byte("INC-42 (alice)"), []byte("INC-42 (mallory)"), 1)
+
+	if n, err := nozzleaudit.Verify(bytes.NewReader(tampered)); n != 1 || !errors.Is(err, nozzleaudit.ErrBrokenChain) {
+		t.Errorf("Expected err=%v after 1 intact record Got=%d err=%v", nozzleaudit.ErrBrokenChain, n, err)
+	}
+
+	removed := bytes.Join(append(bytes.Split(b, []byte("\n"))[:1], bytes.Split(b, []byte("\n"))[2:]...), []byte("\n"))
+
+	if _, err := nozzleaudit.Verify(bytes.NewReader(removed)); !errors.Is(err, nozzleaudit.ErrBrokenChain) {
+		t.Errorf("Expected err=%v for a removed record Got=%v", nozzleaudit.ErrBrokenChain, err)
+	}
+
+	if err := os.WriteFile(path, tampered, 0o600); err != nil {
+		t.Fatalf("Expected WriteFile err=nil Got=%v", err)
+	}
+
+	if _, err := nozzleaudit.Open(path); !errors.Is(err, nozzleaudit.ErrBrokenChain) {
+		t.Errorf("Expected Open err=%v Got=%v", nozzleaudit.ErrBrokenChain, err)
+	}
+}
@@ -0,0 +1,303 @@
+// Package nozzleconfig reads the Options of named Nozzles from a JSON file, so services do not hand-map their own config structs to nozzle.Options.
+//
+// Durations are strings, such as "1s", and strategies are chosen by name. Read validates the whole file and reports every problem at once.
+// Hooks, such as OnStateChange or Logger, cannot be written in JSON; set them in the customize function of Register.
+//
+// Example file:
+//
+//	{
+//		"nozzles": {
+//			"payments": {
+//				"interval": "1s",
+//				"allowedFailurePercent": 20,
+//				"reopenCooldown": "30s",
+//				"strategy": {"name": "ramp", "open": [1], "close": [10, 50]}
+//			},
+//			"search": {
+//				"interval": "500ms",
+//				"allowedFailurePercent": 50,
+//				"strategy": {"name": "aimd", "increase": 5, "decreaseFactor": 0.5}
+//			}
+//		}
+//	}
+//
+// Example:
+//
+//	f, err := os.Open("nozzles.json")
+//	if err != nil {
+//		// handle error
+//	}
+//	defer f.Close()
+//
+//	config, err := nozzleconfig.Read(f)
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	registry := nozzle.NewRegistry[*http.Response](nozzle.RegistryOptions{})
+//
+//	err = nozzleconfig.Register(registry, config, func(name string, o *nozzle.Options[*http.Response]) {
+//		o.Logger = logger.With("nozzle", name)
+//	})
+package nozzleconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// ErrInvalid is wrapped by every validation error returned from Read.
+var ErrInvalid = errors.New("nozzleconfig: invalid config")
+
+// Strategy names, see Strategy.Name.
+const (
+	Exponential = "exponential"
+	AIMD        = "aimd"
+	PID         = "pid"
+	Ramp        = "ramp"
+)
+
+// Config is a config file: the Options of every Nozzle, by name.
+type Config struct {
+	Nozzles map[string]Nozzle `json:"nozzles"`
+}
+
+// Nozzle is the JSON form of the nozzle.Options that can be written in a file.
+// Each field has the meaning, and zero value, of the nozzle.Options field of the same name.
+type Nozzle struct {
+	Interval                  Duration `json:"interval"`
+	AllowedFailurePercent     int64    `json:"allowedFailurePercent"`
+	ThresholdInclusive        bool     `json:"thresholdInclusive"`
+	DisableClosing            bool     `json:"disableClosing"`
+	InitialFlowRate           int64    `json:"initialFlowRate"`
+	WarmUp                    Duration `json:"warmUp"`
+	WarmUpFlowRate            int64    `json:"warmUpFlowRate"`
+	HysteresisBand            int64    `json:"hysteresisBand"`
+	MinIntervalsBeforeReverse int      `json:"minIntervalsBeforeReverse"`
+	ReopenCooldown            Duration `json:"reopenCooldown"`
+	FailureWindow             int      `json:"failureWindow"`
+	SlowFailureWindow         int      `json:"slowFailureWindow"`
+	FailureSmoothing          float64  `json:"failureSmoothing"`
+	MaxConcurrent             int64    `json:"maxConcurrent"`
+	ReservationTTL            int      `json:"reservationTTL"`
+	MaxWait                   Duration `json:"maxWait"`
+	CompatLevel               int      `json:"compatLevel"`
+
+	// Strategy selects and tunes the nozzle.Strategy.
+	Strategy Strategy `json:"strategy"`
+}
+
+// Strategy is the JSON form of a nozzle.Strategy.
+// Each strategy reads only its own fields.
+type Strategy struct {
+	// Name is one of Exponential, AIMD, PID, or Ramp.
+	// If empty, Exponential is used.
+	Name string `json:"name"`
+
+	// Increase and DecreaseFactor tune AIMD, see nozzle.AIMD.
+	Increase       int64   `json:"increase"`
+	DecreaseFactor float64 `json:"decreaseFactor"`
+
+	// TargetFailurePercent, Kp, Ki, and Kd tune PID, see nozzle.PID.
+	TargetFailurePercent int64   `json:"targetFailurePercent"`
+	Kp                   float64 `json:"kp"`
+	Ki                   float64 `json:"ki"`
+	Kd                   float64 `json:"kd"`
+
+	// Open and Close are the curves of Ramp, as the deltas of a nozzle.StepCurve.
+	// Example: [1] reopens by 1% per interval.
+	// If empty, nozzle.ExponentialCurve is used.
+	Open  []int64 `json:"open"`
+	Close []int64 `json:"close"`
+}
+
+// Duration is a time.Duration written in JSON as a string, such as "1s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string, such as "1s", with time.ParseDuration.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("nozzleconfig: durations must be strings, such as \"1s\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("nozzleconfig: %w", err)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalJSON writes the duration as a string, such as "1s".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String()) //nolint:wrapcheck // marshaling a string cannot fail.
+}
+
+// Read reads and validates a Config. Unknown fields are errors, so a typo cannot silently fall back to a default.
+// Every invalid field is reported, each wrapping ErrInvalid.
+func Read(r io.Reader) (Config, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var c Config
+	if err := decoder.Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// Validate reports every invalid field of every Nozzle, each wrapping ErrInvalid.
+func (c Config) Validate() error {
+	var errs []error
+
+	for _, name := range c.names() {
+		for _, problem := range c.Nozzles[name].problems() {
+			errs = append(errs, fmt.Errorf("%w: %s: %s", ErrInvalid, name, problem))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// names returns the name of every Nozzle, sorted, so errors and registration are deterministic.
+func (c Config) names() []string {
+	names := make([]string, 0, len(c.Nozzles))
+	for name := range c.Nozzles {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// problems describes every invalid field of n.
+func (n Nozzle) problems() []string {
+	var problems []string
+
+	check := func(invalid bool, format string, args ...any) {
+		if invalid {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	percent := func(field string, value int64) {
+		check(value < 0 || value > 100, "%s must be between 0 and 100, got %d", field, value)
+	}
+
+	check(n.Interval <= 0, "interval must be positive, got %q", time.Duration(n.Interval))
+	percent("allowedFailurePercent", n.AllowedFailurePercent)
+	percent("initialFlowRate", n.InitialFlowRate)
+	percent("warmUpFlowRate", n.WarmUpFlowRate)
+	percent("hysteresisBand", n.HysteresisBand)
+	check(n.WarmUp < 0, "warmUp must not be negative")
+	check(n.ReopenCooldown < 0, "reopenCooldown must not be negative")
+	check(n.MinIntervalsBeforeReverse < 0, "minIntervalsBeforeReverse must not be negative")
+	check(n.FailureWindow < 0, "failureWindow must not be negative")
+	check(n.SlowFailureWindow < 0, "slowFailureWindow must not be negative")
+	check(n.FailureSmoothing < 0 || n.FailureSmoothing > 1, "failureSmoothing must be between 0 and 1, got %g", n.FailureSmoothing)
+	check(n.MaxConcurrent < 0, "maxConcurrent must not be negative")
+	check(n.ReservationTTL < 0, "reservationTTL must not be negative")
+	check(n.MaxWait < 0, "maxWait must not be negative")
+	check(n.CompatLevel < 0 || n.CompatLevel > int(nozzle.CurrentCompatLevel), "compatLevel must be between 0 and %d, got %d", nozzle.CurrentCompatLevel, n.CompatLevel)
+
+	s := n.Strategy
+
+	switch s.Name {
+	case "", Exponential, PID:
+	case AIMD:
+		check(s.Increase < 0, "strategy.increase must not be negative")
+		check(s.DecreaseFactor < 0 || s.DecreaseFactor > 1, "strategy.decreaseFactor must be between 0 and 1, got %g", s.DecreaseFactor)
+	case Ramp:
+		check(slices.ContainsFunc(s.Open, func(d int64) bool { return d <= 0 }), "strategy.open must only hold positive deltas")
+		check(slices.ContainsFunc(s.Close, func(d int64) bool { return d <= 0 }), "strategy.close must only hold positive deltas")
+	default:
+		check(true, "strategy.name must be one of %q, %q, %q, or %q, got %q", Exponential, AIMD, PID, Ramp, s.Name)
+	}
+
+	if s.Name == PID {
+		percent("strategy.targetFailurePercent", s.TargetFailurePercent)
+	}
+
+	return problems
+}
+
+// Options converts n to nozzle.Options. Each call creates a new Strategy, since some keep state and cannot be shared between Nozzles.
+func Options[T any](n Nozzle) nozzle.Options[T] {
+	return nozzle.Options[T]{
+		Interval:                  time.Duration(n.Interval),
+		AllowedFailurePercent:     n.AllowedFailurePercent,
+		ThresholdInclusive:        n.ThresholdInclusive,
+		DisableClosing:            n.DisableClosing,
+		InitialFlowRate:           n.InitialFlowRate,
+		WarmUp:                    time.Duration(n.WarmUp),
+		WarmUpFlowRate:            n.WarmUpFlowRate,
+		HysteresisBand:            n.HysteresisBand,
+		MinIntervalsBeforeReverse: n.MinIntervalsBeforeReverse,
+		ReopenCooldown:            time.Duration(n.ReopenCooldown),
+		FailureWindow:             n.FailureWindow,
+		SlowFailureWindow:         n.SlowFailureWindow,
+		FailureSmoothing:          n.FailureSmoothing,
+		MaxConcurrent:             n.MaxConcurrent,
+		ReservationTTL:            n.ReservationTTL,
+		MaxWait:                   time.Duration(n.MaxWait),
+		CompatLevel:               nozzle.CompatLevel(n.CompatLevel),
+		Strategy:                  n.Strategy.strategy(),
+	}
+}
+
+// strategy creates the nozzle.Strategy s describes, or nil for the default.
+func (s Strategy) strategy() nozzle.Strategy {
+	switch s.Name {
+	case AIMD:
+		return nozzle.AIMD{Increase: s.Increase, DecreaseFactor: s.DecreaseFactor}
+	case PID:
+		return &nozzle.PID{TargetFailurePercent: s.TargetFailurePercent, Kp: s.Kp, Ki: s.Ki, Kd: s.Kd}
+	case Ramp:
+		return &nozzle.Ramp{Open: curve(s.Open), Close: curve(s.Close)}
+	default:
+		return nil
+	}
+}
+
+// curve returns the StepCurve of deltas, or nil for the default curve.
+func curve(deltas []int64) nozzle.Curve {
+	if len(deltas) == 0 {
+		return nil
+	}
+
+	return nozzle.StepCurve(deltas...)
+}
+
+// Register creates every Nozzle of c in registry, in name order.
+// customize, if not nil, is called with each Nozzle's Options before it is created, to set what a file cannot, such as hooks and a Logger.
+// It stops at the first Nozzle that cannot be registered, such as one whose name is already taken, and returns its error.
+func Register[T any](registry *nozzle.Registry[T], c Config, customize func(name string, o *nozzle.Options[T])) error {
+	for _, name := range c.names() {
+		options := Options[T](c.Nozzles[name])
+
+		if customize != nil {
+			customize(name, &options)
+		}
+
+		if _, err := registry.New(name, options); err != nil {
+			return fmt.Errorf("nozzleconfig: %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package nozzleconfig_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzleconfig"
+)
+
+const file = `{
+	"nozzles": {
+		"payments": {
+			"interval": "1s",
+			"allowedFailurePercent": 20,
+			"reopenCooldown": "30s",
+			"strategy": {"name": "ramp", "open": [1], "close": [10, 50]}
+		},
+		"search": {
+			"interval": "500ms",
+			"allowedFailurePercent": 50,
+			"strategy": {"name": "aimd", "increase": 5, "decreaseFactor": 0.5}
+		}
+	}
+}`
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	config, err := nozzleconfig.Read(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payments := nozzleconfig.Options[any](config.Nozzles["payments"])
+
+	if payments.Interval != time.Second || payments.AllowedFailurePercent != 20 || payments.ReopenCooldown != 30*time.Second {
+		t.Errorf("Expected Interval=1s AllowedFailurePercent=20 ReopenCooldown=30s Got Interval=%s AllowedFailurePercent=%d ReopenCooldown=%s", payments.Interval, payments.AllowedFailurePercent, payments.ReopenCooldown)
+	}
+
+	if _, ok := payments.Strategy.(*nozzle.Ramp); !ok {
+		t.Errorf("Expected Strategy=*nozzle.Ramp Got=%T", payments.Strategy)
+	}
+
+	// Stateful strategies are never shared.
+	if again := nozzleconfig.Options[any](config.Nozzles["payments"]); again.Strategy == payments.Strategy {
+		t.Error("Expected every Options to have its own Strategy")
+	}
+
+	if search := nozzleconfig.Options[any](config.Nozzles["search"]); search.Strategy != (nozzle.AIMD{Increase: 5, DecreaseFactor: 0.5}) {
+		t.Errorf("Expected Strategy=AIMD{5 0.5} Got=%+v", search.Strategy)
+	}
+
+	tests := []struct {
+		nozzle   string
+		problems int
+	}{
+		{nozzle: `{"interval": "1s", "allowedFailurePercent": 50}`, problems: 0},
+		{nozzle: `{"interval": "0s"}`, problems: 1},
+		{nozzle: `{"interval": "1s", "allowedFailurePercent": 150, "failureSmoothing": 2}`, problems: 2},
+		{nozzle: `{"interval": "1s", "strategy": {"name": "linear"}}`, problems: 1},
+		{nozzle: `{"interval": "1s", "strategy": {"name": "ramp", "open": [0]}}`, problems: 1},
+		{nozzle: `{"interval": "1s", "strategy": {"name": "pid", "targetFailurePercent": -1}}`, problems: 1},
+		{nozzle: `{"interval": 1}`, problems: 1},
+		{nozzle: `{"interval": "1s", "allowedFailurePercnt": 50}`, problems: 1},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			_, err := nozzleconfig.Read(strings.NewReader(`{"nozzles": {"a": ` + test.nozzle + `}}`))
+
+			if test.problems == 0 {
+				if err != nil {
+					t.Errorf("Expected no error Got=%v", err)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, nozzleconfig.ErrInvalid) {
+				t.Fatalf("Expected err=%v Got=%v", nozzleconfig.ErrInvalid, err)
+			}
+
+			if problems := strings.Count(err.Error(), nozzleconfig.ErrInvalid.Error()); problems != test.problems {
+				t.Errorf("Expected %d problems Got=%d: %v", test.problems, problems, err)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	config, err := nozzleconfig.Read(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	var customized []string
+
+	err = nozzleconfig.Register(registry, config, func(name string, o *nozzle.Options[any]) {
+		customized = append(customized, name)
+		o.ManualTick = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := registry.Names(); len(names) != 2 || names[0] != "payments" || names[1] != "search" {
+		t.Errorf("Expected Names=[payments search] Got=%v", names)
+	}
+
+	if len(customized) != 2 || customized[0] != "payments" {
+		t.Errorf("Expected every Nozzle to be customized in name order Got=%v", customized)
+	}
+
+	if d := registry.Nozzle("search").DescribeConfig(); d.Interval != 500*time.Millisecond || d.AllowedFailurePercent != 50 {
+		t.Errorf("Expected Interval=500ms AllowedFailurePercent=50 Got Interval=%s AllowedFailurePercent=%d", d.Interval, d.AllowedFailurePercent)
+	}
+
+	if err := nozzleconfig.Register(registry, config, nil); !errors.Is(err, nozzle.ErrRegistered) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrRegistered, err)
+	}
+}
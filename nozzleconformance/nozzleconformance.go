@@ -0,0 +1,254 @@
+// Package nozzleconformance verifies that integrations built on top of a Nozzle behave consistently.
+//
+// An integration is anything that wraps a dependency with a Nozzle: an HTTP client, a gRPC interceptor, a SQL wrapper, or your own type.
+// Every integration should classify outcomes the same way, surface nozzle.ErrBlocked to its callers, honor the caller's context, and close cleanly.
+// Run checks all of these behaviors from a regular Go test.
+//
+// Example:
+//
+//	func TestConformance(t *testing.T) {
+//		nozzleconformance.Run(t, func(n *nozzle.Nozzle[any], dep nozzleconformance.Dependency) nozzleconformance.Integration {
+//			return &myClient{nozzle: n, call: dep}
+//		})
+//	}
+package nozzleconformance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// ErrDependencyFailed is returned by the Dependency when the suite simulates a failing call.
+// Integrations should count it as a failure.
+var ErrDependencyFailed = errors.New("nozzleconformance: dependency failed")
+
+// Dependency stands in for whatever the integration protects (a server, a database, a queue).
+// The integration must call it at most once per Call, passing along the caller's context.
+// It returns nil for a successful call and an error for a failed one.
+type Dependency func(ctx context.Context) error
+
+// Integration is the behavior under test.
+// Call performs a single operation through the integration and reports its error, if any.
+//
+// If the Integration also implements io.Closer, the suite verifies that it closes cleanly.
+type Integration interface {
+	Call(ctx context.Context) error
+}
+
+// Factory builds the Integration under test around the given Nozzle and Dependency.
+// It is called once per check so that every check starts from a fresh Nozzle.
+type Factory[T any] func(n *nozzle.Nozzle[T], dep Dependency) Integration
+
+// Run executes every conformance check against the Integration built by factory.
+// Each check is reported as a sub-test so failures point at the exact behavior that diverged.
+func Run[T any](t *testing.T, factory Factory[T]) {
+	t.Helper()
+
+	t.Run("classifies success", func(t *testing.T) {
+		t.Parallel()
+		classifiesSuccess(t, factory)
+	})
+
+	t.Run("classifies failure", func(t *testing.T) {
+		t.Parallel()
+		classifiesFailure(t, factory)
+	})
+
+	t.Run("propagates ErrBlocked", func(t *testing.T) {
+		t.Parallel()
+		propagatesBlocked(t, factory)
+	})
+
+	t.Run("honors context", func(t *testing.T) {
+		t.Parallel()
+		honorsContext(t, factory)
+	})
+
+	t.Run("closes cleanly", func(t *testing.T) {
+		t.Parallel()
+		closesCleanly(t, factory)
+	})
+}
+
+// calls is how many calls each check sends through the Integration.
+const calls = 10
+
+// recorder is a Dependency that counts how often it was invoked and returns a fixed result.
+type recorder struct {
+	invoked int
+	result  error
+}
+
+// call implements Dependency.
+// Like a real dependency, it fails fast when the context is already done.
+func (r *recorder) call(ctx context.Context) error {
+	r.invoked++
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.result
+}
+
+// idle creates a Nozzle whose interval is long enough that it never ticks during a check.
+// This keeps the rates stable while the check inspects them.
+func idle[T any](t testing.TB) *nozzle.Nozzle[T] {
+	t.Helper()
+
+	return closing(t, nozzle.New(nozzle.Options[T]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	}))
+}
+
+// closing closes n once the check is done, so its goroutine does not outlive the check.
+// An Integration may close n itself; closing it again does nothing.
+func closing[T any](t testing.TB, n *nozzle.Nozzle[T]) *nozzle.Nozzle[T] {
+	t.Helper()
+
+	t.Cleanup(func() {
+		n.Close() //nolint:errcheck // the check is over, so an undelivered interval does not matter.
+	})
+
+	return n
+}
+
+func classifiesSuccess[T any](t *testing.T, factory Factory[T]) {
+	t.Helper()
+
+	noz := idle[T](t)
+	dep := &recorder{}
+	integration := factory(noz, dep.call)
+
+	for range calls {
+		if err := integration.Call(context.Background()); err != nil {
+			t.Fatalf("Call returned error=%v for a successful dependency", err)
+		}
+	}
+
+	if dep.invoked != calls {
+		t.Errorf("Dependency invoked want=%d got=%d", calls, dep.invoked)
+	}
+
+	if fr := noz.FailureRate(); fr != 0 {
+		t.Errorf("FailureRate want=0 got=%d", fr)
+	}
+
+	if sr := noz.SuccessRate(); sr != 100 {
+		t.Errorf("SuccessRate want=100 got=%d", sr)
+	}
+}
+
+func classifiesFailure[T any](t *testing.T, factory Factory[T]) {
+	t.Helper()
+
+	noz := idle[T](t)
+	dep := &recorder{result: ErrDependencyFailed}
+	integration := factory(noz, dep.call)
+
+	for range calls {
+		if err := integration.Call(context.Background()); err == nil {
+			t.Fatal("Call returned a nil error for a failing dependency")
+		}
+	}
+
+	if dep.invoked != calls {
+		t.Errorf("Dependency invoked want=%d got=%d", calls, dep.invoked)
+	}
+
+	if fr := noz.FailureRate(); fr != 100 {
+		t.Errorf("FailureRate want=100 got=%d", fr)
+	}
+}
+
+func propagatesBlocked[T any](t *testing.T, factory Factory[T]) {
+	t.Helper()
+
+	noz := closing(t, nozzle.New(nozzle.Options[T]{
+		Interval:              time.Millisecond * 50,
+		AllowedFailurePercent: 0,
+	}))
+	dep := &recorder{}
+	integration := factory(noz, dep.call)
+
+	for noz.FlowRate() > 0 {
+		for range calls {
+			noz.DoBool(func() (T, bool) {
+				return *new(T), false
+			})
+		}
+
+		noz.Wait()
+	}
+
+	err := integration.Call(context.Background())
+
+	if !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Call error want=%v got=%v", nozzle.ErrBlocked, err)
+	}
+
+	if dep.invoked != 0 {
+		t.Errorf("Dependency invoked %d times while the nozzle was closed", dep.invoked)
+	}
+}
+
+func honorsContext[T any](t *testing.T, factory Factory[T]) {
+	t.Helper()
+
+	type key struct{}
+
+	noz := idle[T](t)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, t.Name()))
+
+	var seen any
+
+	integration := factory(noz, func(ctx context.Context) error {
+		seen = ctx.Value(key{})
+
+		return ctx.Err()
+	})
+
+	if err := integration.Call(ctx); err != nil {
+		t.Fatalf("Call returned error=%v for a successful dependency", err)
+	}
+
+	if seen != t.Name() {
+		t.Error("Dependency did not receive the caller's context")
+	}
+
+	cancel()
+
+	if err := integration.Call(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Call error want=%v got=%v", context.Canceled, err)
+	}
+}
+
+func closesCleanly[T any](t *testing.T, factory Factory[T]) {
+	t.Helper()
+
+	dep := &recorder{}
+	integration := factory(idle[T](t), dep.call)
+
+	closer, ok := integration.(io.Closer)
+	if !ok {
+		t.Skip("Integration does not implement io.Closer")
+	}
+
+	if err := integration.Call(context.Background()); err != nil {
+		t.Fatalf("Call returned error=%v for a successful dependency", err)
+	}
+
+	if err := closer.Close(); err != nil {
+		t.Errorf("Close returned error=%v", err)
+	}
+
+	if err := closer.Close(); err != nil {
+		t.Errorf("second Close returned error=%v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package nozzleconformance_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzleconformance"
+)
+
+// client is the smallest possible integration: it sends every call through DoError.
+type client struct {
+	nozzle *nozzle.Nozzle[string]
+	call   nozzleconformance.Dependency
+}
+
+func (c *client) Call(ctx context.Context) error {
+	_, err := c.nozzle.DoError(func() (string, error) {
+		return "", c.call(ctx)
+	})
+
+	return err
+}
+
+func (c *client) Close() error {
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	nozzleconformance.Run(t, func(n *nozzle.Nozzle[string], dep nozzleconformance.Dependency) nozzleconformance.Integration {
+		return &client{nozzle: n, call: dep}
+	})
+}
+
+func TestRunClosesNozzles(t *testing.T) {
+	t.Parallel()
+
+	var mut sync.Mutex
+
+	var nozzles []*nozzle.Nozzle[string]
+
+	// t.Run returns once every check, and its cleanup, is done.
+	t.Run("checks", func(t *testing.T) {
+		nozzleconformance.Run(t, func(n *nozzle.Nozzle[string], dep nozzleconformance.Dependency) nozzleconformance.Integration {
+			mut.Lock()
+			defer mut.Unlock()
+
+			nozzles = append(nozzles, n)
+
+			return &client{nozzle: n, call: dep}
+		})
+	})
+
+	if len(nozzles) == 0 {
+		t.Fatal("Expected the checks to create Nozzles")
+	}
+
+	// A closed Nozzle returns ErrClosed right away; an open one waits for its next tick, which is far away.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i, n := range nozzles {
+		if _, err := n.WaitSnapshot(ctx); !errors.Is(err, nozzle.ErrClosed) {
+			t.Errorf("test=%d Expected the Nozzle to be closed, err=%v Got=%v", i, nozzle.ErrClosed, err)
+		}
+	}
+}
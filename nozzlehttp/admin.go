@@ -0,0 +1,234 @@
+package nozzlehttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// AdminOptions controls an AdminHandler.
+type AdminOptions struct {
+	// Authorize is called before every request; an error rejects it with 403 Forbidden.
+	// Actions are POST requests and inspections are GET requests, so it can allow them separately.
+	// Example:
+	//
+	//	Authorize: func(r *http.Request) error {
+	//		if r.Method != http.MethodGet && r.Header.Get("Authorization") != "Bearer "+token {
+	//			return errors.New("actions need the operator token")
+	//		}
+	//		return nil
+	//	},
+	//
+	// If nil, every request is allowed, so only serve the handler on an internal address.
+	Authorize func(r *http.Request) error
+}
+
+// AdminAction is the JSON body of an AdminHandler action. Each action reads only the fields it needs.
+type AdminAction struct {
+	// Reason explains a force-open or force-close, see nozzle.ForceCloseFor.
+	Reason string `json:"reason"`
+
+	// Duration bounds a force-open or force-close, such as "10m". If empty, the override lasts until unforce.
+	Duration string `json:"duration"`
+
+	// AllowedFailurePercent is set by thresholds, if present. See nozzle.SetAllowedFailurePercent.
+	AllowedFailurePercent *int64 `json:"allowedFailurePercent"`
+
+	// Interval is set by thresholds, such as "2s", if present. See nozzle.SetInterval.
+	Interval string `json:"interval"`
+}
+
+// AdminNozzle is the JSON response describing one Nozzle.
+type AdminNozzle struct {
+	Snapshot nozzle.StateSnapshot     `json:"snapshot"`
+	Config   nozzle.ConfigDescription `json:"config"`
+
+	// Fairness shows which callers the Nozzle sheds, see nozzle.Options.TrackFairness.
+	Fairness nozzle.FairnessReport `json:"fairness"`
+}
+
+// errNotFound is returned for names the Registry does not have.
+var errNotFound = errors.New("nozzlehttp: no such nozzle")
+
+// AdminHandler serves JSON endpoints to inspect and control the Nozzles of registry at runtime, without redeploying.
+// Mount it on an internal address, behind AdminOptions.Authorize:
+//
+//	GET  /nozzles                       the Snapshot of every Nozzle, by name
+//	GET  /nozzles/{name}                its live Snapshot, DescribeConfig, and Fairness
+//	GET  /nozzles/{name}/history        its History
+//	POST /nozzles/{name}/force-open     ForceOpen, or ForceOpenFor with a duration
+//	POST /nozzles/{name}/force-close    ForceClose, or ForceCloseFor with a duration
+//	POST /nozzles/{name}/unforce        Unforce
+//	POST /nozzles/{name}/reset          Reset
+//	POST /nozzles/{name}/pause          Pause
+//	POST /nozzles/{name}/resume         Resume
+//	POST /nozzles/{name}/thresholds     SetAllowedFailurePercent and SetInterval, from the next interval on
+//
+// Actions take an AdminAction body, and respond with the Nozzle's live Snapshot. Errors respond with {"error": "..."}.
+//
+// Example:
+//
+//	admin := http.NewServeMux()
+//	admin.Handle("/admin/", http.StripPrefix("/admin", nozzlehttp.AdminHandler(registry, nozzlehttp.AdminOptions{
+//		Authorize: authorize,
+//	})))
+//
+//	go http.ListenAndServe("127.0.0.1:9090", admin)
+//
+//	// curl -X POST 127.0.0.1:9090/admin/nozzles/payments/force-close -d '{"reason":"INC-42","duration":"10m"}'
+func AdminHandler[T any](registry *nozzle.Registry[T], options AdminOptions) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /nozzles", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, registry.Snapshot())
+	})
+
+	inspect := func(pattern string, view func(n *nozzle.Nozzle[T]) any) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			n := registry.Nozzle(r.PathValue("name"))
+			if n == nil {
+				writeError(w, http.StatusNotFound, fmt.Errorf("%w: %q", errNotFound, r.PathValue("name")))
+
+				return
+			}
+
+			writeJSON(w, http.StatusOK, view(n))
+		})
+	}
+
+	inspect("GET /nozzles/{name}", func(n *nozzle.Nozzle[T]) any {
+		return AdminNozzle{Snapshot: n.SnapshotNow(), Config: n.DescribeConfig(), Fairness: n.Fairness()}
+	})
+
+	inspect("GET /nozzles/{name}/history", func(n *nozzle.Nozzle[T]) any {
+		return n.History()
+	})
+
+	act := func(action string, do func(n *nozzle.Nozzle[T], a AdminAction) error) {
+		mux.HandleFunc("POST /nozzles/{name}/"+action, func(w http.ResponseWriter, r *http.Request) {
+			n := registry.Nozzle(r.PathValue("name"))
+			if n == nil {
+				writeError(w, http.StatusNotFound, fmt.Errorf("%w: %q", errNotFound, r.PathValue("name")))
+
+				return
+			}
+
+			var a AdminAction
+			if err := json.NewDecoder(r.Body).Decode(&a); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("nozzlehttp: %s: %w", action, err))
+
+				return
+			}
+
+			if err := do(n, a); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("nozzlehttp: %s: %w", action, err))
+
+				return
+			}
+
+			writeJSON(w, http.StatusOK, n.SnapshotNow())
+		})
+	}
+
+	act("force-open", func(n *nozzle.Nozzle[T], a AdminAction) error {
+		return force(a, n.ForceOpen, n.ForceOpenFor)
+	})
+
+	act("force-close", func(n *nozzle.Nozzle[T], a AdminAction) error {
+		return force(a, n.ForceClose, n.ForceCloseFor)
+	})
+
+	act("unforce", func(n *nozzle.Nozzle[T], _ AdminAction) error {
+		n.Unforce()
+
+		return nil
+	})
+
+	act("reset", func(n *nozzle.Nozzle[T], _ AdminAction) error {
+		n.Reset()
+
+		return nil
+	})
+
+	act("pause", func(n *nozzle.Nozzle[T], _ AdminAction) error {
+		n.Pause()
+
+		return nil
+	})
+
+	act("resume", func(n *nozzle.Nozzle[T], _ AdminAction) error {
+		n.Resume()
+
+		return nil
+	})
+
+	act("thresholds", func(n *nozzle.Nozzle[T], a AdminAction) error {
+		var interval time.Duration
+
+		if a.Interval != "" {
+			var err error
+			if interval, err = time.ParseDuration(a.Interval); err != nil {
+				return fmt.Errorf("interval: %w", err)
+			}
+		}
+
+		// Parsed before changing anything, so an invalid body changes nothing.
+		if a.AllowedFailurePercent != nil {
+			n.SetAllowedFailurePercent(*a.AllowedFailurePercent)
+		}
+
+		if a.Interval != "" {
+			n.SetInterval(interval)
+		}
+
+		return nil
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if options.Authorize != nil {
+			if err := options.Authorize(r); err != nil {
+				writeError(w, http.StatusForbidden, err)
+
+				return
+			}
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// force starts the override of a force-open or force-close: bounded by AdminAction.Duration, if it has one.
+func force(a AdminAction, indefinitely func(reason string), bounded func(d time.Duration, reason string)) error {
+	if a.Duration == "" {
+		indefinitely(a.Reason)
+
+		return nil
+	}
+
+	d, err := time.ParseDuration(a.Duration)
+	if err != nil {
+		return fmt.Errorf("duration: %w", err)
+	}
+
+	bounded(d, a.Reason)
+
+	return nil
+}
+
+// writeJSON responds with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(v) //nolint:errcheck,errchkjson // the status is already sent.
+}
+
+// writeError responds with err as a JSON error.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,149 @@
+package nozzlehttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+func TestAdminHandler(t *testing.T) {
+	t.Parallel()
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noz, err := registry.New("payments", nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+		TrackFairness:         true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := nozzlehttp.AdminHandler(registry, nozzlehttp.AdminOptions{
+		Authorize: func(r *http.Request) error {
+			if r.Method != http.MethodGet && r.Header.Get("Authorization") != "Bearer operator" {
+				return errors.New("actions need the operator token")
+			}
+
+			return nil
+		},
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if method == http.MethodPost {
+			req.Header.Set("Authorization", "Bearer operator")
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		state  nozzle.State
+	}{
+		{method: http.MethodPost, path: "/nozzles/payments/force-close", body: `{"reason":"INC-42","duration":"10m"}`, status: http.StatusOK, state: nozzle.ForcedClosed},
+		{method: http.MethodPost, path: "/nozzles/payments/unforce", status: http.StatusOK, state: nozzle.Opening},
+		{method: http.MethodPost, path: "/nozzles/payments/force-open", body: `{"reason":"drill"}`, status: http.StatusOK, state: nozzle.ForcedOpen},
+		{method: http.MethodPost, path: "/nozzles/payments/unforce", status: http.StatusOK, state: nozzle.Opening},
+		{method: http.MethodPost, path: "/nozzles/payments/reset", status: http.StatusOK, state: nozzle.Opening},
+		{method: http.MethodPost, path: "/nozzles/payments/force-close", body: `{"duration":"soon"}`, status: http.StatusBadRequest},
+		{method: http.MethodPost, path: "/nozzles/payments/thresholds", body: `{"allowedFailurePercent":20,"interval":"2s"}`, status: http.StatusOK, state: nozzle.Opening},
+		{method: http.MethodPost, path: "/nozzles/missing/reset", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/nozzles/missing", status: http.StatusNotFound},
+	}
+
+	for i, test := range tests {
+		rec := serve(test.method, test.path, test.body)
+		if rec.Code != test.status {
+			t.Fatalf("test=%d Expected status=%d Got=%d %s", i, test.status, rec.Code, rec.Body)
+		}
+
+		if test.status != http.StatusOK {
+			continue
+		}
+
+		var s nozzle.StateSnapshot
+		if err := json.NewDecoder(rec.Body).Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+
+		if s.State != test.state {
+			t.Errorf("test=%d Expected State=%s Got=%s", i, test.state, s.State)
+		}
+	}
+
+	// Thresholds take effect when the interval ends.
+	clock.Advance(time.Hour)
+	noz.Tick()
+
+	if d := noz.DescribeConfig(); d.AllowedFailurePercent != 20 || d.Interval != 2*time.Second {
+		t.Errorf("Expected AllowedFailurePercent=20 Interval=2s Got AllowedFailurePercent=%d Interval=%s", d.AllowedFailurePercent, d.Interval)
+	}
+
+	// A caller shed while the Nozzle is closed shows in its Fairness.
+	noz.ForceClose("test")
+	noz.DoErrorContext(nozzle.WithCaller(context.Background(), "tenant-a"), func(context.Context) (any, error) { //nolint:errcheck
+		return nil, nil
+	})
+	noz.Unforce()
+
+	var inspected nozzlehttp.AdminNozzle
+	if err := json.NewDecoder(serve(http.MethodGet, "/nozzles/payments", "").Body).Decode(&inspected); err != nil {
+		t.Fatal(err)
+	}
+
+	if inspected.Config.AllowedFailurePercent != 20 {
+		t.Errorf("Expected Config.AllowedFailurePercent=20 Got=%d", inspected.Config.AllowedFailurePercent)
+	}
+
+	if c := inspected.Fairness.Callers["tenant-a"]; c.Blocked != 1 {
+		t.Errorf("Expected Fairness of tenant-a Blocked=1 Got=%d", c.Blocked)
+	}
+
+	var snapshots map[string]nozzle.StateSnapshot
+	if err := json.NewDecoder(serve(http.MethodGet, "/nozzles", "").Body).Decode(&snapshots); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := snapshots["payments"]; !ok || len(snapshots) != 1 {
+		t.Errorf("Expected the snapshot of payments Got=%v", snapshots)
+	}
+
+	for i, path := range []string{"/nozzles/payments/force-close", "/nozzles/payments/reset"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("test=%d Expected an action without the token to be forbidden Got status=%d", i, rec.Code)
+		}
+	}
+
+	if s := noz.State(); s != nozzle.Opening {
+		t.Errorf("Expected forbidden actions to change nothing Got State=%s", s)
+	}
+
+	if rec := serve(http.MethodGet, "/nozzles/payments/history", ""); rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "[") {
+		t.Errorf("Expected a JSON array of history Got status=%d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,136 @@
+package nozzlehttp
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// Sparkline dimensions, in SVG user units.
+const (
+	sparklineWidth  = 240
+	sparklineHeight = 40
+)
+
+// dashboardRow is what the dashboard shows of one Nozzle.
+type dashboardRow struct {
+	Name      string
+	Snapshot  nozzle.StateSnapshot
+	Stats     nozzle.Stats
+	Sparkline string
+	Intervals int
+}
+
+// dashboard renders the page served by DashboardHandler.
+var dashboard = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="5">
+<title>nozzles</title>
+<style>
+body { font-family: monospace; margin: 1em; }
+table { border-collapse: collapse; }
+th, td { padding: 0.25em 0.75em; text-align: right; border-bottom: 1px solid #ddd; }
+th:first-child, td:first-child, .reason { text-align: left; }
+.closing { color: #b00; }
+.opening { color: #070; }
+.forced-open, .forced-closed { color: #a60; }
+polyline { fill: none; stroke: #36c; stroke-width: 1.5; }
+</style>
+</head>
+<body>
+<h1>nozzles</h1>
+{{if not .}}<p>No nozzles are registered.</p>{{else}}
+<table>
+<tr><th>name</th><th>state</th><th>flow rate</th><th>failure rate</th><th>flow rate history</th><th>allowed</th><th>blocked</th><th>successes</th><th>failures</th><th>state changes</th><th class="reason">reason</th></tr>
+{{range .}}<tr>
+<td>{{.Name}}</td>
+<td class="{{.Snapshot.State}}">{{.Snapshot.State}}{{if .Snapshot.Paused}} (paused){{end}}{{if .Snapshot.Maintenance}} (maintenance){{end}}</td>
+<td>{{.Snapshot.FlowRate}}%</td>
+<td>{{.Snapshot.FailureRate}}%</td>
+<td><svg width="` + fmt.Sprint(sparklineWidth) + `" height="` + fmt.Sprint(sparklineHeight) + `"><title>last {{.Intervals}} intervals</title><polyline points="{{.Sparkline}}"/></svg></td>
+<td>{{.Stats.Allowed}}</td>
+<td>{{.Stats.Blocked}}</td>
+<td>{{.Stats.Successes}}</td>
+<td>{{.Stats.Failures}}</td>
+<td>{{.Stats.StateChanges}}</td>
+<td class="reason">{{.Snapshot.Reason}}</td>
+</tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// DashboardHandler serves a self-contained HTML page showing every Nozzle of registry:
+// its state, flow rate, and counters, and a sparkline of its flow rate over its History.
+// It is meant for quick triage on hosts without access to metrics, like net/http/pprof; the page refreshes itself every 5 seconds.
+// Like AdminHandler, only serve it on an internal address. Viewing it counts as using every Nozzle, see nozzle.RegistryOptions.IdleTTL.
+//
+// Example:
+//
+//	admin := http.NewServeMux()
+//	admin.Handle("/debug/nozzles", nozzlehttp.DashboardHandler(registry))
+//
+//	go http.ListenAndServe("127.0.0.1:9090", admin)
+func DashboardHandler[T any](registry *nozzle.Registry[T]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var rows []dashboardRow
+
+		for _, name := range registry.Names() {
+			n := registry.Nozzle(name)
+			if n == nil {
+				continue
+			}
+
+			history := n.History()
+
+			rows = append(rows, dashboardRow{
+				Name:      name,
+				Snapshot:  n.SnapshotNow(),
+				Stats:     n.Stats(),
+				Sparkline: sparkline(history),
+				Intervals: len(history),
+			})
+		}
+
+		// Rendered before writing, so a failure can still respond with an error status.
+		var page bytes.Buffer
+		if err := dashboard.Execute(&page, rows); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.WriteTo(w) //nolint:errcheck // the status is already sent.
+	})
+}
+
+// sparkline returns the points of an SVG polyline plotting the flow rate of each interval, oldest on the left.
+// A flow rate of 100 is at the top.
+func sparkline(history []nozzle.IntervalStats) string {
+	if len(history) == 0 {
+		return ""
+	}
+
+	step := float64(sparklineWidth)
+	if len(history) > 1 {
+		step = float64(sparklineWidth) / float64(len(history)-1)
+	}
+
+	points := make([]string, len(history))
+
+	for i, interval := range history {
+		x := float64(i) * step
+		y := float64(sparklineHeight) * float64(100-interval.FlowRate) / 100
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+
+	return strings.Join(points, " ")
+}
@@ -0,0 +1,72 @@
+package nozzlehttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	t.Parallel()
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	handler := nozzlehttp.DashboardHandler(registry)
+
+	serve := func() string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/nozzles", nil))
+
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Fatalf("Expected status=200 Content-Type=text/html Got status=%d Content-Type=%s", rec.Code, rec.Header().Get("Content-Type"))
+		}
+
+		return rec.Body.String()
+	}
+
+	if page := serve(); !strings.Contains(page, "No nozzles are registered.") {
+		t.Errorf("Expected an empty dashboard Got=%s", page)
+	}
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noz, err := registry.New("<payments>", nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		noz.ReportSuccess()
+		clock.Advance(time.Second)
+		noz.Tick()
+	}
+
+	noz.ForceClose("INC-42")
+
+	page := serve()
+
+	expected := []string{
+		"&lt;payments&gt;",
+		`class="forced-closed"`,
+		"INC-42",
+		`points="0.0,0.0 240.0,0.0"`,
+		"last 2 intervals",
+	}
+
+	for i, e := range expected {
+		if !strings.Contains(page, e) {
+			t.Errorf("test=%d Expected the dashboard to contain %q Got=%s", i, e, page)
+		}
+	}
+}
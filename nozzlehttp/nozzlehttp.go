@@ -0,0 +1,282 @@
+// Package nozzlehttp protects HTTP dependencies with a Nozzle.
+//
+// Whether an HTTP call failed is not the same as whether it returned an error: a 503 is a failure of the dependency, while a 404 is usually the caller's problem.
+// ClassifyStatus and StatusClassifier map responses to outcomes, and Transport applies them to every request sent through an http.Client.
+// On the server side, StreamHandler protects long-lived streaming and WebSocket endpoints, AdminHandler lets operators inspect and control Nozzles at runtime, and DashboardHandler renders them as an HTML page for quick triage.
+//
+// Example:
+//
+//	noz := nozzle.New(nozzle.Options[*http.Response]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//	})
+//
+//	client := &http.Client{
+//		Transport: &nozzlehttp.Transport{Nozzle: noz},
+//	}
+//
+//	resp, err := client.Get("https://payments.internal/charge")
+//	if errors.Is(err, nozzle.ErrBlocked) {
+//		// the Nozzle blocked the request; it was never sent.
+//	}
+package nozzlehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// Classifier decides the outcome of an HTTP call from its response and error.
+// resp is nil when err is not nil.
+type Classifier func(resp *http.Response, err error) nozzle.Outcome
+
+// StatusClassifier classifies HTTP calls by status code.
+//
+// Transport errors and 5xx responses are failures.
+// Every other response, including 4xx, is a success, since the dependency answered.
+// 429 Too Many Requests is configurable, because some teams treat it as backpressure and others as a quota the caller exceeded.
+//
+// Example:
+//
+//	classifier := nozzlehttp.StatusClassifier{TooManyRequests: nozzle.Success}
+//
+//	client := &http.Client{
+//		Transport: &nozzlehttp.Transport{Nozzle: noz, Classify: classifier.Classify},
+//	}
+type StatusClassifier struct {
+	// TooManyRequests is the outcome of a 429 Too Many Requests response.
+	// If zero, it is a failure.
+	TooManyRequests nozzle.Outcome
+}
+
+// Classify implements Classifier.
+func (c StatusClassifier) Classify(resp *http.Response, err error) nozzle.Outcome {
+	switch {
+	case err != nil || resp == nil:
+		return nozzle.Failure
+	case resp.StatusCode == http.StatusTooManyRequests && c.TooManyRequests != 0:
+		return c.TooManyRequests
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nozzle.Failure
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return nozzle.Failure
+	default:
+		return nozzle.Success
+	}
+}
+
+// ClassifyStatus classifies an HTTP call with the zero StatusClassifier.
+// Transport errors, 5xx, and 429 responses are failures; everything else is a success.
+func ClassifyStatus(resp *http.Response, err error) nozzle.Outcome {
+	return StatusClassifier{}.Classify(resp, err)
+}
+
+// errFailed marks a response that was classified as a failure without an error, such as a 503.
+// It never reaches the caller, who receives the response instead.
+var errFailed = errors.New("nozzlehttp: response classified as failure")
+
+// Transport is an http.RoundTripper that sends each request through a Nozzle.
+//
+// Blocked requests are never sent; RoundTrip returns an error that matches nozzle.ErrBlocked.
+// Otherwise the response and error of Base are returned unchanged, and Classify decides how the Nozzle counts them.
+//
+// With nozzle.Options.Retry, only requests that can be sent again are retried: those with an idempotent method, or an Idempotency-Key header,
+// whose body is empty or can be rewound with GetBody. Each retry rewinds the body, and drains and closes the response it replaces.
+type Transport struct {
+	// Nozzle decides which requests are sent.
+	Nozzle *nozzle.Nozzle[*http.Response]
+
+	// Base sends the requests the Nozzle allows.
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Classify decides whether each call succeeded.
+	// If nil, ClassifyStatus is used.
+	Classify Classifier
+
+	// HonorRetryAfter closes the Nozzle for as long as a 429 or 503 response's Retry-After header asks.
+	// The server is telling you exactly when it can take traffic again, which is faster and more precise than waiting for the failure rate to catch up.
+	// The Nozzle is closed with ForceCloseFor, so the pause is reported to Options.OnEvent, and adapting resumes where it left off once it expires.
+	// A manual override that is already active is left alone.
+	HonorRetryAfter bool
+
+	// MaxRetryAfter caps how long a single Retry-After header may close the Nozzle, so a misbehaving server cannot shut it for days.
+	// If zero, DefaultMaxRetryAfter is used.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultMaxRetryAfter is used when Transport.MaxRetryAfter is zero.
+const DefaultMaxRetryAfter = time.Minute
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	classify := t.Classify
+	if classify == nil {
+		classify = ClassifyStatus
+	}
+
+	var (
+		sent bool
+		err  error
+	)
+
+	ctx := req.Context()
+	if !replayable(req) {
+		ctx = nozzle.WithoutRetry(ctx)
+	}
+
+	var last *http.Response
+
+	resp, outcome := t.Nozzle.DoErrorContext(ctx, func(ctx context.Context) (*http.Response, error) {
+		attempt := req.WithContext(ctx)
+
+		if sent {
+			// A retry: the previous response is discarded, and the body sent again from the start.
+			discard(last)
+			last = nil
+
+			if err = rewind(attempt); err != nil {
+				return nil, err
+			}
+		}
+
+		sent = true
+
+		var resp *http.Response
+
+		resp, err = base.RoundTrip(attempt)
+		last = resp
+
+		if classify(resp, err) == nozzle.Success {
+			return resp, nil
+		}
+
+		if err == nil {
+			return resp, errFailed
+		}
+
+		return resp, err
+	})
+
+	if !sent {
+		return nil, outcome
+	}
+
+	// Such as a *nozzle.PanicError from a Base that panicked, with nozzle.PanicRecover.
+	if resp == nil && err == nil {
+		return nil, outcome
+	}
+
+	if t.HonorRetryAfter && err == nil {
+		t.retryAfter(resp)
+	}
+
+	return resp, err
+}
+
+// replayable reports whether req can be sent again, the way net/http decides whether to retry a request on a new connection.
+func replayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	// Looked up directly, like net/http, so a header set to nil still counts.
+	_, key := req.Header["Idempotency-Key"]
+	_, xKey := req.Header["X-Idempotency-Key"]
+
+	return key || xKey
+}
+
+// rewind replaces the body of attempt, a copy of the caller's request, with a fresh one from GetBody.
+func rewind(attempt *http.Request) error {
+	if attempt.GetBody == nil {
+		return nil
+	}
+
+	body, err := attempt.GetBody()
+	if err != nil {
+		return fmt.Errorf("nozzlehttp: rewind request body: %w", err)
+	}
+
+	attempt.Body = body
+
+	return nil
+}
+
+// maxDrain bounds how much of a discarded response body is read so its connection can be reused.
+const maxDrain = 64 << 10
+
+// discard drains and closes the body of a response that will not be returned, so its connection is not leaked.
+func discard(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain)) //nolint:errcheck // the connection is only reused if this succeeds.
+	resp.Body.Close()
+}
+
+// retryAfter closes the Nozzle for the duration advised by resp's Retry-After header, if any.
+func (t *Transport) retryAfter(resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+
+	d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		return
+	}
+
+	if s := t.Nozzle.State(); s == nozzle.ForcedClosed || s == nozzle.ForcedOpen {
+		return
+	}
+
+	maximum := t.MaxRetryAfter
+	if maximum <= 0 {
+		maximum = DefaultMaxRetryAfter
+	}
+
+	t.Nozzle.ForceCloseFor(min(d, maximum), fmt.Sprintf("%d response asked to retry after %s", resp.StatusCode, d))
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date.
+// It reports false when the header is missing, malformed, or not in the future.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil {
+		// Avoid overflowing time.Duration; anything this long is capped by MaxRetryAfter anyway.
+		seconds = min(seconds, int64(math.MaxInt64/time.Second))
+
+		return time.Duration(seconds) * time.Second, seconds > 0
+	}
+
+	date, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+
+	d := date.Sub(now)
+
+	return d, d > 0
+}
@@ -0,0 +1,307 @@
+package nozzlehttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzleconformance"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+var errRefused = errors.New("connection refused")
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		classifier nozzlehttp.StatusClassifier
+		status     int
+		err        error
+		expected   nozzle.Outcome
+	}{
+		{status: http.StatusOK, expected: nozzle.Success},
+		{status: http.StatusNotFound, expected: nozzle.Success},
+		{status: http.StatusBadRequest, expected: nozzle.Success},
+		{status: http.StatusInternalServerError, expected: nozzle.Failure},
+		{status: http.StatusServiceUnavailable, expected: nozzle.Failure},
+		{err: errRefused, expected: nozzle.Failure},
+		{status: http.StatusTooManyRequests, expected: nozzle.Failure},
+		{
+			classifier: nozzlehttp.StatusClassifier{TooManyRequests: nozzle.Success},
+			status:     http.StatusTooManyRequests,
+			expected:   nozzle.Success,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			var resp *http.Response
+			if test.err == nil {
+				resp = &http.Response{StatusCode: test.status}
+			}
+
+			if o := test.classifier.Classify(resp, test.err); o != test.expected {
+				t.Errorf("Expected Outcome=%d Got=%d", test.expected, o)
+			}
+		})
+	}
+}
+
+// roundTripperFunc adapts a function into an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// client sends conformance calls through a Transport.
+type client struct {
+	http *http.Client
+}
+
+func (c *client) Call(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://dependency.test", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nozzleconformance.ErrDependencyFailed
+	}
+
+	return nil
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	nozzleconformance.Run(t, func(n *nozzle.Nozzle[*http.Response], dep nozzleconformance.Dependency) nozzleconformance.Integration {
+		return &client{
+			http: &http.Client{
+				Transport: &nozzlehttp.Transport{
+					Nozzle: n,
+					Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+						if err := dep(req.Context()); err != nil {
+							if errors.Is(err, nozzleconformance.ErrDependencyFailed) {
+								return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+							}
+
+							return nil, err
+						}
+
+						return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+					}),
+				},
+			},
+		}
+	})
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	statuses := []int{http.StatusOK, http.StatusNotFound, http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var i int
+
+		_, _ = fmt.Sscan(r.URL.Query().Get("i"), &i)
+
+		w.WriteHeader(statuses[i])
+	}))
+	defer server.Close()
+
+	noz := nozzle.New(nozzle.Options[*http.Response]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	c := &http.Client{
+		Transport: &nozzlehttp.Transport{Nozzle: noz},
+	}
+
+	for i, status := range statuses {
+		resp, err := c.Get(fmt.Sprintf("%s?i=%d", server.URL, i))
+		if err != nil {
+			t.Fatalf("request=%d Expected err=nil Got=%v", i, err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			t.Errorf("request=%d Expected StatusCode=%d Got=%d", i, status, resp.StatusCode)
+		}
+	}
+
+	if s := noz.Stats(); s.Successes != 2 || s.Failures != 2 {
+		t.Errorf("Expected Successes=2 Failures=2 Got Successes=%d Failures=%d", s.Successes, s.Failures)
+	}
+}
+
+func TestHonorRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	events := make(chan nozzle.Event, 1)
+
+	noz := nozzle.New(nozzle.Options[*http.Response]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		OnEvent: func(e nozzle.Event) {
+			if e.Type != nozzle.EventCompatLevel {
+				events <- e
+			}
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	c := &http.Client{
+		Transport: &nozzlehttp.Transport{
+			Nozzle:          noz,
+			HonorRetryAfter: true,
+			MaxRetryAfter:   time.Minute,
+		},
+	}
+
+	resp, err := c.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	resp.Body.Close()
+
+	if s := noz.State(); s != nozzle.ForcedClosed {
+		t.Errorf("Expected State=%s Got=%s", nozzle.ForcedClosed, s)
+	}
+
+	if e := <-events; e.Duration != time.Minute {
+		t.Errorf("Expected Duration=%s capped by MaxRetryAfter Got=%s", time.Minute, e.Duration)
+	}
+
+	if _, err := c.Get(server.URL); !errors.Is(err, nozzle.ErrBlocked) { //nolint:bodyclose // a blocked request has no response.
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrBlocked, err)
+	}
+}
+
+// trackedBody is a response body that records whether it was closed.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+
+	return nil
+}
+
+func TestTransportRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		method string
+		sends  int
+	}{
+		// POST is not idempotent, so it is never sent twice.
+		{method: http.MethodPost, sends: 1},
+		{method: http.MethodPut, sends: 3},
+	}
+
+	for i, test := range tests {
+		noz := nozzle.New(nozzle.Options[*http.Response]{
+			Interval:              time.Hour,
+			AllowedFailurePercent: 100,
+			Retry:                 nozzle.Retry{MaxAttempts: 3},
+		})
+		defer noz.Close() //nolint:errcheck
+
+		var bodies []string
+		var responses []*trackedBody
+
+		transport := &nozzlehttp.Transport{
+			Nozzle: noz,
+			Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				b, _ := io.ReadAll(req.Body)
+				bodies = append(bodies, string(b))
+
+				body := &trackedBody{Reader: strings.NewReader("unavailable")}
+				responses = append(responses, body)
+
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: body}, nil
+			}),
+		}
+
+		req, err := http.NewRequestWithContext(context.Background(), test.method, "http://payments.internal/charge", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := transport.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("test=%d Expected the last response Got err=%v", i, err)
+		}
+
+		if len(bodies) != test.sends {
+			t.Fatalf("test=%d Expected sends=%d Got=%d", i, test.sends, len(bodies))
+		}
+
+		// Every attempt sends the whole body, and every discarded response is closed.
+		for j, body := range bodies {
+			if body != "payload" {
+				t.Errorf("test=%d send=%d Expected body=payload Got=%q", i, j, body)
+			}
+
+			if last := j == len(bodies)-1; responses[j].closed == last {
+				t.Errorf("test=%d send=%d Expected closed=%t Got=%t", i, j, !last, responses[j].closed)
+			}
+		}
+	}
+}
+
+func TestTransportPanic(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[*http.Response]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		PanicPolicy:           nozzle.PanicRecover,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	transport := &nozzlehttp.Transport{
+		Nozzle: noz,
+		Base: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			panic("broken transport")
+		}),
+	}
+
+	resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://payments.internal/", nil))
+
+	var p *nozzle.PanicError
+	if resp != nil || !errors.As(err, &p) {
+		t.Errorf("Expected resp=nil err=*nozzle.PanicError Got resp=%v err=%v", resp, err)
+	}
+}
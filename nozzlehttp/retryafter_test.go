@@ -0,0 +1,40 @@
+package nozzlehttp //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header   string
+		expected time.Duration
+		ok       bool
+	}{
+		{header: "", ok: false},
+		{header: "120", expected: 2 * time.Minute, ok: true},
+		{header: "0", ok: false},
+		{header: "-5", expected: -5 * time.Second, ok: false},
+		{header: "soon", ok: false},
+		{header: now.Add(30 * time.Second).Format(http.TimeFormat), expected: 30 * time.Second, ok: true},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), expected: -time.Minute, ok: false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			d, ok := parseRetryAfter(test.header, now)
+
+			if ok != test.ok || (ok && d != test.expected) {
+				t.Errorf("Expected Duration=%s ok=%t Got Duration=%s ok=%t", test.expected, test.ok, d, ok)
+			}
+		})
+	}
+}
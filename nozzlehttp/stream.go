@@ -0,0 +1,135 @@
+package nozzlehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// DefaultCloseCode is used when StreamHandler.CloseCode is zero.
+// It is the WebSocket close code 1013 Try Again Later, which tells clients the server is temporarily shedding load.
+const DefaultCloseCode = 1013
+
+// TerminatedError is the cause of a stream's context when StreamHandler terminates it.
+// Handlers should end the stream gracefully, such as by sending a WebSocket close frame with CloseCode.
+//
+// Example:
+//
+//	<-ctx.Done()
+//
+//	var terminated *nozzlehttp.TerminatedError
+//	if errors.As(context.Cause(ctx), &terminated) {
+//		conn.Close(websocket.StatusCode(terminated.CloseCode), "shedding load")
+//	}
+type TerminatedError struct {
+	// FlowRate is the flow rate that fell below StreamHandler.MinFlowRate.
+	FlowRate int64
+
+	// CloseCode is StreamHandler.CloseCode, or DefaultCloseCode.
+	CloseCode int
+}
+
+// Error implements error.
+func (e *TerminatedError) Error() string {
+	return fmt.Sprintf("nozzlehttp: stream terminated (flow rate %d%%, close code %d)", e.FlowRate, e.CloseCode)
+}
+
+// StreamHandler protects long-lived streaming and WebSocket endpoints with a Nozzle.
+//
+// Each request, such as a WebSocket upgrade, is admitted by the Nozzle before Handler sees it.
+// A blocked request is rejected with RejectStatus before the upgrade, along with a Retry-After header when the Nozzle can estimate one.
+// Streams that are already established can also be shed: when the flow rate drops below MinFlowRate, the stream's context is canceled with a TerminatedError.
+// Handler keeps control of the connection, so it can end the stream gracefully instead of dropping it.
+//
+// Each stream counts as one successful call when Handler returns, since a stream ending says nothing about the health of the server.
+//
+// Example:
+//
+//	http.Handle("/events", &nozzlehttp.StreamHandler{
+//		Nozzle:      noz,
+//		Handler:     events,
+//		MinFlowRate: 50,
+//	})
+type StreamHandler struct {
+	// Nozzle decides which streams are accepted.
+	Nozzle *nozzle.Nozzle[any]
+
+	// Handler serves the streams the Nozzle accepts.
+	// Its request's context is canceled, with a TerminatedError as the cause, if the stream is terminated.
+	Handler http.Handler
+
+	// RejectStatus is the status code of a blocked request.
+	// If zero, 503 Service Unavailable is used.
+	RejectStatus int
+
+	// MinFlowRate terminates established streams when the Nozzle's flow rate drops below it.
+	// It is checked at the end of every interval.
+	// If zero, established streams are never terminated.
+	MinFlowRate int64
+
+	// CloseCode is reported in the TerminatedError of a terminated stream.
+	// If zero, DefaultCloseCode is used.
+	CloseCode int
+}
+
+// ServeHTTP implements http.Handler.
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, err := h.Nozzle.DoErrorContext(r.Context(), func(ctx context.Context) (any, error) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		defer cancel(nil)
+
+		if h.MinFlowRate > 0 {
+			go h.watch(ctx, cancel)
+		}
+
+		h.Handler.ServeHTTP(w, r.WithContext(ctx))
+
+		return nil, nil
+	})
+
+	var blocked *nozzle.BlockedError
+	if errors.As(err, &blocked) {
+		h.reject(w, blocked)
+	}
+}
+
+// watch terminates the stream served with ctx once the flow rate drops below MinFlowRate.
+// It returns when ctx is done or the Nozzle is closed.
+func (h *StreamHandler) watch(ctx context.Context, cancel context.CancelCauseFunc) {
+	for {
+		snapshot, err := h.Nozzle.WaitSnapshot(ctx)
+		if err != nil {
+			return
+		}
+
+		if snapshot.FlowRate < h.MinFlowRate {
+			code := h.CloseCode
+			if code == 0 {
+				code = DefaultCloseCode
+			}
+
+			cancel(&TerminatedError{FlowRate: snapshot.FlowRate, CloseCode: code})
+
+			return
+		}
+	}
+}
+
+// reject responds to a request the Nozzle blocked.
+func (h *StreamHandler) reject(w http.ResponseWriter, blocked *nozzle.BlockedError) {
+	status := h.RejectStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+
+	if blocked.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(blocked.RetryAfter.Seconds()))))
+	}
+
+	http.Error(w, blocked.Error(), status)
+}
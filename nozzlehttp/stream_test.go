@@ -0,0 +1,116 @@
+package nozzlehttp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlehttp"
+)
+
+func TestStreamHandlerReject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		rejectStatus   int
+		expectedStatus int
+	}{
+		{
+			rejectStatus:   0,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			rejectStatus:   http.StatusTooManyRequests,
+			expectedStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := nozzle.New(nozzle.Options[any]{
+				Interval:              time.Hour,
+				AllowedFailurePercent: 50,
+			})
+			defer noz.Close() //nolint:errcheck
+
+			noz.ForceCloseFor(time.Minute, "maintenance")
+
+			var served bool
+
+			h := &nozzlehttp.StreamHandler{
+				Nozzle: noz,
+				Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+					served = true
+				}),
+				RejectStatus: test.rejectStatus,
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+			if served {
+				t.Error("Expected a blocked upgrade not to reach the Handler")
+			}
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("Expected status=%d Got=%d", test.expectedStatus, w.Code)
+			}
+
+			if retryAfter := w.Header().Get("Retry-After"); retryAfter != "60" {
+				t.Errorf("Expected Retry-After=60 Got=%q", retryAfter)
+			}
+		})
+	}
+}
+
+func TestStreamHandlerTerminate(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	started := make(chan struct{})
+	causes := make(chan error, 1)
+
+	h := &nozzlehttp.StreamHandler{
+		Nozzle: noz,
+		Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			close(started)
+
+			<-r.Context().Done()
+
+			causes <- context.Cause(r.Context())
+		}),
+		MinFlowRate: 50,
+	}
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	<-started
+
+	noz.ForceCloseFor(time.Minute, "shedding streams")
+
+	select {
+	case cause := <-causes:
+		var terminated *nozzlehttp.TerminatedError
+		if !errors.As(cause, &terminated) {
+			t.Fatalf("Expected cause=*TerminatedError Got=%v", cause)
+		}
+
+		if terminated.FlowRate != 0 || terminated.CloseCode != nozzlehttp.DefaultCloseCode {
+			t.Errorf("Expected FlowRate=0 CloseCode=%d Got FlowRate=%d CloseCode=%d", nozzlehttp.DefaultCloseCode, terminated.FlowRate, terminated.CloseCode)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to be terminated")
+	}
+}
@@ -0,0 +1,26 @@
+package nozzle
+
+import "context"
+
+// Nozzler is the part of a Nozzle most call sites use.
+// Depend on it instead of *Nozzle so tests can substitute a fake, such as Noop or AlwaysBlocked.
+//
+// Example:
+//
+//	type PaymentsClient struct {
+//		nozzle nozzle.Nozzler[*Receipt]
+//	}
+//
+//	// In tests:
+//	client := PaymentsClient{nozzle: nozzle.AlwaysBlocked[*Receipt]()}
+type Nozzler[T any] interface {
+	DoBool(callback func() (T, bool)) (T, bool)
+	DoError(callback func() (T, error)) (T, error)
+	DoErrorContext(ctx context.Context, callback func(context.Context) (T, error)) (T, error)
+	FlowRate() int64
+	State() State
+	Close() error
+}
+
+// Every *Nozzle[T] is a Nozzler[T].
+var _ Nozzler[any] = (*Nozzle[any])(nil)
@@ -0,0 +1,59 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestNozzler(t *testing.T) {
+	t.Parallel()
+
+	charge := func(n nozzle.Nozzler[string]) (string, error) {
+		return n.DoErrorContext(context.Background(), func(context.Context) (string, error) {
+			return "receipt", nil
+		})
+	}
+
+	tests := []struct {
+		nozzler     nozzle.Nozzler[string]
+		expected    string
+		expectedErr error
+	}{
+		{
+			nozzler: nozzle.New(nozzle.Options[string]{
+				Interval:              time.Hour,
+				AllowedFailurePercent: 50,
+			}),
+			expected:    "receipt",
+			expectedErr: nil,
+		},
+		{
+			nozzler:     nozzle.Noop[string](),
+			expected:    "receipt",
+			expectedErr: nil,
+		},
+		{
+			nozzler:     nozzle.AlwaysBlocked[string](),
+			expected:    "",
+			expectedErr: nozzle.ErrBlocked,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			defer test.nozzler.Close() //nolint:errcheck
+
+			res, err := charge(test.nozzler)
+			if res != test.expected || !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected res=%q err=%v Got res=%q err=%v", test.expected, test.expectedErr, res, err)
+			}
+		})
+	}
+}
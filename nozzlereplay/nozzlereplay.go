@@ -0,0 +1,282 @@
+// Package nozzlereplay replays historical call outcomes through a Nozzle at virtual time, to tune its Options offline.
+//
+// Export the outcomes of a past incident from your logs, then compare how different Options would have shed load,
+// before changing anything in production. Replays are deterministic: the same outcomes and Options always produce the same trajectory.
+//
+// Example:
+//
+//	f, err := os.Open("incident.csv")
+//	if err != nil {
+//		// handle error
+//	}
+//	defer f.Close()
+//
+//	outcomes, err := nozzlereplay.ReadCSV(f)
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	trajectory, err := nozzlereplay.Replay(outcomes, nozzlereplay.Options{
+//		Nozzle: nozzle.Options[any]{
+//			Interval:              time.Second,
+//			AllowedFailurePercent: 20,
+//		},
+//	})
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	for _, s := range trajectory {
+//		fmt.Println(s.Time, s.FlowRate, s.State)
+//	}
+package nozzlereplay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// ErrInterval is returned by Replay when Options.Nozzle.Interval is not positive, since intervals would never end.
+var ErrInterval = errors.New("nozzlereplay: Interval must be positive")
+
+// Outcome is the result of one historical call.
+type Outcome struct {
+	// Time is when the call was made.
+	Time time.Time `json:"timestamp"`
+
+	// Success reports whether the call succeeded.
+	Success bool `json:"success"`
+
+	// Latency is how long the call took. It is only used by Options.SlowThreshold.
+	Latency Duration `json:"latency"`
+}
+
+// Duration is a time.Duration written in JSON as a string, such as "120ms".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string, such as "120ms", with time.ParseDuration.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("nozzlereplay: latency must be a duration string: %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("nozzlereplay: latency: %w", err)
+	}
+
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// MarshalJSON writes the duration as a string, such as "120ms".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String()) //nolint:wrapcheck // marshaling a string cannot fail.
+}
+
+// Options controls a replay.
+type Options struct {
+	// Nozzle are the Options to evaluate.
+	// Their Clock and ManualTick are replaced, so the replay runs at virtual time; Interval must be positive.
+	Nozzle nozzle.Options[any]
+
+	// SlowThreshold counts successful calls that took longer than it as failures, as if they had timed out.
+	// Example:
+	//
+	//	SlowThreshold: 2 * time.Second // Evaluate a 2 second client timeout
+	//
+	// If zero, only Outcome.Success decides.
+	SlowThreshold time.Duration
+}
+
+// Replay runs outcomes, in time order, through a Nozzle created with options, and returns its flow-rate trajectory:
+// one snapshot for every interval from the first outcome to the last, including intervals without any outcome.
+//
+// Each outcome is first admitted by the Nozzle. An allowed call reports its historical outcome; a blocked call is only counted as blocked,
+// since it would never have reached the dependency. So the trajectory shows how the Options would have reacted,
+// but the dependency's own recovery is only as fast as it was in the recording.
+func Replay(outcomes []Outcome, options Options) ([]nozzle.StateSnapshot, error) {
+	if options.Nozzle.Interval <= 0 {
+		return nil, ErrInterval
+	}
+
+	if len(outcomes) == 0 {
+		return []nozzle.StateSnapshot{}, nil
+	}
+
+	sorted := slices.SortedStableFunc(slices.Values(outcomes), func(a, b Outcome) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	clock := nozzle.NewManualClock(sorted[0].Time)
+
+	noz := nozzle.New(withClock(options.Nozzle, clock))
+	defer noz.Close() //nolint:errcheck // ManualTick Nozzles have nothing to deliver.
+
+	// New clamps Interval to MinInterval, so use the one it settled on.
+	interval := noz.DescribeConfig().Interval
+	end := sorted[0].Time.Add(interval)
+
+	var trajectory []nozzle.StateSnapshot
+
+	tick := func() {
+		clock.Advance(end.Sub(clock.Now()))
+		noz.Tick()
+
+		trajectory = append(trajectory, noz.Snapshot())
+		end = end.Add(interval)
+	}
+
+	for _, o := range sorted {
+		for !o.Time.Before(end) {
+			tick()
+		}
+
+		clock.Advance(o.Time.Sub(clock.Now()))
+
+		if !noz.Allow() {
+			continue
+		}
+
+		if o.Success && (options.SlowThreshold <= 0 || time.Duration(o.Latency) <= options.SlowThreshold) {
+			noz.ReportSuccess()
+		} else {
+			noz.ReportFailure()
+		}
+	}
+
+	// End the interval of the last outcome too.
+	tick()
+
+	return trajectory, nil
+}
+
+// withClock returns a copy of options that runs at the virtual time of clock.
+func withClock(options nozzle.Options[any], clock nozzle.Clock) nozzle.Options[any] {
+	options.Clock = clock
+	options.ManualTick = true
+	options.Scheduler = nil
+
+	return options
+}
+
+// ReadCSV reads outcomes from CSV with a header row naming its columns: timestamp, success, and optionally latency.
+// Timestamps are RFC 3339, success is a boolean such as true or false, and latency is a duration such as 120ms.
+//
+// Example:
+//
+//	timestamp,success,latency
+//	2024-01-01T00:00:00.120Z,true,120ms
+//	2024-01-01T00:00:00.250Z,false,2s
+func ReadCSV(r io.Reader) ([]Outcome, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("nozzlereplay: read header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	for _, required := range []string{"timestamp", "success"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("nozzlereplay: header has no %s column", required)
+		}
+	}
+
+	var outcomes []Outcome
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return outcomes, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("nozzlereplay: line %d: %w", line, err)
+		}
+
+		o, err := parse(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("nozzlereplay: line %d: %w", line, err)
+		}
+
+		outcomes = append(outcomes, o)
+	}
+}
+
+// parse reads one CSV record, whose fields are found by columns.
+func parse(record []string, columns map[string]int) (Outcome, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+
+		return ""
+	}
+
+	var (
+		o   Outcome
+		err error
+	)
+
+	if o.Time, err = time.Parse(time.RFC3339Nano, field("timestamp")); err != nil {
+		return Outcome{}, fmt.Errorf("timestamp: %w", err)
+	}
+
+	if o.Success, err = strconv.ParseBool(field("success")); err != nil {
+		return Outcome{}, fmt.Errorf("success: %w", err)
+	}
+
+	if latency := field("latency"); latency != "" {
+		parsed, err := time.ParseDuration(latency)
+		if err != nil {
+			return Outcome{}, fmt.Errorf("latency: %w", err)
+		}
+
+		o.Latency = Duration(parsed)
+	}
+
+	return o, nil
+}
+
+// ReadJSONL reads outcomes from JSON Lines: one JSON object per line, with the fields of Outcome.
+//
+// Example:
+//
+//	{"timestamp":"2024-01-01T00:00:00.120Z","success":true,"latency":"120ms"}
+//	{"timestamp":"2024-01-01T00:00:00.250Z","success":false,"latency":"2s"}
+func ReadJSONL(r io.Reader) ([]Outcome, error) {
+	decoder := json.NewDecoder(r)
+
+	var outcomes []Outcome
+
+	for {
+		var o Outcome
+
+		err := decoder.Decode(&o)
+		if errors.Is(err, io.EOF) {
+			return outcomes, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("nozzlereplay: outcome %d: %w", len(outcomes)+1, err)
+		}
+
+		outcomes = append(outcomes, o)
+	}
+}
@@ -0,0 +1,131 @@
+package nozzlereplay_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlereplay"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expected := []nozzlereplay.Outcome{
+		{Time: start.Add(120 * time.Millisecond), Success: true, Latency: nozzlereplay.Duration(120 * time.Millisecond)},
+		{Time: start.Add(250 * time.Millisecond), Success: false, Latency: nozzlereplay.Duration(2 * time.Second)},
+	}
+
+	csv, err := nozzlereplay.ReadCSV(strings.NewReader("success,latency,timestamp\ntrue,120ms,2024-01-01T00:00:00.120Z\nfalse,2s,2024-01-01T00:00:00.250Z\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonl, err := nozzlereplay.ReadJSONL(strings.NewReader(`{"timestamp":"2024-01-01T00:00:00.120Z","success":true,"latency":"120ms"}
+{"timestamp":"2024-01-01T00:00:00.250Z","success":false,"latency":"2s"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, outcomes := range map[string][]nozzlereplay.Outcome{"csv": csv, "jsonl": jsonl} {
+		if len(outcomes) != len(expected) {
+			t.Fatalf("%s Expected %d outcomes Got=%d", name, len(expected), len(outcomes))
+		}
+
+		for i := range expected {
+			if !outcomes[i].Time.Equal(expected[i].Time) || outcomes[i].Success != expected[i].Success || outcomes[i].Latency != expected[i].Latency {
+				t.Errorf("%s outcome=%d Expected=%+v Got=%+v", name, i, expected[i], outcomes[i])
+			}
+		}
+	}
+
+	invalid := []string{
+		"",
+		"timestamp,latency\n",
+		"timestamp,success\nyesterday,true\n",
+		"timestamp,success\n2024-01-01T00:00:00Z,maybe\n",
+		"timestamp,success,latency\n2024-01-01T00:00:00Z,true,fast\n",
+	}
+
+	for i, input := range invalid {
+		if _, err := nozzlereplay.ReadCSV(strings.NewReader(input)); err == nil {
+			t.Errorf("test=%d Expected an error for %q", i, input)
+		}
+	}
+
+	if _, err := nozzlereplay.ReadJSONL(strings.NewReader(`{"timestamp":"2024-01-01T00:00:00Z","latency":120}`)); err == nil {
+		t.Error("Expected an error for a numeric latency")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 10 calls per second: healthy, then 2 seconds of slow calls, then a silent second, then healthy again.
+	var outcomes []nozzlereplay.Outcome
+
+	for second, latency := range []time.Duration{time.Millisecond, time.Minute, time.Minute, 0, time.Millisecond} {
+		if latency == 0 {
+			continue
+		}
+
+		for i := range 10 {
+			outcomes = append(outcomes, nozzlereplay.Outcome{
+				Time:    start.Add(time.Duration(second)*time.Second + time.Duration(i)*100*time.Millisecond),
+				Success: true,
+				Latency: nozzlereplay.Duration(latency),
+			})
+		}
+	}
+
+	tests := []struct {
+		slowThreshold time.Duration
+		flowRates     []int64
+	}{
+		{slowThreshold: 0, flowRates: []int64{100, 100, 100, 100, 100}},
+		{slowThreshold: time.Second, flowRates: []int64{100, 99, 97, 98, 100}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			trajectory, err := nozzlereplay.Replay(outcomes, nozzlereplay.Options{
+				Nozzle: nozzle.Options[any]{
+					Interval:              time.Second,
+					AllowedFailurePercent: 50,
+				},
+				SlowThreshold: test.slowThreshold,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(trajectory) != len(test.flowRates) {
+				t.Fatalf("Expected %d intervals Got=%d", len(test.flowRates), len(trajectory))
+			}
+
+			for j, s := range trajectory {
+				if s.FlowRate != test.flowRates[j] {
+					t.Errorf("interval=%d Expected FlowRate=%d Got=%d", j, test.flowRates[j], s.FlowRate)
+				}
+
+				if expected := start.Add(time.Duration(j+1) * time.Second); !s.Time.Equal(expected) {
+					t.Errorf("interval=%d Expected Time=%s Got=%s", j, expected, s.Time)
+				}
+			}
+		})
+	}
+
+	if _, err := nozzlereplay.Replay(outcomes, nozzlereplay.Options{}); !errors.Is(err, nozzlereplay.ErrInterval) {
+		t.Errorf("Expected err=%v Got=%v", nozzlereplay.ErrInterval, err)
+	}
+}
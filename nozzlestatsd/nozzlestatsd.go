@@ -0,0 +1,175 @@
+// Package nozzlestatsd periodically reports a Nozzle's state to a StatsD or DogStatsD endpoint.
+//
+// OnStateChange is only called when the Nozzle changes, so metrics built on it go quiet while the Nozzle is stable.
+// A Reporter instead flushes on its own interval, so dashboards always have fresh data points.
+//
+// Example:
+//
+//	conn, err := net.Dial("udp", "127.0.0.1:8125")
+//	if err != nil {
+//		// handle error
+//	}
+//
+//	reporter := nozzlestatsd.New(conn, noz, nozzlestatsd.Options{
+//		Prefix:   "checkout.payments",
+//		Tags:     []string{"env:prod"},
+//		Interval: 10 * time.Second,
+//	})
+//
+//	go reporter.Run(ctx)
+package nozzlestatsd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+// DefaultPrefix is used when Options.Prefix is empty.
+const DefaultPrefix = "nozzle"
+
+// DefaultInterval is used when Options.Interval is not positive.
+const DefaultInterval = 10 * time.Second
+
+// Source is the part of a Nozzle a Reporter reads from.
+// Every *nozzle.Nozzle[T] implements it, whatever its T.
+type Source interface {
+	FlowRate() int64
+	FailureRate() int64
+	Stats() nozzle.Stats
+}
+
+// Options controls the behavior of a Reporter.
+type Options struct {
+	// Prefix is prepended to every metric name, separated by a dot.
+	// Example:
+	//
+	//	Prefix: "checkout.payments" // Reports checkout.payments.flow_rate, checkout.payments.blocked, ...
+	//
+	// If empty, DefaultPrefix is used.
+	Prefix string
+
+	// Tags are attached to every metric using the DogStatsD tag extension.
+	// Example:
+	//
+	//	Tags: []string{"env:prod", "dependency:payments"}
+	//
+	// Leave empty when reporting to a plain StatsD server, which does not understand tags.
+	Tags []string
+
+	// Interval controls how often Run flushes metrics.
+	// If unsure, match the flush interval of your StatsD server (often 10 seconds).
+	// If zero or negative, DefaultInterval is used.
+	Interval time.Duration
+
+	// OnError is called when a flush fails to write.
+	// Run keeps flushing after errors, since StatsD is usually sent over UDP and failures are transient.
+	OnError func(error)
+}
+
+// Reporter writes a Source's metrics to a StatsD endpoint.
+//
+// Each flush reports:
+//
+//	<prefix>.flow_rate     gauge   the current flow rate
+//	<prefix>.failure_rate  gauge   the current failure rate
+//	<prefix>.allowed       counter calls allowed since the previous flush
+//	<prefix>.blocked       counter calls blocked since the previous flush
+type Reporter struct {
+	w       io.Writer
+	source  Source
+	options Options
+
+	// mut guards last so Flush can be called while Run is running.
+	mut sync.Mutex
+
+	// last holds the Stats seen by the previous flush, used to compute deltas.
+	last nozzle.Stats
+}
+
+// New creates a Reporter that writes to w.
+// w is usually a UDP connection created with net.Dial.
+// The first flush reports every call since the Source was created.
+func New(w io.Writer, source Source, options Options) *Reporter {
+	if options.Prefix == "" {
+		options.Prefix = DefaultPrefix
+	}
+
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+
+	return &Reporter{
+		w:       w,
+		source:  source,
+		options: options,
+	}
+}
+
+// Run flushes metrics every Options.Interval until ctx is done.
+// It flushes one final time before returning, so the last partial period is not lost.
+func (r *Reporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.flush()
+
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// Flush writes the current metrics immediately.
+// All metrics are sent in a single write, which StatsD servers accept as one newline-delimited packet.
+func (r *Reporter) Flush() error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	stats := r.source.Stats()
+
+	var buf bytes.Buffer
+
+	r.write(&buf, "flow_rate", r.source.FlowRate(), "g")
+	r.write(&buf, "failure_rate", r.source.FailureRate(), "g")
+	r.write(&buf, "allowed", stats.Allowed-r.last.Allowed, "c")
+	r.write(&buf, "blocked", stats.Blocked-r.last.Blocked, "c")
+
+	if _, err := r.w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))); err != nil {
+		return fmt.Errorf("nozzlestatsd: flush: %w", err)
+	}
+
+	r.last = stats
+
+	return nil
+}
+
+// flush calls Flush and reports any error to Options.OnError.
+func (r *Reporter) flush() {
+	if err := r.Flush(); err != nil && r.options.OnError != nil {
+		r.options.OnError(err)
+	}
+}
+
+// write appends a single metric line in StatsD format.
+// Example: checkout.payments.flow_rate:85|g|#env:prod
+func (r *Reporter) write(buf *bytes.Buffer, name string, value int64, kind string) {
+	fmt.Fprintf(buf, "%s.%s:%d|%s", r.options.Prefix, name, value, kind)
+
+	if len(r.options.Tags) > 0 {
+		buf.WriteString("|#")
+		buf.WriteString(strings.Join(r.options.Tags, ","))
+	}
+
+	buf.WriteString("\n")
+}
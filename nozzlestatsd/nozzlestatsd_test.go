@@ -0,0 +1,80 @@
+package nozzlestatsd_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlestatsd"
+)
+
+func TestFlush(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+
+	var buf bytes.Buffer
+
+	reporter := nozzlestatsd.New(&buf, noz, nozzlestatsd.Options{
+		Prefix: "test",
+		Tags:   []string{"env:ci", "dependency:fake"},
+	})
+
+	for i := range 4 {
+		noz.DoBool(func() (any, bool) {
+			return nil, i%2 == 0
+		})
+	}
+
+	tests := []string{
+		"test.flow_rate:100|g|#env:ci,dependency:fake\n" +
+			"test.failure_rate:50|g|#env:ci,dependency:fake\n" +
+			"test.allowed:4|c|#env:ci,dependency:fake\n" +
+			"test.blocked:0|c|#env:ci,dependency:fake",
+		"test.flow_rate:100|g|#env:ci,dependency:fake\n" +
+			"test.failure_rate:50|g|#env:ci,dependency:fake\n" +
+			"test.allowed:0|c|#env:ci,dependency:fake\n" +
+			"test.blocked:0|c|#env:ci,dependency:fake",
+	}
+
+	for i, expected := range tests {
+		buf.Reset()
+
+		if err := reporter.Flush(); err != nil {
+			t.Fatalf("flush=%d Expected err=nil Got=%v", i, err)
+		}
+
+		if got := buf.String(); got != expected {
+			t.Errorf("flush=%d Expected:\n%s\nGot:\n%s", i, expected, got)
+		}
+	}
+}
+
+func TestRunDefaultInterval(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+
+	var buf bytes.Buffer
+
+	// A zero Interval uses DefaultInterval, instead of panicking in time.NewTicker.
+	reporter := nozzlestatsd.New(&buf, noz, nozzlestatsd.Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reporter.Run(ctx)
+
+	if !strings.HasPrefix(buf.String(), nozzlestatsd.DefaultPrefix+".flow_rate:100|g") {
+		t.Errorf("Expected the final flush Got=%s", buf.String())
+	}
+}
@@ -0,0 +1,280 @@
+// Package nozzlewebhook POSTs a Nozzle's state changes as JSON to a URL.
+//
+// It is the lowest-common-denominator integration, for alerting or orchestration systems that cannot be reached from Go code directly.
+// Deliveries are retried with exponential backoff, rate limited, and signed with HMAC-SHA256 so the receiver can trust them.
+//
+// Example:
+//
+//	sink := nozzlewebhook.New(nozzlewebhook.Options{
+//		URL:    "https://alerts.example.com/hooks/nozzle",
+//		Name:   "payments",
+//		Secret: []byte(os.Getenv("NOZZLE_WEBHOOK_SECRET")),
+//	})
+//
+//	go sink.Run(ctx, noz)
+package nozzlewebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"golang.org/x/time/rate"
+)
+
+// SignatureHeader is the header that carries a delivery's signature, see Sign.
+const SignatureHeader = "X-Nozzle-Signature"
+
+// Defaults used when the Options field of the same name is zero.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = time.Second
+	DefaultTimeout     = 10 * time.Second
+)
+
+// buffer is how many state changes Run holds while a delivery is in progress.
+// When more pile up, the oldest are dropped, so the newest state is always delivered.
+const buffer = 16
+
+// ErrStatus is returned when the URL responds with a status other than 2xx.
+var ErrStatus = errors.New("nozzlewebhook: unexpected status")
+
+// Source is the part of a Nozzle a Sink reads from.
+// Every *nozzle.Nozzle[T] implements it, whatever its T.
+type Source interface {
+	Subscribe(buffer int) (<-chan nozzle.StateSnapshot, func())
+}
+
+// Options controls the behavior of a Sink.
+type Options struct {
+	// URL receives a POST for every state change.
+	URL string
+
+	// Name identifies the Nozzle in each Payload, so one URL can receive the changes of many Nozzles.
+	Name string
+
+	// Secret signs every delivery, see Sign.
+	// If empty, deliveries are not signed.
+	Secret []byte
+
+	// Client sends the deliveries.
+	// If nil, a client with a DefaultTimeout is used.
+	Client *http.Client
+
+	// MaxAttempts is how many times a delivery is attempted before it is given up, including the first attempt.
+	// Only network errors, 429, and 5xx responses are retried.
+	// If zero, DefaultMaxAttempts is used.
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry. It doubles after each retry.
+	// If zero, DefaultBackoff is used.
+	Backoff time.Duration
+
+	// Limit caps how many deliveries per second are sent, retries included, so a flapping Nozzle cannot flood the receiver.
+	// Example:
+	//
+	//	Limit: rate.Every(10 * time.Second),
+	//	Burst: 3,
+	//
+	// If zero, deliveries are not rate limited.
+	Limit rate.Limit
+
+	// Burst is how many deliveries may be sent at once under Limit.
+	// If zero, 1 is used.
+	Burst int
+
+	// OnError is called when a delivery is given up.
+	// Run keeps delivering after errors.
+	OnError func(error)
+}
+
+// Payload is the JSON body of every delivery.
+type Payload struct {
+	// Name is Options.Name.
+	Name string `json:"name,omitempty"`
+
+	// Time is when the state changed.
+	Time time.Time `json:"time"`
+
+	// State is the Nozzle's new state.
+	State nozzle.State `json:"state"`
+
+	// FlowRate is the Nozzle's new flow rate.
+	FlowRate int64 `json:"flowRate"`
+
+	// FailureRate is the failure rate of the interval that caused the change.
+	FailureRate int64 `json:"failureRate"`
+
+	// Reason explains the change.
+	Reason string `json:"reason"`
+
+	// Paused reports whether the flow rate is frozen, see nozzle.Pause.
+	Paused bool `json:"paused"`
+
+	// OverrideReason is the reason given for a manual override, if one is active.
+	OverrideReason string `json:"overrideReason,omitempty"`
+}
+
+// Sink delivers a Source's state changes to Options.URL.
+type Sink struct {
+	options Options
+
+	// limiter enforces Options.Limit, or is nil when it is not set.
+	limiter *rate.Limiter
+}
+
+// New creates a Sink that delivers to Options.URL.
+func New(options Options) *Sink {
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultBackoff
+	}
+
+	s := &Sink{options: options}
+
+	if options.Limit > 0 {
+		s.limiter = rate.NewLimiter(options.Limit, max(options.Burst, 1))
+	}
+
+	return s
+}
+
+// Run delivers every state change of source until ctx is done or source is closed.
+// Deliveries are sent one at a time; changes that pile up meanwhile are coalesced, so the newest state is always delivered.
+func (s *Sink) Run(ctx context.Context, source Source) {
+	changes, unsubscribe := source.Subscribe(buffer)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case snapshot, ok := <-changes:
+			if !ok {
+				return
+			}
+
+			if err := s.Send(ctx, snapshot); err != nil && s.options.OnError != nil {
+				s.options.OnError(err)
+			}
+		}
+	}
+}
+
+// Send delivers snapshot immediately, retrying as configured.
+// It returns the error of the last attempt, or ctx.Err() if ctx is done first.
+func (s *Sink) Send(ctx context.Context, snapshot nozzle.StateSnapshot) error {
+	body, err := json.Marshal(Payload{
+		Name:           s.options.Name,
+		Time:           snapshot.Time,
+		State:          snapshot.State,
+		FlowRate:       snapshot.FlowRate,
+		FailureRate:    snapshot.FailureRate,
+		Reason:         snapshot.Reason,
+		Paused:         snapshot.Paused,
+		OverrideReason: snapshot.OverrideReason,
+	})
+	if err != nil {
+		return fmt.Errorf("nozzlewebhook: encode: %w", err)
+	}
+
+	backoff := s.options.Backoff
+
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= s.options.MaxAttempts {
+			return fmt.Errorf("nozzlewebhook: gave up after %d attempts: %w", attempt, err)
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err() //nolint:wrapcheck // callers compare it to context errors.
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+// post makes one delivery attempt, and reports whether a failed one is worth retrying.
+func (s *Sink) post(ctx context.Context, body []byte) (bool, error) {
+	if s.limiter != nil {
+		if err := s.limiter.Wait(ctx); err != nil {
+			return false, fmt.Errorf("nozzlewebhook: rate limit: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("nozzlewebhook: request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(s.options.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(s.options.Secret, body))
+	}
+
+	res, err := s.options.Client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("nozzlewebhook: post: %w", err)
+	}
+
+	// Drained so the connection can be reused.
+	io.Copy(io.Discard, res.Body) //nolint:errcheck
+	res.Body.Close()              //nolint:errcheck
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+
+	return retry, fmt.Errorf("%w: %d", ErrStatus, res.StatusCode)
+}
+
+// Sign returns the signature of body under secret, as sent in SignatureHeader: "sha256=" followed by the hex HMAC-SHA256.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature, the value of SignatureHeader, signs body under secret.
+// Receivers should call it before trusting a delivery.
+//
+// Example:
+//
+//	body, _ := io.ReadAll(r.Body)
+//	if !nozzlewebhook.Verify(secret, body, r.Header.Get(nozzlewebhook.SignatureHeader)) {
+//		w.WriteHeader(http.StatusUnauthorized)
+//		return
+//	}
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
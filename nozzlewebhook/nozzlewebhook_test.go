@@ -0,0 +1,169 @@
+package nozzlewebhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+	"github.com/justindfuller/nozzle/nozzlewebhook"
+)
+
+// subscribed is a Source that reports when Run has subscribed, since changes before then are not delivered.
+type subscribed struct {
+	*nozzle.Nozzle[any]
+
+	ready chan struct{}
+}
+
+func (s *subscribed) Subscribe(buffer int) (<-chan nozzle.StateSnapshot, func()) {
+	defer close(s.ready)
+
+	return s.Nozzle.Subscribe(buffer)
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	deliveries := make(chan nozzlewebhook.Payload, 1)
+
+	var attempts atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !nozzlewebhook.Verify(secret, body, r.Header.Get(nozzlewebhook.SignatureHeader)) {
+			t.Errorf("Expected a valid signature Got=%q", r.Header.Get(nozzlewebhook.SignatureHeader))
+		}
+
+		// Fail the first attempt, so the delivery is retried.
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		var payload nozzlewebhook.Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Error(err)
+		}
+
+		deliveries <- payload
+	}))
+	defer server.Close()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+
+	sink := nozzlewebhook.New(nozzlewebhook.Options{
+		URL:     server.URL,
+		Name:    "payments",
+		Secret:  secret,
+		Backoff: time.Millisecond,
+		OnError: func(err error) {
+			t.Error(err)
+		},
+	})
+
+	source := &subscribed{Nozzle: noz, ready: make(chan struct{})}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		sink.Run(context.Background(), source)
+	}()
+
+	<-source.ready
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	if payload := <-deliveries; payload.Name != "payments" || payload.State != nozzle.ForcedClosed || payload.OverrideReason != "maintenance" {
+		t.Errorf("Expected Name=payments State=%s OverrideReason=maintenance Got=%+v", nozzle.ForcedClosed, payload)
+	}
+
+	if a := attempts.Load(); a != 2 {
+		t.Errorf("Expected attempts=2 Got=%d", a)
+	}
+
+	if err := noz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	<-done
+}
+
+func TestSend(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status   int
+		attempts int64
+	}{
+		{status: http.StatusOK, attempts: 1},
+		{status: http.StatusBadRequest, attempts: 1},
+		{status: http.StatusTooManyRequests, attempts: 3},
+		{status: http.StatusInternalServerError, attempts: 3},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int64
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			sink := nozzlewebhook.New(nozzlewebhook.Options{
+				URL:     server.URL,
+				Backoff: time.Millisecond,
+			})
+
+			err := sink.Send(context.Background(), nozzle.StateSnapshot{State: nozzle.Closing})
+
+			if ok := test.status == http.StatusOK; ok != (err == nil) || !ok && !errors.Is(err, nozzlewebhook.ErrStatus) {
+				t.Errorf("Expected status=%d to return err=%v Got=%v", test.status, nozzlewebhook.ErrStatus, err)
+			}
+
+			if a := attempts.Load(); a != test.attempts {
+				t.Errorf("Expected attempts=%d Got=%d", test.attempts, a)
+			}
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"state":"closing"}`)
+	signature := nozzlewebhook.Sign([]byte("secret"), body)
+
+	if !nozzlewebhook.Verify([]byte("secret"), body, signature) {
+		t.Error("Expected the signature to verify")
+	}
+
+	if nozzlewebhook.Verify([]byte("other"), body, signature) {
+		t.Error("Expected a different secret not to verify")
+	}
+
+	if nozzlewebhook.Verify([]byte("secret"), []byte(`{"state":"opening"}`), signature) {
+		t.Error("Expected a different body not to verify")
+	}
+}
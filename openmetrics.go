@@ -0,0 +1,88 @@
+package nozzle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// states are every State, in the order WriteOpenMetrics lists them.
+var states = []State{Opening, Closing, ForcedOpen, ForcedClosed}
+
+// WriteOpenMetrics writes the Nozzle's gauges and counters to w in the OpenMetrics text format.
+// It lets a minimal binary serve /metrics by hand, without a Prometheus client library.
+// The exposition is complete, ending with "# EOF", so write one Nozzle per response.
+//
+// Example:
+//
+//	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+//		n.WriteOpenMetrics(w)
+//	})
+//
+// Example output:
+//
+//	# TYPE nozzle_flow_rate gauge
+//	# HELP nozzle_flow_rate Percentage of calls the Nozzle allows.
+//	nozzle_flow_rate 100
+//	...
+//	# EOF
+func (n *Nozzle[T]) WriteOpenMetrics(w io.Writer) error {
+	stats := n.Stats()
+	state := n.State()
+
+	var buf bytes.Buffer
+
+	gauge := func(name, help string, value any) {
+		fmt.Fprintf(&buf, "# TYPE nozzle_%s gauge\n# HELP nozzle_%s %s\nnozzle_%s %v\n", name, name, help, name, value)
+	}
+
+	counter := func(name, help string, value int64) {
+		fmt.Fprintf(&buf, "# TYPE nozzle_%s counter\n# HELP nozzle_%s %s\nnozzle_%s_total %d\n", name, name, help, name, value)
+	}
+
+	gauge("flow_rate", "Percentage of calls the Nozzle allows.", n.FlowRate())
+	gauge("failure_rate", "Percentage of allowed calls that failed in the current interval.", n.FailureRate())
+
+	fmt.Fprint(&buf, "# TYPE nozzle_state stateset\n# HELP nozzle_state State of the Nozzle.\n")
+
+	for _, s := range states {
+		var value int
+		if s == state {
+			value = 1
+		}
+
+		fmt.Fprintf(&buf, "nozzle_state{nozzle_state=%q} %d\n", s, value)
+	}
+
+	fmt.Fprintf(
+		&buf,
+		"# TYPE nozzle_calculate_duration_seconds gauge\n# UNIT nozzle_calculate_duration_seconds seconds\n# HELP nozzle_calculate_duration_seconds Time the last interval end took.\nnozzle_calculate_duration_seconds %g\n",
+		n.Metrics().CalculateDuration.Seconds(),
+	)
+
+	counter("allowed", "Calls the Nozzle allowed.", stats.Allowed)
+	counter("blocked", "Calls the Nozzle blocked.", stats.Blocked)
+	counter("successes", "Allowed calls that succeeded.", stats.Successes)
+	counter("failures", "Allowed calls that failed.", stats.Failures)
+	counter("caller_canceled", "Allowed calls that ended because the caller's context was done.", stats.CallerCanceled)
+	counter("bulkhead_blocked", "Calls blocked because MaxConcurrent calls were already running.", stats.BulkheadBlocked)
+	counter("retries", "Retries attempted.", stats.Retries)
+	counter("state_changes", "Times the Nozzle changed direction between opening and closing.", stats.StateChanges)
+	counter("reservations_leaked", "Allowed Reservations that did not end within ReservationTTL intervals.", stats.ReservationsLeaked)
+	counter("unmatched_reports", "Outcomes reported while no admitted call was running.", stats.UnmatchedReports)
+
+	fmt.Fprintf(
+		&buf,
+		"# TYPE nozzle_reduced_flow_seconds counter\n# UNIT nozzle_reduced_flow_seconds seconds\n# HELP nozzle_reduced_flow_seconds Time the flow rate was below 100.\nnozzle_reduced_flow_seconds_total %g\n",
+		stats.ReducedFlowTime.Seconds(),
+	)
+
+	buf.WriteString("# EOF\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("nozzle: write open metrics: %w", err)
+	}
+
+	return nil
+}
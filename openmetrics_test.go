@@ -0,0 +1,86 @@
+package nozzle_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	for i := range 4 {
+		noz.DoBool(func() (any, bool) { return nil, i > 0 })
+	}
+
+	var buf bytes.Buffer
+
+	if err := noz.WriteOpenMetrics(&buf); err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	expected := `# TYPE nozzle_flow_rate gauge
+# HELP nozzle_flow_rate Percentage of calls the Nozzle allows.
+nozzle_flow_rate 100
+# TYPE nozzle_failure_rate gauge
+# HELP nozzle_failure_rate Percentage of allowed calls that failed in the current interval.
+nozzle_failure_rate 25
+# TYPE nozzle_state stateset
+# HELP nozzle_state State of the Nozzle.
+nozzle_state{nozzle_state="opening"} 1
+nozzle_state{nozzle_state="closing"} 0
+nozzle_state{nozzle_state="forced-open"} 0
+nozzle_state{nozzle_state="forced-closed"} 0
+# TYPE nozzle_calculate_duration_seconds gauge
+# UNIT nozzle_calculate_duration_seconds seconds
+# HELP nozzle_calculate_duration_seconds Time the last interval end took.
+nozzle_calculate_duration_seconds 0
+# TYPE nozzle_allowed counter
+# HELP nozzle_allowed Calls the Nozzle allowed.
+nozzle_allowed_total 4
+# TYPE nozzle_blocked counter
+# HELP nozzle_blocked Calls the Nozzle blocked.
+nozzle_blocked_total 0
+# TYPE nozzle_successes counter
+# HELP nozzle_successes Allowed calls that succeeded.
+nozzle_successes_total 3
+# TYPE nozzle_failures counter
+# HELP nozzle_failures Allowed calls that failed.
+nozzle_failures_total 1
+# TYPE nozzle_caller_canceled counter
+# HELP nozzle_caller_canceled Allowed calls that ended because the caller's context was done.
+nozzle_caller_canceled_total 0
+# TYPE nozzle_bulkhead_blocked counter
+# HELP nozzle_bulkhead_blocked Calls blocked because MaxConcurrent calls were already running.
+nozzle_bulkhead_blocked_total 0
+# TYPE nozzle_retries counter
+# HELP nozzle_retries Retries attempted.
+nozzle_retries_total 0
+# TYPE nozzle_state_changes counter
+# HELP nozzle_state_changes Times the Nozzle changed direction between opening and closing.
+nozzle_state_changes_total 0
+# TYPE nozzle_reservations_leaked counter
+# HELP nozzle_reservations_leaked Allowed Reservations that did not end within ReservationTTL intervals.
+nozzle_reservations_leaked_total 0
+# TYPE nozzle_unmatched_reports counter
+# HELP nozzle_unmatched_reports Outcomes reported while no admitted call was running.
+nozzle_unmatched_reports_total 0
+# TYPE nozzle_reduced_flow_seconds counter
+# UNIT nozzle_reduced_flow_seconds seconds
+# HELP nozzle_reduced_flow_seconds Time the flow rate was below 100.
+nozzle_reduced_flow_seconds_total 0
+# EOF
+`
+
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,208 @@
+package nozzle
+
+import "time"
+
+// ForceCloseFor blocks every call for the given duration, regardless of the failure rate.
+// Use it for planned maintenance on the dependency, so the outage is not learned as organic failure.
+//
+// The override expires on its own, so it cannot be forgotten.
+// While it is active, State reports ForcedClosed, FlowRate reports 0, and the reason and remaining time are included in snapshots.
+// Starting and ending the override are reported to Options.OnEvent.
+// Calling ForceCloseFor or ForceOpenFor again replaces the current override, and Unforce ends it early.
+// A duration that is not positive does nothing.
+//
+// When the override expires, the Nozzle resumes adapting from the flow rate it had before the override.
+//
+// Example:
+//
+//	n.ForceCloseFor(15*time.Minute, "database failover CHG-1234")
+func (n *Nozzle[T]) ForceCloseFor(d time.Duration, reason string) {
+	if d <= 0 {
+		return
+	}
+
+	n.force(ForcedClosed, d, reason)
+}
+
+// ForceOpenFor allows every call for the given duration, regardless of the failure rate.
+// Use it when you know failures are expected and harmless, such as while a dependency is being verified after a deploy.
+//
+// It behaves like ForceCloseFor, except that State reports ForcedOpen and FlowRate reports 100.
+//
+// Example:
+//
+//	n.ForceOpenFor(5*time.Minute, "verifying payments rollout")
+func (n *Nozzle[T]) ForceOpenFor(d time.Duration, reason string) {
+	if d <= 0 {
+		return
+	}
+
+	n.force(ForcedOpen, d, reason)
+}
+
+// ForceClose blocks every call until Unforce is called, regardless of the failure rate.
+// It is ForceCloseFor without an expiry, for incidents whose end is not known in advance.
+// Prefer ForceCloseFor when you can, since an override that never expires can be forgotten.
+//
+// Example:
+//
+//	n.ForceClose("payments incident INC-42")
+//	defer n.Unforce()
+func (n *Nozzle[T]) ForceClose(reason string) {
+	n.force(ForcedClosed, 0, reason)
+}
+
+// ForceOpen allows every call until Unforce is called, regardless of the failure rate.
+// It is ForceOpenFor without an expiry.
+//
+// Example:
+//
+//	n.ForceOpen("failures are expected during the migration")
+func (n *Nozzle[T]) ForceOpen(reason string) {
+	n.force(ForcedOpen, 0, reason)
+}
+
+// Unforce ends the active manual override, whether or not it expires, and resumes adapting from the flow rate the Nozzle had before it.
+// The end of the override is reported to Options.OnEvent.
+// It does nothing when no override is active.
+//
+// Example:
+//
+//	n.Unforce()
+func (n *Nozzle[T]) Unforce() {
+	if n.passThrough() {
+		return
+	}
+
+	n.mut.Lock()
+
+	if n.override == "" {
+		n.mut.Unlock()
+
+		return
+	}
+
+	report := n.setOverride("", 0, "")
+
+	n.mut.Unlock()
+
+	report()
+}
+
+// force starts an override, ending the current one, if any, including one that expired but calculate has not cleared yet.
+// A zero duration means the override never expires.
+func (n *Nozzle[T]) force(state State, d time.Duration, reason string) {
+	if n.passThrough() {
+		return
+	}
+
+	n.mut.Lock()
+	report := n.setOverride(state, d, reason)
+	n.mut.Unlock()
+
+	report()
+}
+
+// setOverride ends the current override, if any, then starts one pinning the Nozzle to state for d, unless state is empty.
+// An override that expired is ended too, even before calculate clears it, so its end is still reported.
+// It returns a function that reports the changes to Options.OnEvent and Options.Audit, and calls OnFullyClosed or OnFullyOpened.
+// The caller must hold the lock, and call the returned function after releasing it.
+func (n *Nozzle[T]) setOverride(state State, d time.Duration, reason string) func() {
+	now := n.now()
+
+	var ended Event
+
+	replaced := n.override != ""
+	actor := ActorOperator
+
+	if replaced {
+		if n.forced() == "" {
+			actor = ActorNozzle
+		}
+
+		ended = n.endOverride()
+	}
+
+	if state != "" {
+		n.override = state
+		n.overrideReason = reason
+
+		if d > 0 {
+			n.overrideUntil = now.Add(d)
+		}
+	}
+
+	flowRate := n.effectiveFlowRate()
+	hook := n.edge(flowRate)
+
+	n.publish()
+
+	return func() {
+		if replaced {
+			n.emit(ended)
+			n.audit(actor, ended, flowRate)
+		}
+
+		if state != "" {
+			started := Event{
+				Type:     EventOverrideStarted,
+				Time:     now,
+				State:    state,
+				Reason:   reason,
+				Duration: d,
+			}
+
+			n.emit(started)
+			n.audit(ActorOperator, started, flowRate)
+		}
+
+		if hook != nil {
+			hook()
+		}
+	}
+}
+
+// endOverride clears the current override and returns the Event describing its end.
+// The caller must hold the lock, and emit the Event after releasing it.
+func (n *Nozzle[T]) endOverride() Event {
+	ended := Event{
+		Type:   EventOverrideEnded,
+		Time:   n.now(),
+		State:  n.override,
+		Reason: n.overrideReason,
+	}
+
+	n.override = ""
+	n.overrideReason = ""
+	n.overrideUntil = time.Time{}
+
+	return ended
+}
+
+// forced reports the state of the active override, or an empty State when there is none.
+// An expired override is no longer active, even before calculate clears it.
+// The caller must hold the lock.
+func (n *Nozzle[T]) forced() State {
+	if n.override == "" {
+		return ""
+	}
+
+	if !n.overrideUntil.IsZero() && !n.now().Before(n.overrideUntil) {
+		return ""
+	}
+
+	return n.override
+}
+
+// effectiveFlowRate reports the flow rate callers experience, accounting for an active override.
+// The caller must hold the lock.
+func (n *Nozzle[T]) effectiveFlowRate() int64 {
+	switch n.forced() {
+	case ForcedOpen:
+		return 100
+	case ForcedClosed:
+		return 0
+	default:
+		return n.engine.FlowRate()
+	}
+}
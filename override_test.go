@@ -0,0 +1,218 @@
+package nozzle_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestForceCloseFor(t *testing.T) {
+	t.Parallel()
+
+	var mut sync.Mutex
+	var events []nozzle.Event
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+		OnEvent: func(e nozzle.Event) {
+			if e.Type == nozzle.EventCompatLevel {
+				return
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			events = append(events, e)
+		},
+	})
+
+	noz.ForceCloseFor(time.Millisecond*50, "maintenance")
+
+	if s := noz.State(); s != nozzle.ForcedClosed {
+		t.Errorf("Expected State=%s Got=%s", nozzle.ForcedClosed, s)
+	}
+
+	if fr := noz.FlowRate(); fr != 0 {
+		t.Errorf("Expected FlowRate=0 Got=%d", fr)
+	}
+
+	if _, err := noz.DoError(func() (any, error) { return nil, nil }); !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrBlocked, err)
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	noz.Wait()
+
+	if s := noz.State(); s != nozzle.Opening {
+		t.Errorf("Expected State=%s Got=%s", nozzle.Opening, s)
+	}
+
+	if fr := noz.FlowRate(); fr != 100 {
+		t.Errorf("Expected FlowRate=100 Got=%d", fr)
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events Got=%d", len(events))
+	}
+
+	if e := events[0]; e.Type != nozzle.EventOverrideStarted || e.State != nozzle.ForcedClosed || e.Reason != "maintenance" || e.Duration != time.Millisecond*50 {
+		t.Errorf("Unexpected start event=%+v", e)
+	}
+
+	if e := events[1]; e.Type != nozzle.EventOverrideEnded || e.State != nozzle.ForcedClosed || e.Reason != "maintenance" {
+		t.Errorf("Unexpected end event=%+v", e)
+	}
+}
+
+func TestForceOpenFor(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		InitialFlowRate:       1,
+	})
+
+	noz.ForceOpenFor(time.Hour, "verifying rollout")
+
+	for range 10 {
+		if _, ok, blocked := noz.DoBool2(func() (any, bool) { return nil, true }); !ok || blocked {
+			t.Fatalf("Expected call to be allowed Got ok=%v blocked=%v", ok, blocked)
+		}
+	}
+
+	if s := noz.State(); s != nozzle.ForcedOpen {
+		t.Errorf("Expected State=%s Got=%s", nozzle.ForcedOpen, s)
+	}
+
+	noz.ForceOpenFor(0, "ignored")
+
+	if s := noz.State(); s != nozzle.ForcedOpen {
+		t.Errorf("Expected State=%s Got=%s", nozzle.ForcedOpen, s)
+	}
+}
+
+func TestUnforce(t *testing.T) {
+	t.Parallel()
+
+	var events []nozzle.Event
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		OnEvent: func(e nozzle.Event) {
+			if e.Type != nozzle.EventCompatLevel {
+				events = append(events, e)
+			}
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	noz.ForceClose("incident INC-42")
+
+	if s := noz.Snapshot(); s.State != nozzle.ForcedClosed || s.OverrideRemaining != 0 || s.OverrideReason != "incident INC-42" {
+		t.Errorf("Expected State=%s OverrideRemaining=0 Got=%+v", nozzle.ForcedClosed, s)
+	}
+
+	if _, _, blocked := noz.DoBool2(func() (any, bool) { return nil, true }); !blocked {
+		t.Error("Expected call to be blocked")
+	}
+
+	noz.ForceOpen("migration")
+
+	if s := noz.State(); s != nozzle.ForcedOpen {
+		t.Errorf("Expected State=%s Got=%s", nozzle.ForcedOpen, s)
+	}
+
+	noz.Unforce()
+	noz.Unforce()
+
+	if s := noz.Snapshot(); s.State != nozzle.Opening || s.FlowRate != 100 || s.OverrideReason != "" {
+		t.Errorf("Expected State=%s FlowRate=100 after Unforce Got=%+v", nozzle.Opening, s)
+	}
+
+	expected := []nozzle.EventType{
+		nozzle.EventOverrideStarted,
+		nozzle.EventOverrideEnded,
+		nozzle.EventOverrideStarted,
+		nozzle.EventOverrideEnded,
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events Got=%d", len(expected), len(events))
+	}
+
+	for i, e := range events {
+		if e.Type != expected[i] {
+			t.Errorf("event=%d Expected Type=%s Got=%s", i, expected[i], e.Type)
+		}
+	}
+}
+
+// auditLog is an AuditSink that keeps every entry.
+type auditLog struct {
+	mut     sync.Mutex
+	entries []nozzle.AuditEntry
+}
+
+func (l *auditLog) Audit(e nozzle.AuditEntry) error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.entries = append(l.entries, e)
+
+	return nil
+}
+
+func TestForceReplacesExpiredOverride(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	audit := &auditLog{}
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+		Audit:                 audit,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	noz.ForceCloseFor(time.Minute, "maintenance")
+
+	// The override expires, but no interval ends to clear it.
+	clock.Advance(time.Minute * 2)
+
+	noz.ForceOpen("verifying")
+
+	audit.mut.Lock()
+	defer audit.mut.Unlock()
+
+	var overrides []nozzle.AuditEntry
+
+	for _, e := range audit.entries {
+		if e.Type == nozzle.EventOverrideStarted || e.Type == nozzle.EventOverrideEnded {
+			overrides = append(overrides, e)
+		}
+	}
+
+	if len(overrides) != 3 {
+		t.Fatalf("Expected 3 override entries Got=%+v", overrides)
+	}
+
+	if e := overrides[1]; e.Type != nozzle.EventOverrideEnded || e.State != nozzle.ForcedClosed || e.Reason != "maintenance" || e.Actor != nozzle.ActorNozzle {
+		t.Errorf("Expected the expired override to end by %s Got=%+v", nozzle.ActorNozzle, e)
+	}
+
+	if e := overrides[2]; e.Type != nozzle.EventOverrideStarted || e.State != nozzle.ForcedOpen || e.Actor != nozzle.ActorOperator {
+		t.Errorf("Expected the new override to start by %s Got=%+v", nozzle.ActorOperator, e)
+	}
+}
@@ -0,0 +1,108 @@
+package nozzle
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicPolicy decides what a Nozzle does when a callback it admitted panics.
+// Whatever the policy, the call stops counting as running, so the panic cannot leak a slot of Options.MaxConcurrent.
+//
+// A panic is panicked again with its original value, so code above the Nozzle that recovers it, such as net/http
+// checking for http.ErrAbortHandler, sees what the callback panicked with. The stack of the second panic only reaches
+// the Nozzle; Options.OnPanic receives a *PanicError with the stack where the callback panicked.
+type PanicPolicy int
+
+const (
+	// PanicRethrow counts the call as a failure, then panics again with the same value.
+	// It is the zero value, so a panic still crashes the program unless something above the Nozzle recovers it.
+	PanicRethrow PanicPolicy = iota
+
+	// PanicRecover counts the call as a failure, and reports the panic as the call's result:
+	// a *PanicError from DoError and DoErrorContext, and false from DoBool and DoBoolContext.
+	PanicRecover
+
+	// PanicUncounted panics again with the same value without counting the call as a success or a failure, for programs that treat panics as bugs rather than signs of an unhealthy dependency.
+	PanicUncounted
+)
+
+// PanicError is a panic recovered from a callback, see Options.PanicPolicy.
+type PanicError struct {
+	// Value is the value the callback panicked with.
+	Value any
+
+	// Stack is the stack trace of the panic.
+	Stack []byte
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("nozzle: callback panicked: %v", e.Value)
+}
+
+// Unwrap returns Value, if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+
+	return err
+}
+
+// guard runs an admitted callback that weighs weight, and handles a panic in it according to Options.PanicPolicy.
+// It returns the PanicError of a recovered panic, whose outcome is already recorded, or nil if run returned.
+// If run exits its goroutine with runtime.Goexit, such as through t.FailNow, its slot is freed without counting an outcome.
+// The caller must not hold the lock.
+func (n *Nozzle[T]) guard(weight int64, run func()) (p *PanicError) {
+	// Set once run returns, since recover cannot tell runtime.Goexit from returning.
+	returned := false
+
+	defer func() {
+		if returned {
+			return
+		}
+
+		value := recover()
+		if value == nil {
+			n.mut.Lock()
+			n.finished()
+			n.mut.Unlock()
+
+			return
+		}
+
+		// Captured here, before the stack unwinds, so it still shows where the callback panicked.
+		p = &PanicError{Value: value, Stack: debug.Stack()}
+
+		n.panicked(p, weight)
+
+		if n.options().PanicPolicy != PanicRecover {
+			panic(value)
+		}
+	}()
+
+	run()
+
+	returned = true
+
+	return nil
+}
+
+// panicked records the outcome of a call that panicked, and reports it to Options.OnPanic.
+func (n *Nozzle[T]) panicked(p *PanicError, weight int64) {
+	n.mut.Lock()
+
+	n.totals.Panics++
+
+	if n.options().PanicPolicy == PanicUncounted {
+		n.finished()
+	}
+
+	n.mut.Unlock()
+
+	if n.options().PanicPolicy != PanicUncounted {
+		n.failure(weight)
+	}
+
+	if n.options().OnPanic != nil {
+		n.options().OnPanic(p)
+	}
+}
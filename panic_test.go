@@ -0,0 +1,155 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestPanicPolicy(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	calls := map[string]func(n *nozzle.Nozzle[any]) error{
+		"DoError": func(n *nozzle.Nozzle[any]) error {
+			_, err := n.DoError(func() (any, error) { panic(errBoom) })
+
+			return err
+		},
+		"DoErrorContext": func(n *nozzle.Nozzle[any]) error {
+			_, err := n.DoErrorContext(context.Background(), func(context.Context) (any, error) { panic(errBoom) })
+
+			return err
+		},
+		"DoBool": func(n *nozzle.Nozzle[any]) error {
+			if _, ok := n.DoBool(func() (any, bool) { panic(errBoom) }); ok {
+				return nil
+			}
+
+			return errBoom
+		},
+		"DoBoolContext": func(n *nozzle.Nozzle[any]) error {
+			if _, ok := n.DoBoolContext(context.Background(), func(context.Context) (any, bool) { panic(errBoom) }); ok {
+				return nil
+			}
+
+			return errBoom
+		},
+	}
+
+	tests := []struct {
+		policy   nozzle.PanicPolicy
+		rethrown bool
+		failures int64
+	}{
+		{policy: nozzle.PanicRethrow, rethrown: true, failures: 1},
+		{policy: nozzle.PanicRecover, rethrown: false, failures: 1},
+		{policy: nozzle.PanicUncounted, rethrown: true, failures: 0},
+	}
+
+	for i, test := range tests {
+		for name, call := range calls {
+			t.Run(fmt.Sprintf("test=%d/%s", i, name), func(t *testing.T) {
+				t.Parallel()
+
+				var reported atomic.Int64
+
+				noz := nozzle.New(nozzle.Options[any]{
+					Interval:              time.Hour,
+					AllowedFailurePercent: 50,
+					MaxConcurrent:         1,
+					PanicPolicy:           test.policy,
+					OnPanic: func(p *nozzle.PanicError) {
+						if !errors.Is(p, errBoom) || !strings.Contains(string(p.Stack), "panic_test.go") {
+							t.Errorf("Expected a PanicError wrapping %v with a stack Got=%v", errBoom, p)
+						}
+
+						reported.Add(1)
+					},
+				})
+				defer noz.Close() //nolint:errcheck
+
+				var recovered any
+				var err error
+
+				func() {
+					defer func() {
+						recovered = recover()
+					}()
+
+					err = call(noz)
+				}()
+
+				if rethrown := recovered != nil; rethrown != test.rethrown {
+					t.Errorf("Expected rethrown=%t Got=%t", test.rethrown, rethrown)
+				}
+
+				// Exactly the original value, so recoverers above the Nozzle can compare it.
+				if recovered != nil && recovered != errBoom { //nolint:errorlint,err113 // comparing the panic value itself.
+					t.Errorf("Expected the panic value to be %v Got=%v", errBoom, recovered)
+				}
+
+				var p *nozzle.PanicError
+				if !test.rethrown && strings.HasPrefix(name, "DoError") && !errors.As(err, &p) {
+					t.Errorf("Expected err=*nozzle.PanicError Got=%v", err)
+				}
+
+				s := noz.Stats()
+				if s.Panics != 1 || s.Failures != test.failures || s.Successes != 0 {
+					t.Errorf("Expected Panics=1 Failures=%d Successes=0 Got Panics=%d Failures=%d Successes=%d", test.failures, s.Panics, s.Failures, s.Successes)
+				}
+
+				if reported.Load() != 1 {
+					t.Errorf("Expected OnPanic to be called once Got=%d", reported.Load())
+				}
+
+				// The panicking call released its slot, so MaxConcurrent still admits the next call.
+				if _, err := noz.DoError(func() (any, error) { return nil, nil }); err != nil {
+					t.Errorf("Expected the next call to be admitted Got=%v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestPanicGoexit(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		MaxConcurrent:         1,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	exited := make(chan struct{})
+
+	// Such as t.FailNow inside a callback.
+	go func() {
+		defer close(exited)
+
+		noz.DoError(func() (any, error) { //nolint:errcheck
+			runtime.Goexit()
+
+			return nil, nil
+		})
+	}()
+
+	<-exited
+
+	if _, err := noz.DoError(func() (any, error) { return nil, nil }); err != nil {
+		t.Errorf("Expected the exited call to release its slot Got=%v", err)
+	}
+
+	if s := noz.Stats(); s.Panics != 0 || s.Failures != 0 || s.Successes != 1 {
+		t.Errorf("Expected Panics=0 Failures=0 Successes=1 Got Panics=%d Failures=%d Successes=%d", s.Panics, s.Failures, s.Successes)
+	}
+}
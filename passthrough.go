@@ -0,0 +1,46 @@
+package nozzle
+
+import "sync/atomic"
+
+// passThroughs counts the calls let through by Nozzles that are nil or were not created with New.
+// See PassThroughs for usage.
+var passThroughs atomic.Int64
+
+// PassThroughs reports how many calls were let through, since the program started, by Nozzles that are nil or were not created with New.
+//
+// Such a Nozzle is a pass-through: it allows every call and runs every callback, without recording anything.
+// Every method is safe to call. Its FlowRate and SuccessRate are 100, its State is Opening, and its Stats, History, and other reports are empty.
+// Methods that change it, such as ForceClose, Pause, or Close, do nothing. It never ticks, so WaitSnapshot returns ErrClosed and Subscribe returns a closed channel.
+// This lets structs hold a *Nozzle that is only assigned later in startup, without guarding every call against nil.
+//
+// A value above zero once startup is complete usually means a Nozzle is used before it is assigned, or was never assigned.
+//
+// Example:
+//
+//	if n := nozzle.PassThroughs(); n > 0 {
+//		slog.Warn("calls bypassed an uninitialized nozzle", "calls", n)
+//	}
+func PassThroughs() int64 {
+	return passThroughs.Load()
+}
+
+// passThrough reports whether the Nozzle is nil or was not created with New, and so must let every call through.
+func (n *Nozzle[T]) passThrough() bool {
+	return n == nil || n.engine == nil
+}
+
+// passedThrough is like passThrough, but also counts the call in PassThroughs when it is let through.
+func (n *Nozzle[T]) passedThrough() bool {
+	if !n.passThrough() {
+		return false
+	}
+
+	passThroughs.Add(1)
+
+	return true
+}
+
+// passThroughSnapshot is the StateSnapshot of a pass-through Nozzle.
+func passThroughSnapshot() StateSnapshot {
+	return StateSnapshot{FlowRate: 100, State: Opening, SuccessRate: 100}
+}
@@ -0,0 +1,158 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestPassThrough(t *testing.T) {
+	t.Parallel()
+
+	nozzles := map[string]*nozzle.Nozzle[int]{
+		"nil":  nil,
+		"zero": {},
+	}
+
+	for name, noz := range nozzles {
+		before := nozzle.PassThroughs()
+
+		if res, ok := noz.DoBool(func() (int, bool) { return 1, false }); res != 1 || ok {
+			t.Errorf("%s Expected DoBool to return the callback's res=1 ok=false Got res=%d ok=%t", name, res, ok)
+		}
+
+		if res, err := noz.DoError(func() (int, error) { return 2, nil }); res != 2 || err != nil {
+			t.Errorf("%s Expected DoError to return the callback's res=2 err=nil Got res=%d err=%v", name, res, err)
+		}
+
+		if res, err := noz.DoErrorContext(context.Background(), func(context.Context) (int, error) { return 3, nil }); res != 3 || err != nil {
+			t.Errorf("%s Expected DoErrorContext to return the callback's res=3 err=nil Got res=%d err=%v", name, res, err)
+		}
+
+		if res, err := noz.DoWaitContext(context.Background(), func(context.Context) (int, error) { return 4, nil }); res != 4 || err != nil {
+			t.Errorf("%s Expected DoWaitContext to return the callback's res=4 err=nil Got res=%d err=%v", name, res, err)
+		}
+
+		if !noz.Allow() {
+			t.Errorf("%s Expected Allow=true", name)
+		}
+
+		noz.ReportSuccess()
+		noz.ReportFailure()
+
+		r := noz.Reserve()
+		if !r.OK() {
+			t.Errorf("%s Expected Reserve to be allowed", name)
+		}
+
+		r.Commit(nozzle.Failure)
+		r.Cancel()
+
+		if after := nozzle.PassThroughs(); after-before != 6 {
+			t.Errorf("%s Expected PassThroughs to grow by 6 Got=%d", name, after-before)
+		}
+
+		// Counted too, so after the PassThroughs check.
+		if err := noz.Do(func() error { return nil }); err != nil {
+			t.Errorf("%s Expected Do to return nil Got=%v", name, err)
+		}
+
+		if err := noz.DoContext(context.Background(), func(context.Context) error { return nil }); err != nil {
+			t.Errorf("%s Expected DoContext to return nil Got=%v", name, err)
+		}
+
+		g, _ := nozzle.Group(context.Background(), noz)
+
+		var ran atomic.Bool
+
+		if !g.Go(func(context.Context) error { ran.Store(true); return nil }) {
+			t.Errorf("%s Expected Group to launch every function", name)
+		}
+
+		if err := g.Wait(); err != nil || !ran.Load() || g.Skipped() != 0 {
+			t.Errorf("%s Expected the function to run Got err=%v ran=%t Skipped=%d", name, err, ran.Load(), g.Skipped())
+		}
+
+		if f, s := noz.FlowRate(), noz.State(); f != 100 || s != nozzle.Opening {
+			t.Errorf("%s Expected FlowRate=100 State=%s Got FlowRate=%d State=%s", name, nozzle.Opening, f, s)
+		}
+
+		if err := noz.Close(); err != nil {
+			t.Errorf("%s Expected Close to return nil Got=%v", name, err)
+		}
+	}
+}
+
+func TestPassThroughMethods(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ok   func(n *nozzle.Nozzle[int]) bool
+	}{
+		{name: "SuccessRate", ok: func(n *nozzle.Nozzle[int]) bool { return n.SuccessRate() == 100 }},
+		{name: "FailureRate", ok: func(n *nozzle.Nozzle[int]) bool { return n.FailureRate() == 0 }},
+		{name: "Reason", ok: func(n *nozzle.Nozzle[int]) bool { return n.Reason() == "" }},
+		{name: "Stats", ok: func(n *nozzle.Nozzle[int]) bool { return n.Stats() == nozzle.Stats{} }},
+		{name: "ReentrantCalls", ok: func(n *nozzle.Nozzle[int]) bool { return n.ReentrantCalls() == 0 }},
+		{name: "RuntimeStats", ok: func(n *nozzle.Nozzle[int]) bool { return n.RuntimeStats() == nozzle.RuntimeStats{} }},
+		{name: "DescribeConfig", ok: func(n *nozzle.Nozzle[int]) bool { return n.DescribeConfig().Interval == 0 }},
+		{name: "Fairness", ok: func(n *nozzle.Nozzle[int]) bool { return n.Fairness().JainIndex == 1 }},
+		{name: "Metrics", ok: func(n *nozzle.Nozzle[int]) bool { return n.Metrics() == nozzle.Metrics{} }},
+		{name: "History", ok: func(n *nozzle.Nozzle[int]) bool { return n.History() == nil }},
+		{name: "HistoryChart", ok: func(n *nozzle.Nozzle[int]) bool { return n.HistoryChart(10) == "" }},
+		{name: "EstimatedReopen", ok: func(n *nozzle.Nozzle[int]) bool { d, ok := n.EstimatedReopen(100); return d == 0 && ok }},
+		{name: "Snapshot", ok: func(n *nozzle.Nozzle[int]) bool { return n.Snapshot().FlowRate == 100 }},
+		{name: "SnapshotNow", ok: func(n *nozzle.Nozzle[int]) bool { return n.SnapshotNow().State == nozzle.Opening }},
+		{name: "WaitSnapshot", ok: func(n *nozzle.Nozzle[int]) bool {
+			_, err := n.WaitSnapshot(context.Background())
+
+			return errors.Is(err, nozzle.ErrClosed)
+		}},
+		{name: "Wait", ok: func(n *nozzle.Nozzle[int]) bool { n.Wait(); return true }},
+		{name: "Subscribe", ok: func(n *nozzle.Nozzle[int]) bool {
+			changes, unsubscribe := n.Subscribe(1)
+			defer unsubscribe()
+
+			_, open := <-changes
+
+			return !open
+		}},
+		{name: "WriteOpenMetrics", ok: func(n *nozzle.Nozzle[int]) bool { return n.WriteOpenMetrics(io.Discard) == nil }},
+		{name: "Health", ok: func(n *nozzle.Nozzle[int]) bool { return n.Health(nozzle.HealthOptions{}).Healthy() }},
+		{name: "Child", ok: func(n *nozzle.Nozzle[int]) bool {
+			return n.Child(nozzle.ChildOptions{MaxConcurrent: 1}).FlowRate() == 100
+		}},
+		{name: "ForceClose", ok: func(n *nozzle.Nozzle[int]) bool { n.ForceClose("test"); return n.FlowRate() == 100 }},
+		{name: "ForceOpen", ok: func(n *nozzle.Nozzle[int]) bool { n.ForceOpen("test"); return true }},
+		{name: "ForceCloseFor", ok: func(n *nozzle.Nozzle[int]) bool { n.ForceCloseFor(time.Minute, "test"); return n.FlowRate() == 100 }},
+		{name: "ForceOpenFor", ok: func(n *nozzle.Nozzle[int]) bool { n.ForceOpenFor(time.Minute, "test"); return true }},
+		{name: "Unforce", ok: func(n *nozzle.Nozzle[int]) bool { n.Unforce(); return true }},
+		{name: "Pause", ok: func(n *nozzle.Nozzle[int]) bool { n.Pause(); return !n.Snapshot().Paused }},
+		{name: "Resume", ok: func(n *nozzle.Nozzle[int]) bool { n.Resume(); return true }},
+		{name: "Reset", ok: func(n *nozzle.Nozzle[int]) bool { n.Reset(); return true }},
+		{name: "Tick", ok: func(n *nozzle.Nozzle[int]) bool { n.Tick(); return true }},
+		{name: "SetAllowedFailurePercent", ok: func(n *nozzle.Nozzle[int]) bool { n.SetAllowedFailurePercent(10); return true }},
+		{name: "SetInterval", ok: func(n *nozzle.Nozzle[int]) bool { n.SetInterval(time.Second); return true }},
+		{name: "IngestAggregate", ok: func(n *nozzle.Nozzle[int]) bool { n.IngestAggregate(1, 1, time.Second); return true }},
+		{name: "ReportLate", ok: func(n *nozzle.Nozzle[int]) bool { n.ReportLate(nozzle.Failure, time.Now()); return true }},
+	}
+
+	nozzles := map[string]func() *nozzle.Nozzle[int]{
+		"nil":  func() *nozzle.Nozzle[int] { return nil },
+		"zero": func() *nozzle.Nozzle[int] { return &nozzle.Nozzle[int]{} },
+	}
+
+	for name, noz := range nozzles {
+		for _, test := range tests {
+			if !test.ok(noz()) {
+				t.Errorf("%s Expected %s to behave as a pass-through", name, test.name)
+			}
+		}
+	}
+}
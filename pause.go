@@ -0,0 +1,58 @@
+package nozzle
+
+// Pause freezes the flow rate at its current value until Resume is called.
+// Use it during planned dependency failovers, when transient errors are expected and should not move the Nozzle.
+//
+// Unlike ForceOpen and ForceClose, Pause keeps admitting calls at the current flow rate rather than pinning it.
+// Intervals still end on schedule: they are recorded in History and delivered to Options.OnIntervalEnd, but the flow rate and state do not change.
+// A manual override still takes precedence while the Nozzle is paused.
+// Pausing and resuming are reported to Options.OnEvent.
+// Calling Pause on a paused Nozzle does nothing.
+//
+// Example:
+//
+//	n.Pause()
+//	defer n.Resume()
+//
+//	failover(ctx)
+func (n *Nozzle[T]) Pause() {
+	n.setPaused(true, EventPaused)
+}
+
+// Resume lets a paused Nozzle adapt again, starting at the end of the current interval.
+// Calling Resume on a Nozzle that is not paused does nothing.
+func (n *Nozzle[T]) Resume() {
+	n.setPaused(false, EventResumed)
+}
+
+// setPaused sets whether the Nozzle is paused, and reports the change as an Event of type t.
+func (n *Nozzle[T]) setPaused(paused bool, t EventType) {
+	if n.passThrough() {
+		return
+	}
+
+	n.mut.Lock()
+
+	if n.paused == paused {
+		n.mut.Unlock()
+
+		return
+	}
+
+	n.paused = paused
+	state := n.engine.State()
+	flowRate := n.effectiveFlowRate()
+
+	n.publish()
+
+	n.mut.Unlock()
+
+	e := Event{
+		Type:  t,
+		Time:  n.now(),
+		State: state,
+	}
+
+	n.emit(e)
+	n.audit(ActorOperator, e, flowRate)
+}
@@ -0,0 +1,59 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import "testing"
+
+func TestPause(t *testing.T) {
+	t.Parallel()
+
+	var events []EventType
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		OnEvent: func(e Event) {
+			events = append(events, e.Type)
+		},
+	}, 50)
+
+	noz.Pause()
+	noz.Pause()
+
+	if !noz.Snapshot().Paused {
+		t.Error("Expected Snapshot Paused=true")
+	}
+
+	for range 3 {
+		for range 10 {
+			noz.DoBool(func() (any, bool) {
+				return nil, false
+			})
+		}
+
+		noz.calculate()
+
+		if f := noz.FlowRate(); f != 50 {
+			t.Errorf("Expected paused FlowRate=50 Got=%d", f)
+		}
+
+		if r := noz.Reason(); r != "paused" {
+			t.Errorf("Expected Reason=paused Got=%q", r)
+		}
+	}
+
+	noz.Resume()
+
+	for range 10 {
+		noz.DoBool(func() (any, bool) {
+			return nil, false
+		})
+	}
+
+	noz.calculate()
+
+	if f := noz.FlowRate(); f >= 50 {
+		t.Errorf("Expected FlowRate<50 after Resume Got=%d", f)
+	}
+
+	if len(events) != 2 || events[0] != EventPaused || events[1] != EventResumed {
+		t.Errorf("Expected events=[%s %s] Got=%v", EventPaused, EventResumed, events)
+	}
+}
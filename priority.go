@@ -0,0 +1,60 @@
+package nozzle
+
+import (
+	"context"
+
+	"github.com/justindfuller/nozzle/engine"
+)
+
+// Priority ranks calls for shedding, so that when the flow rate drops, less important calls are blocked first.
+// Attach it to a call's context with WithPriority; calls without one are PriorityNormal.
+//
+// Each priority is admitted at its own share of the flow rate:
+//
+//	flow rate   PriorityLow   PriorityNormal   PriorityCritical
+//	100         100           100              100
+//	75          50            75               100
+//	50          0             50               100
+//	25          0             25               50
+//	0           0             0                0
+//
+// A fully closed Nozzle blocks every call, whatever its priority.
+// Manual overrides also ignore priorities.
+type Priority = engine.Priority
+
+const (
+	// PriorityLow calls are the first to be blocked.
+	PriorityLow = engine.PriorityLow
+
+	// PriorityNormal calls are admitted at the flow rate.
+	PriorityNormal = engine.PriorityNormal
+
+	// PriorityCritical calls are the last to be blocked.
+	PriorityCritical = engine.PriorityCritical
+)
+
+// priorityKey is the context key WithPriority sets.
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx that makes calls admitted with it use priority p.
+// It applies to DoBoolContext and DoErrorContext, and to any Nozzle the context reaches.
+//
+// Example:
+//
+//	ctx = nozzle.WithPriority(ctx, nozzle.PriorityCritical)
+//
+//	res, err := n.DoErrorContext(ctx, func(ctx context.Context) (*example, error) {
+//		return checkout(ctx, cart)
+//	})
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityOf reports the Priority attached to ctx with WithPriority, or PriorityNormal.
+func priorityOf(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+
+	return PriorityNormal
+}
@@ -0,0 +1,49 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithPriority(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{AllowedFailurePercent: 50}, 50)
+
+	calls := map[Priority]context.Context{
+		PriorityLow:      WithPriority(context.Background(), PriorityLow),
+		PriorityNormal:   context.Background(),
+		PriorityCritical: WithPriority(context.Background(), PriorityCritical),
+	}
+
+	expected := map[Priority]int{
+		PriorityLow:      0,
+		PriorityNormal:   5,
+		PriorityCritical: 10,
+	}
+
+	for p, ctx := range calls {
+		var allowed int
+
+		for range 10 {
+			noz.DoBoolContext(ctx, func(context.Context) (any, bool) {
+				allowed++
+
+				return nil, true
+			})
+		}
+
+		if allowed != expected[p] {
+			t.Errorf("priority=%s Expected Allowed=%d Got=%d", p, expected[p], allowed)
+		}
+	}
+
+	noz.ForceOpenFor(time.Hour, "priorities are ignored while forced")
+
+	if _, err := noz.DoErrorContext(calls[PriorityLow], func(context.Context) (any, error) {
+		return nil, nil
+	}); err != nil {
+		t.Errorf("Expected a low priority call to be allowed while ForcedOpen Got err=%v", err)
+	}
+}
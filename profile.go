@@ -0,0 +1,57 @@
+package nozzle
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// FlowBand is the "flow_band" pprof label of a callback, see Options.ProfileLabel.
+type FlowBand string
+
+const (
+	// FlowBandHealthy means the callback ran while the Nozzle allowed every call.
+	FlowBandHealthy FlowBand = "healthy"
+
+	// FlowBandDegraded means the callback ran while the Nozzle blocked some calls.
+	FlowBandDegraded FlowBand = "degraded"
+)
+
+// flowBand returns the FlowBand of flowRate.
+func flowBand(flowRate int64) FlowBand {
+	if flowRate >= 100 {
+		return FlowBandHealthy
+	}
+
+	return FlowBandDegraded
+}
+
+// profile runs run with the Nozzle's pprof labels, when Options.ProfileLabel is set.
+// The flow band comes from the lock-free Snapshot, so labeling never contends with the Nozzle's lock.
+func (n *Nozzle[T]) profile(ctx context.Context, run func(context.Context)) {
+	if n.options().ProfileLabel == "" {
+		run(ctx)
+
+		return
+	}
+
+	band := flowBand(n.Snapshot().FlowRate)
+
+	pprof.Do(ctx, pprof.Labels("nozzle", n.options().ProfileLabel, "flow_band", string(band)), run)
+}
+
+// call runs an admitted context-aware callback with the Nozzle's pprof labels, under the context that marks it as inside this Nozzle.
+// It reports whether the callback panicked; the panic was then recovered and its outcome recorded, and err is its *PanicError.
+func (n *Nozzle[T]) call(ctx context.Context, decision uint64, callback func(context.Context) (T, error)) (T, bool, error) {
+	var res T
+	var err error
+
+	if p := n.guard(1, func() {
+		n.profile(ctx, func(ctx context.Context) {
+			res, err = callback(&callContext{Context: ctx, nozzle: n, decision: decision})
+		})
+	}); p != nil {
+		return *new(T), true, p
+	}
+
+	return res, false, err
+}
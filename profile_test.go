@@ -0,0 +1,81 @@
+package nozzle_test
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestProfileLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		label           string
+		initialFlowRate int64
+		expectedNozzle  string
+		expectedBand    string
+	}{
+		{
+			label:           "payments",
+			initialFlowRate: 100,
+			expectedNozzle:  "payments",
+			expectedBand:    string(nozzle.FlowBandHealthy),
+		},
+		{
+			label:           "payments",
+			initialFlowRate: 50,
+			expectedNozzle:  "payments",
+			expectedBand:    string(nozzle.FlowBandDegraded),
+		},
+		{
+			label:           "",
+			initialFlowRate: 100,
+			expectedNozzle:  "",
+			expectedBand:    "",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := nozzle.New(nozzle.Options[any]{
+				Interval:              time.Hour,
+				AllowedFailurePercent: 50,
+				InitialFlowRate:       test.initialFlowRate,
+				ProfileLabel:          test.label,
+			})
+			defer noz.Close() //nolint:errcheck
+
+			var name, band string
+			var ran bool
+
+			// At a 50% flow rate, one of any two calls is allowed.
+			for range 2 {
+				_, _ = noz.DoErrorContext(context.Background(), func(ctx context.Context) (any, error) {
+					ran = true
+					name, _ = pprof.Label(ctx, "nozzle")
+					band, _ = pprof.Label(ctx, "flow_band")
+
+					return nil, nil
+				})
+			}
+
+			if !ran {
+				t.Fatal("Expected the callback to run")
+			}
+
+			if name != test.expectedNozzle {
+				t.Errorf("Expected nozzle=%q Got=%q", test.expectedNozzle, name)
+			}
+
+			if band != test.expectedBand {
+				t.Errorf("Expected flow_band=%q Got=%q", test.expectedBand, band)
+			}
+		})
+	}
+}
@@ -0,0 +1,487 @@
+package nozzle
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrRegistered is returned by Registry.New when the name is already in use.
+var ErrRegistered = errors.New("nozzle: name already registered")
+
+// RegistryOptions controls how a Registry evicts Nozzles.
+// The zero RegistryOptions never evicts.
+type RegistryOptions struct {
+	// IdleTTL closes and removes Nozzles that have not been used for this long.
+	// A Nozzle is used when it is created or looked up by name, with New, Get, or Nozzle,
+	// and while it admits calls, so a Nozzle kept after Get is not closed under a caller that still uses it.
+	// A call that is still running keeps its Nozzle from being evicted for being idle.
+	// Use it for keyed Nozzles, such as one per user, that would otherwise grow without bound.
+	// Example:
+	//
+	//	IdleTTL: 10 * time.Minute
+	//
+	// Idle Nozzles are evicted in the background, about twice per IdleTTL.
+	// If zero, Nozzles are never evicted for being idle.
+	IdleTTL time.Duration
+
+	// MaxSize caps how many Nozzles the Registry holds.
+	// Registering one more evicts the least recently used, counting calls as use like IdleTTL does.
+	// The cap is strict: when every Nozzle is running calls, the least recently used is closed anyway,
+	// and calls made to it afterwards are handled as calls after Close.
+	// If zero, the size is not capped.
+	MaxSize int
+
+	// OnEvict is called after a Nozzle is evicted, with the error from its Close.
+	// It is called without holding the Registry's lock, so it may use the Registry.
+	OnEvict func(name string, err error)
+
+	// Audit records each batch of overrides applied with Registry.Apply as one entry.
+	// The Nozzles of the batch still record their own overrides to their Options.Audit.
+	// If nil, batches are not recorded.
+	Audit AuditSink
+
+	// Clock tells the Registry the time and schedules its evictions.
+	// If nil, the system clock is used. See Options.Clock.
+	Clock Clock
+}
+
+// Registry creates Nozzles by name, and owns their lifecycle.
+// It lets one place look them up, enumerate them for metrics export, and close them all during shutdown,
+// so no Nozzle is forgotten and keeps its goroutine running.
+//
+// Example:
+//
+//	registry := nozzle.NewRegistry[*http.Response](nozzle.RegistryOptions{})
+//	defer registry.Close()
+//
+//	payments, err := registry.New("payments", nozzle.Options[*http.Response]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//	})
+//	if err != nil {
+//		// handle error
+//	}
+type Registry[T any] struct {
+	// options controls eviction.
+	options RegistryOptions
+
+	// mut guards nozzles, recent, and closed.
+	mut sync.Mutex
+
+	// nozzles holds the element of recent of every registered Nozzle, by name.
+	nozzles map[string]*list.Element
+
+	// recent holds every registered Nozzle as a *registered, most recently used first.
+	recent *list.List
+
+	// closed is set by Close, after which no Nozzle can be registered.
+	closed bool
+
+	// done is closed by Close to stop evicting idle Nozzles.
+	done chan struct{}
+
+	// evicting is done when the goroutine evicting idle Nozzles has stopped.
+	evicting sync.WaitGroup
+}
+
+// registered is a Nozzle of a Registry.
+type registered[T any] struct {
+	name   string
+	nozzle *Nozzle[T]
+
+	// used is when the Nozzle was last created, looked up, or seen admitting calls.
+	used time.Time
+
+	// uses is the Nozzle's count of admitted and finished calls when used was last updated.
+	uses uint64
+}
+
+// NewRegistry creates an empty Registry.
+// With RegistryOptions.IdleTTL, it starts a goroutine that runs until Close is called.
+//
+// Example:
+//
+//	users := nozzle.NewRegistry[*Profile](nozzle.RegistryOptions{
+//		IdleTTL: 10 * time.Minute,
+//		MaxSize: 10_000,
+//	})
+func NewRegistry[T any](options RegistryOptions) *Registry[T] {
+	r := &Registry[T]{
+		options: options,
+		nozzles: map[string]*list.Element{},
+		recent:  list.New(),
+		done:    make(chan struct{}),
+	}
+
+	if options.IdleTTL > 0 {
+		r.evicting.Add(1)
+
+		// Created here rather than in the goroutine, so the first eviction is always due one period from now.
+		go r.evictIdle(r.clock().NewTicker(max(options.IdleTTL/2, MinInterval)))
+	}
+
+	return r
+}
+
+// New creates a Nozzle with options and registers it as name.
+// It returns ErrRegistered if name is already in use, and ErrClosed if the Registry is closed.
+func (r *Registry[T]) New(name string, options Options[T]) (*Nozzle[T], error) {
+	r.mut.Lock()
+	err := r.available(name)
+	r.mut.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Created without holding the lock, since WarmStart and OnEvent may block or use the Registry.
+	n := New(options)
+
+	r.mut.Lock()
+
+	// Another caller may have registered name, or closed the Registry, while n was created.
+	if err := r.available(name); err != nil {
+		r.mut.Unlock()
+		n.Close() //nolint:errcheck // n was never used.
+
+		return nil, err
+	}
+
+	evicted := r.add(name, n)
+
+	r.mut.Unlock()
+
+	r.close(evicted)
+
+	return n, nil
+}
+
+// Get returns the Nozzle registered as name, creating it with options if there is none.
+// Use it for keyed Nozzles, such as one per user, created on first use.
+// The Nozzle may be evicted once it is idle, see RegistryOptions.IdleTTL, so call Get again rather than keeping it for long.
+// It returns ErrClosed if the Registry is closed.
+//
+// Example:
+//
+//	n, err := users.Get(userID, options)
+func (r *Registry[T]) Get(name string, options Options[T]) (*Nozzle[T], error) {
+	if n, err := r.existing(name); n != nil || err != nil {
+		return n, err
+	}
+
+	// Created without holding the lock, since WarmStart and OnEvent may block or use the Registry.
+	n := New(options)
+
+	r.mut.Lock()
+
+	// Another caller may have registered name, or closed the Registry, while n was created.
+	// Their Nozzle wins, and n is discarded.
+	if r.closed {
+		r.mut.Unlock()
+		n.Close() //nolint:errcheck // n was never used.
+
+		return nil, ErrClosed
+	}
+
+	if existing := r.use(name); existing != nil {
+		r.mut.Unlock()
+		n.Close() //nolint:errcheck // n was never used.
+
+		return existing, nil
+	}
+
+	evicted := r.add(name, n)
+
+	r.mut.Unlock()
+
+	r.close(evicted)
+
+	return n, nil
+}
+
+// existing returns the Nozzle registered as name, marking it as used, or nil if there is none.
+// It returns ErrClosed if the Registry is closed.
+func (r *Registry[T]) existing(name string) (*Nozzle[T], error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	return r.use(name), nil
+}
+
+// available returns ErrClosed if the Registry is closed, and ErrRegistered if name is in use.
+// The caller must hold the lock.
+func (r *Registry[T]) available(name string) error {
+	if r.closed {
+		return ErrClosed
+	}
+
+	if _, ok := r.nozzles[name]; ok {
+		return fmt.Errorf("%w: %q", ErrRegistered, name)
+	}
+
+	return nil
+}
+
+// Nozzle returns the Nozzle registered as name, or nil if there is none.
+func (r *Registry[T]) Nozzle(name string) *Nozzle[T] {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return r.use(name)
+}
+
+// Names reports the name of every registered Nozzle, sorted.
+func (r *Registry[T]) Names() []string {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	return slices.Sorted(maps.Keys(r.nozzles))
+}
+
+// Snapshot reports the Snapshot of every registered Nozzle, by name.
+// Use it to export metrics for all of them at once.
+// It does not count as using them, see RegistryOptions.IdleTTL.
+//
+// Example:
+//
+//	for name, s := range registry.Snapshot() {
+//		flowRate.WithLabelValues(name).Set(float64(s.FlowRate))
+//	}
+func (r *Registry[T]) Snapshot() map[string]StateSnapshot {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	snapshots := make(map[string]StateSnapshot, len(r.nozzles))
+	for name, e := range r.nozzles {
+		snapshots[name] = entry[T](e).nozzle.Snapshot()
+	}
+
+	return snapshots
+}
+
+// Stats reports the Stats of every Nozzle, by name.
+// Like Snapshot, it does not count as using them, so a metrics collector can call it without keeping idle Nozzles alive.
+//
+// Example:
+//
+//	for name, s := range registry.Stats() {
+//		blocked.WithLabelValues(name).Set(float64(s.Blocked))
+//	}
+func (r *Registry[T]) Stats() map[string]Stats {
+	r.mut.Lock()
+
+	nozzles := make(map[string]*Nozzle[T], len(r.nozzles))
+	for name, e := range r.nozzles {
+		nozzles[name] = entry[T](e).nozzle
+	}
+
+	r.mut.Unlock()
+
+	// Read without holding the lock, since Stats takes each Nozzle's lock.
+	stats := make(map[string]Stats, len(nozzles))
+	for name, n := range nozzles {
+		stats[name] = n.Stats()
+	}
+
+	return stats
+}
+
+// Remove closes the Nozzle registered as name, and frees the name.
+// It returns the error from the Nozzle's Close, and nil if there is no such Nozzle.
+func (r *Registry[T]) Remove(name string) error {
+	r.mut.Lock()
+
+	e, ok := r.nozzles[name]
+	if !ok {
+		r.mut.Unlock()
+
+		return nil
+	}
+
+	reg := r.remove(e)
+
+	r.mut.Unlock()
+
+	// Closed without holding the lock, so its callbacks may use the Registry.
+	return reg.nozzle.Close()
+}
+
+// Close closes every registered Nozzle, and stops new ones from being registered.
+// It returns the errors from the Nozzles' Close joined together.
+// Calling Close more than once does nothing.
+func (r *Registry[T]) Close() error {
+	r.mut.Lock()
+
+	if r.closed {
+		r.mut.Unlock()
+
+		return nil
+	}
+
+	nozzles := make(map[string]*Nozzle[T], len(r.nozzles))
+	for name, e := range r.nozzles {
+		nozzles[name] = entry[T](e).nozzle
+	}
+
+	clear(r.nozzles)
+	r.recent.Init()
+	r.closed = true
+	close(r.done)
+
+	r.mut.Unlock()
+
+	r.evicting.Wait()
+
+	errs := make([]error, 0, len(nozzles))
+
+	// Closed without holding the lock, so their callbacks may use the Registry.
+	for _, name := range slices.Sorted(maps.Keys(nozzles)) {
+		errs = append(errs, nozzles[name].Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// add registers n as name, then evicts the least recently used Nozzles over RegistryOptions.MaxSize.
+// It returns the evicted Nozzles, which the caller must close without holding the lock.
+// The caller must hold the lock.
+func (r *Registry[T]) add(name string, n *Nozzle[T]) []*registered[T] {
+	var evicted []*registered[T]
+
+	// Room is made before n is added, so n itself is never evicted.
+	// Each Nozzle is checked for calls once, so the least recently used is evicted even when all of them are in use.
+	checked := 0
+
+	for r.options.MaxSize > 0 && r.recent.Len() >= r.options.MaxSize {
+		if checked < r.recent.Len() && r.active(r.recent.Back()) {
+			checked++
+
+			continue
+		}
+
+		evicted = append(evicted, r.remove(r.recent.Back()))
+	}
+
+	r.nozzles[name] = r.recent.PushFront(&registered[T]{name: name, nozzle: n, used: r.now()})
+
+	return evicted
+}
+
+// active reports whether the Nozzle of e admitted or finished calls since it was last checked, or is running one.
+// If it did, it is marked as the most recently used.
+// The caller must hold the lock.
+func (r *Registry[T]) active(e *list.Element) bool {
+	reg := entry[T](e)
+
+	uses := reg.nozzle.uses.Load()
+	if uses == reg.uses && reg.nozzle.running.Load() == 0 {
+		return false
+	}
+
+	reg.uses = uses
+	reg.used = r.now()
+	r.recent.MoveToFront(e)
+
+	return true
+}
+
+// use returns the Nozzle registered as name, or nil, and marks it as the most recently used.
+// The caller must hold the lock.
+func (r *Registry[T]) use(name string) *Nozzle[T] {
+	e, ok := r.nozzles[name]
+	if !ok {
+		return nil
+	}
+
+	reg := entry[T](e)
+	reg.used = r.now()
+	r.recent.MoveToFront(e)
+
+	return reg.nozzle
+}
+
+// remove unregisters the Nozzle of e, and returns it.
+// The caller must hold the lock.
+func (r *Registry[T]) remove(e *list.Element) *registered[T] {
+	reg := entry[T](e)
+	r.recent.Remove(e)
+	delete(r.nozzles, reg.name)
+
+	return reg
+}
+
+// evictIdle evicts the Nozzles idle for RegistryOptions.IdleTTL, about twice per IdleTTL, until Close is called.
+func (r *Registry[T]) evictIdle(ticker Ticker) {
+	defer r.evicting.Done()
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C():
+		}
+
+		r.mut.Lock()
+
+		var evicted []*registered[T]
+
+		// The least recently used are at the back, so stop at the first that is not idle.
+		// A Nozzle that ran calls since it was last checked moves to the front, so each is checked at most once.
+		for e := r.recent.Back(); e != nil; e = r.recent.Back() {
+			if r.now().Sub(entry[T](e).used) < r.options.IdleTTL {
+				break
+			}
+
+			if r.active(e) {
+				continue
+			}
+
+			evicted = append(evicted, r.remove(e))
+		}
+
+		r.mut.Unlock()
+
+		r.close(evicted)
+	}
+}
+
+// close closes evicted Nozzles, and reports each to RegistryOptions.OnEvict.
+// The caller must not hold the lock.
+func (r *Registry[T]) close(evicted []*registered[T]) {
+	for _, reg := range evicted {
+		err := reg.nozzle.Close()
+
+		if r.options.OnEvict != nil {
+			r.options.OnEvict(reg.name, err)
+		}
+	}
+}
+
+// clock returns RegistryOptions.Clock, or the system clock when it is not set.
+func (r *Registry[T]) clock() Clock {
+	if r.options.Clock != nil {
+		return r.options.Clock
+	}
+
+	return systemClock{}
+}
+
+// now returns the current time according to the Registry's Clock.
+func (r *Registry[T]) now() time.Time {
+	return r.clock().Now()
+}
+
+// entry returns the registered Nozzle held by e, an element of Registry.recent.
+func entry[T any](e *list.Element) *registered[T] {
+	return e.Value.(*registered[T]) //nolint:forcetypeassert // recent only holds *registered[T].
+}
@@ -0,0 +1,291 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+
+	options := nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	}
+
+	payments, err := registry.New("payments", options)
+	if err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	if _, err := registry.New("search", options); err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	if _, err := registry.New("payments", options); !errors.Is(err, nozzle.ErrRegistered) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrRegistered, err)
+	}
+
+	if registry.Nozzle("payments") != payments {
+		t.Error("Expected Nozzle to return the registered Nozzle")
+	}
+
+	if registry.Nozzle("missing") != nil {
+		t.Error("Expected Nozzle=nil for an unknown name")
+	}
+
+	if names := registry.Names(); !slices.Equal(names, []string{"payments", "search"}) {
+		t.Errorf("Expected Names=[payments search] Got=%v", names)
+	}
+
+	if snapshots := registry.Snapshot(); len(snapshots) != 2 || snapshots["payments"].FlowRate != 100 {
+		t.Errorf("Expected 2 snapshots at FlowRate=100 Got=%v", snapshots)
+	}
+
+	if err := registry.Remove("search"); err != nil {
+		t.Errorf("Expected err=nil Got=%v", err)
+	}
+
+	if names := registry.Names(); !slices.Equal(names, []string{"payments"}) {
+		t.Errorf("Expected Names=[payments] Got=%v", names)
+	}
+
+	if err := registry.Close(); err != nil {
+		t.Errorf("Expected err=nil Got=%v", err)
+	}
+
+	// Closed Nozzles no longer tick.
+	if _, err := payments.WaitSnapshot(context.Background()); !errors.Is(err, nozzle.ErrClosed) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrClosed, err)
+	}
+
+	if _, err := registry.New("payments", options); !errors.Is(err, nozzle.ErrClosed) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrClosed, err)
+	}
+
+	if err := registry.Close(); err != nil {
+		t.Errorf("Expected err=nil Got=%v", err)
+	}
+}
+
+func TestRegistryEviction(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Now())
+	evicted := make(chan string, 3)
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{
+		IdleTTL: 10 * time.Minute,
+		MaxSize: 2,
+		Clock:   clock,
+		OnEvict: func(name string, err error) {
+			if err != nil {
+				t.Errorf("Expected err=nil Got=%v", err)
+			}
+
+			evicted <- name
+		},
+	})
+	defer registry.Close() //nolint:errcheck
+
+	options := nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	}
+
+	a, _ := registry.Get("a", options)
+	registry.Get("b", options) //nolint:errcheck
+
+	// Using a again makes b the least recently used, so c evicts it.
+	if again, _ := registry.Get("a", options); again != a {
+		t.Error("Expected Get to return the registered Nozzle")
+	}
+
+	registry.Get("c", options) //nolint:errcheck
+
+	if name := <-evicted; name != "b" {
+		t.Errorf("Expected evicted=b Got=%s", name)
+	}
+
+	// a is used again 4 minutes in, so at 11 minutes only c has been idle for 10.
+	clock.Advance(4 * time.Minute)
+	registry.Nozzle("a")
+
+	// Reading every Nozzle's Snapshot and Stats, as a metrics collector does, does not count as using c.
+	if stats := registry.Stats(); len(stats) != 2 || len(registry.Snapshot()) != 2 {
+		t.Errorf("Expected Stats of 2 Nozzles Got=%d", len(stats))
+	}
+
+	clock.Advance(7 * time.Minute)
+
+	select {
+	case name := <-evicted:
+		if name != "c" {
+			t.Errorf("Expected evicted=c Got=%s", name)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected c to be evicted")
+	}
+
+	if names := registry.Names(); !slices.Equal(names, []string{"a"}) {
+		t.Errorf("Expected Names=[a] Got=%v", names)
+	}
+}
+
+func TestRegistryConstruction(t *testing.T) {
+	t.Parallel()
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{})
+	defer registry.Close() //nolint:errcheck
+
+	// WarmStart runs while New creates the Nozzle, so it must be able to use the Registry.
+	options := nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		WarmStart: func(context.Context) (int64, error) {
+			return int64(len(registry.Names())), nil
+		},
+	}
+
+	created := make(chan *nozzle.Nozzle[any])
+
+	go func() {
+		n, _ := registry.New("payments", options)
+		created <- n
+	}()
+
+	select {
+	case n := <-created:
+		if n == nil {
+			t.Fatal("Expected New to create the Nozzle")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected New not to hold the Registry's lock while creating the Nozzle")
+	}
+
+	// Concurrent Gets of a new name all return the one Nozzle that was registered.
+	var wg sync.WaitGroup
+
+	got := make([]*nozzle.Nozzle[any], 8)
+
+	for i := range got {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			got[i], _ = registry.Get("search", options)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, n := range got {
+		if n == nil || n != registry.Nozzle("search") {
+			t.Errorf("test=%d Expected the registered Nozzle", i)
+		}
+	}
+}
+
+func TestRegistryEvictionInUse(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Now())
+	evicted := make(chan string, 3)
+
+	registry := nozzle.NewRegistry[any](nozzle.RegistryOptions{
+		IdleTTL: 10 * time.Minute,
+		Clock:   clock,
+		OnEvict: func(name string, _ error) {
+			evicted <- name
+		},
+	})
+	defer registry.Close() //nolint:errcheck
+
+	options := nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	}
+
+	next := func() string {
+		select {
+		case name := <-evicted:
+			return name
+		case <-time.After(10 * time.Second):
+			t.Fatal("Expected a Nozzle to be evicted")
+
+			return ""
+		}
+	}
+
+	a, _ := registry.Get("a", options)
+	b, _ := registry.Get("b", options)
+	registry.Get("c", options) //nolint:errcheck
+
+	// a and b are kept by the caller, and used without looking them up again.
+	a.DoBool(func() (any, bool) {
+		return nil, true
+	})
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		b.DoBool(func() (any, bool) {
+			close(running)
+			<-release
+
+			return nil, true
+		})
+	}()
+
+	<-running
+
+	clock.Advance(11 * time.Minute)
+
+	if name := next(); name != "c" {
+		t.Errorf("Expected evicted=c Got=%s", name)
+	}
+
+	// a has been idle since, but b's call is still running.
+	clock.Advance(11 * time.Minute)
+
+	if name := next(); name != "a" {
+		t.Errorf("Expected evicted=a Got=%s", name)
+	}
+
+	if names := registry.Names(); !slices.Equal(names, []string{"b"}) {
+		t.Errorf("Expected Names=[b] Got=%v", names)
+	}
+
+	close(release)
+	<-done
+
+	// Calls count as use for MaxSize too, so d evicts f, which was looked up last but never called.
+	capped := nozzle.NewRegistry[any](nozzle.RegistryOptions{MaxSize: 2})
+	defer capped.Close() //nolint:errcheck
+
+	e, _ := capped.Get("e", options)
+	capped.Get("f", options) //nolint:errcheck
+
+	e.DoBool(func() (any, bool) {
+		return nil, true
+	})
+
+	capped.Get("d", options) //nolint:errcheck
+
+	if names := capped.Names(); !slices.Equal(names, []string{"d", "e"}) {
+		t.Errorf("Expected Names=[d e] Got=%v", names)
+	}
+}
@@ -0,0 +1,50 @@
+package nozzle
+
+import "context"
+
+// Allow decides whether a call may proceed, and counts the decision, without running anything.
+// It is the low-level counterpart of DoBool for call sites that cannot be wrapped in a synchronous callback, such as callbacks and futures.
+//
+// Every call Allow permits must later be reported exactly once, with ReportSuccess or ReportFailure.
+// Blocked calls must not be reported.
+//
+// Example:
+//
+//	if !n.Allow() {
+//		return ErrShed
+//	}
+//
+//	client.SendAsync(req, func(resp *Response, err error) {
+//		if err != nil {
+//			n.ReportFailure()
+//			return
+//		}
+//		n.ReportSuccess()
+//	})
+func (n *Nozzle[T]) Allow() bool {
+	if n.passedThrough() {
+		return true
+	}
+
+	_, ok := n.admit(context.Background(), 1)
+
+	return ok
+}
+
+// ReportSuccess records that a call permitted by Allow succeeded.
+func (n *Nozzle[T]) ReportSuccess() {
+	if n.passThrough() {
+		return
+	}
+
+	n.success(1)
+}
+
+// ReportFailure records that a call permitted by Allow failed.
+func (n *Nozzle[T]) ReportFailure() {
+	if n.passThrough() {
+		return
+	}
+
+	n.failure(1)
+}
@@ -0,0 +1,56 @@
+package nozzle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestAllow(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		OnInvariantViolation: func(err error) {
+			t.Error(err)
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	results := make(chan bool, 4)
+
+	for i := range 4 {
+		if !noz.Allow() {
+			t.Fatalf("call=%d Expected Allow=true at a flow rate of 100", i)
+		}
+
+		// Report asynchronously, as a future would.
+		go func() {
+			results <- i%4 != 0
+		}()
+	}
+
+	for range 4 {
+		if <-results {
+			noz.ReportSuccess()
+		} else {
+			noz.ReportFailure()
+		}
+	}
+
+	if s := noz.Stats(); s.Allowed != 4 || s.Successes != 3 || s.Failures != 1 {
+		t.Errorf("Expected Allowed=4 Successes=3 Failures=1 Got Allowed=%d Successes=%d Failures=%d", s.Allowed, s.Successes, s.Failures)
+	}
+
+	if fr := noz.FailureRate(); fr != 25 {
+		t.Errorf("Expected FailureRate=25 Got=%d", fr)
+	}
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	if noz.Allow() {
+		t.Error("Expected Allow=false while ForcedClosed")
+	}
+}
@@ -0,0 +1,188 @@
+package nozzle
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Reservation is an admission decision whose call runs later, like a reservation from golang.org/x/time/rate.
+// It suits pipelines that decide at enqueue time and execute later, while keeping the Nozzle's accounting accurate.
+//
+// An allowed Reservation must end exactly once, with Commit once the call has run, or with Cancel if it never will.
+// Only the first of them has an effect; the others are counted as Stats.ReservationsReused.
+// Ending it after Options.ReservationTTL intervals, or after the Nozzle is closed, has no effect either, and is counted as Stats.ReservationsLate.
+// A blocked Reservation needs neither.
+type Reservation[T any] struct {
+	nozzle   *Nozzle[T]
+	decision uint64
+	weight   int64
+	ok       bool
+
+	// done is set by the first Commit or Cancel.
+	done atomic.Bool
+}
+
+// Reserve decides whether a call may run later, and counts the decision now.
+//
+// Example:
+//
+//	r := n.Reserve()
+//	if !r.OK() {
+//		return ErrShed
+//	}
+//
+//	queue <- job{reservation: r}
+//
+//	// Later, in the worker:
+//	err := run(j)
+//	if err != nil {
+//		j.reservation.Commit(nozzle.Failure)
+//	} else {
+//		j.reservation.Commit(nozzle.Success)
+//	}
+func (n *Nozzle[T]) Reserve() *Reservation[T] {
+	return n.ReserveN(1)
+}
+
+// ReserveN is like Reserve, but the call counts as weight calls, like DoBoolN.
+// A weight below 1 counts as 1.
+func (n *Nozzle[T]) ReserveN(weight int64) *Reservation[T] {
+	weight = max(weight, 1)
+
+	if n.passedThrough() {
+		return &Reservation[T]{nozzle: n, weight: weight, ok: true}
+	}
+
+	decision, ok := n.admit(context.Background(), weight)
+
+	if ok && n.options().ReservationTTL > 0 {
+		n.mut.Lock()
+
+		if n.reservations == nil {
+			n.reservations = map[uint64]uint64{}
+		}
+
+		n.reservations[decision] = n.intervals
+
+		n.mut.Unlock()
+	}
+
+	return &Reservation[T]{
+		nozzle:   n,
+		decision: decision,
+		weight:   weight,
+		ok:       ok,
+	}
+}
+
+// OK reports whether the call was allowed.
+func (r *Reservation[T]) OK() bool {
+	return r.ok
+}
+
+// DecisionID reports the ID of the decision behind the Reservation.
+// See nozzle.DecisionID.
+func (r *Reservation[T]) DecisionID() uint64 {
+	return r.decision
+}
+
+// Commit records the outcome of the reserved call.
+// It does nothing for a blocked Reservation, a Reservation that already ended or leaked, or the zero Outcome.
+func (r *Reservation[T]) Commit(outcome Outcome) {
+	if !r.ok || outcome == 0 || !r.end() {
+		return
+	}
+
+	switch outcome {
+	case Success:
+		r.nozzle.success(r.weight)
+	case Failure:
+		r.nozzle.failure(r.weight)
+	}
+}
+
+// Cancel gives up the reserved call.
+// If the interval in which it was reserved is still running, the decision is undone, so the slot becomes available to other calls.
+// Otherwise, it is left as an allowed call without an outcome, which does not affect the failure rate.
+// It does nothing for a blocked Reservation, or a Reservation that already ended or leaked.
+func (r *Reservation[T]) Cancel() {
+	if !r.ok || !r.end() {
+		return
+	}
+
+	n := r.nozzle
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	n.finished()
+
+	if r.decision <= n.decisionsAtStart {
+		return
+	}
+
+	n.engine.Retract(PriorityNormal, true, r.weight)
+	n.units -= r.weight
+	n.totals.Allowed -= r.weight
+}
+
+// end marks the Reservation as ended, and reports whether its outcome may still be recorded.
+// Misuse is counted in Stats.
+func (r *Reservation[T]) end() bool {
+	n := r.nozzle
+
+	if n.passThrough() {
+		return false
+	}
+
+	first := r.done.CompareAndSwap(false, true)
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if !first {
+		n.totals.ReservationsReused++
+
+		return false
+	}
+
+	if n.closed {
+		n.totals.ReservationsLate++
+
+		return false
+	}
+
+	if n.options().ReservationTTL <= 0 {
+		return true
+	}
+
+	if _, ok := n.reservations[r.decision]; !ok {
+		n.totals.ReservationsLate++
+
+		return false
+	}
+
+	delete(n.reservations, r.decision)
+
+	return true
+}
+
+// expireReservations counts the Reservations open for Options.ReservationTTL intervals, including the one ending now, as leaked.
+// Each frees its Options.MaxConcurrent slot.
+// The caller must hold the lock.
+func (n *Nozzle[T]) expireReservations() {
+	ttl := n.options().ReservationTTL
+	if ttl <= 0 {
+		return
+	}
+
+	for decision, interval := range n.reservations {
+		if n.intervals-interval+1 < uint64(ttl) {
+			continue
+		}
+
+		delete(n.reservations, decision)
+		n.totals.ReservationsLeaked++
+		n.finished()
+	}
+}
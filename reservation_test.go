@@ -0,0 +1,124 @@
+package nozzle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestReservation(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		OnInvariantViolation: func(err error) {
+			t.Error(err)
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	committed := noz.Reserve()
+	canceled := noz.ReserveN(3)
+
+	if !committed.OK() || !canceled.OK() {
+		t.Fatal("Expected both reservations to be allowed at a flow rate of 100")
+	}
+
+	if committed.DecisionID() != 1 || canceled.DecisionID() != 2 {
+		t.Errorf("Expected DecisionIDs 1 and 2 Got %d and %d", committed.DecisionID(), canceled.DecisionID())
+	}
+
+	committed.Commit(nozzle.Failure)
+	committed.Commit(nozzle.Success)
+	committed.Cancel()
+
+	canceled.Cancel()
+	canceled.Commit(nozzle.Success)
+
+	s := noz.Stats()
+
+	if s.Allowed != 1 || s.Failures != 1 || s.Successes != 0 {
+		t.Errorf("Expected Allowed=1 Failures=1 Successes=0 Got Allowed=%d Failures=%d Successes=%d", s.Allowed, s.Failures, s.Successes)
+	}
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	blocked := noz.Reserve()
+	if blocked.OK() {
+		t.Error("Expected a blocked reservation while ForcedClosed")
+	}
+
+	blocked.Commit(nozzle.Success)
+	blocked.Cancel()
+
+	if s := noz.Stats(); s.Blocked != 1 || s.Successes != 0 {
+		t.Errorf("Expected Blocked=1 Successes=0 Got Blocked=%d Successes=%d", s.Blocked, s.Successes)
+	}
+}
+
+func TestReservationLifecycle(t *testing.T) {
+	t.Parallel()
+
+	clock := nozzle.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Second,
+		AllowedFailurePercent: 50,
+		MaxConcurrent:         1,
+		ReservationTTL:        2,
+		Clock:                 clock,
+		ManualTick:            true,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	tick := func() {
+		clock.Advance(time.Second)
+		noz.Tick()
+	}
+
+	leaked := noz.Reserve()
+	if !leaked.OK() {
+		t.Fatal("Expected the first reservation to be allowed")
+	}
+
+	if noz.Reserve().OK() {
+		t.Error("Expected the open reservation to hold the only MaxConcurrent slot")
+	}
+
+	tick()
+
+	if s := noz.Stats(); s.ReservationsLeaked != 0 {
+		t.Errorf("Expected ReservationsLeaked=0 Got=%d", s.ReservationsLeaked)
+	}
+
+	tick()
+
+	if s := noz.Stats(); s.ReservationsLeaked != 1 {
+		t.Errorf("Expected ReservationsLeaked=1 Got=%d", s.ReservationsLeaked)
+	}
+
+	leaked.Commit(nozzle.Failure)
+
+	reused := noz.Reserve()
+	if !reused.OK() {
+		t.Fatal("Expected the leaked reservation to free its MaxConcurrent slot")
+	}
+
+	reused.Commit(nozzle.Success)
+	reused.Commit(nozzle.Success)
+	reused.Cancel()
+
+	noz.ReportSuccess()
+
+	s := noz.Stats()
+
+	if s.ReservationsLate != 1 || s.ReservationsReused != 2 || s.UnmatchedReports != 1 {
+		t.Errorf("Expected ReservationsLate=1 ReservationsReused=2 UnmatchedReports=1 Got ReservationsLate=%d ReservationsReused=%d UnmatchedReports=%d", s.ReservationsLate, s.ReservationsReused, s.UnmatchedReports)
+	}
+
+	if s.Failures != 0 || s.Successes != 2 {
+		t.Errorf("Expected Failures=0 Successes=2 Got Failures=%d Successes=%d", s.Failures, s.Successes)
+	}
+}
@@ -0,0 +1,89 @@
+package nozzle
+
+import "time"
+
+// Reset restores the Nozzle to fully open, as if it had just been created, without closing it.
+// Use it once a known remediation has completed, so traffic does not have to wait for the Nozzle to reopen on its own.
+// Callbacks, metrics, and anything else wired to the Nozzle keep working.
+//
+// The current interval ends early: its counts are recorded in History and delivered to Options.OnIntervalEnd,
+// and the next interval starts with the Reset.
+// The flow rate returns to 100, the state to Opening, and the failure windows, the reopen cooldown,
+// and the Strategy's momentum are cleared.
+// The cumulative Stats, History, and undelivered intervals are kept, and an active manual override or Pause stays in effect.
+// The reset is reported to Options.OnEvent and Options.Audit.
+//
+// Example:
+//
+//	// The bad deploy was rolled back.
+//	n.Reset()
+func (n *Nozzle[T]) Reset() {
+	if n.passThrough() {
+		return
+	}
+
+	now := n.now()
+
+	n.mut.Lock()
+
+	e := Event{
+		Type:   EventReset,
+		Time:   now,
+		State:  n.engine.State(),
+		Reason: n.engine.Observe().Reason,
+	}
+
+	// End the current interval where it is, so its counts are not lost.
+	if !n.closed {
+		stats := n.intervalStats()
+		stats.Maintenance = n.maintenance != ""
+
+		n.record(stats)
+		n.observeHealth(stats)
+
+		if stats.Maintenance {
+			n.totals.MaintenanceIntervals++
+		}
+
+		if stats.FlowRate < 100 {
+			n.totals.ReducedFlowTime += stats.End.Sub(stats.Start)
+		}
+
+		if n.options().OnIntervalEnd != nil {
+			n.pending = append(n.pending, stats)
+		}
+
+		n.trim()
+		n.intervals++
+	}
+
+	n.start = now
+	n.decisionsAtStart = n.decisions
+	n.unitsAtStart = n.units
+	n.failureTrace = ""
+	n.retries = 0
+	n.bulkheadBlocked = 0
+	n.closedAt = time.Time{}
+	n.engine.Restart(100)
+
+	flowRate := n.effectiveFlowRate()
+	hook := n.edge(flowRate)
+
+	n.publish()
+
+	// Once closed, Close delivers what is left, and reports what it could not.
+	deliver := !n.closed && len(n.pending) > 0
+
+	n.mut.Unlock()
+
+	n.emit(e)
+	n.audit(ActorOperator, e, flowRate)
+
+	if hook != nil {
+		hook()
+	}
+
+	if deliver {
+		n.tryDeliver()
+	}
+}
@@ -0,0 +1,115 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import "testing"
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	var events []Event
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		OnEvent: func(e Event) {
+			events = append(events, e)
+		},
+	}, 100)
+
+	fail := func() {
+		noz.DoBool(func() (any, bool) {
+			return nil, false
+		})
+	}
+
+	for range 4 {
+		fail()
+		noz.calculate()
+	}
+
+	if f := noz.FlowRate(); f != 85 {
+		t.Fatalf("Expected FlowRate=85 before Reset Got=%d", f)
+	}
+
+	fail()
+	noz.Reset()
+
+	// The current interval ends, and the next one starts with the Reset.
+	if s := noz.Snapshot(); s.FlowRate != 100 || s.State != Opening || s.Failures != 0 || s.IntervalSeq != 6 {
+		t.Errorf("Expected FlowRate=100 State=%s Failures=0 IntervalSeq=6 Got=%+v", Opening, s)
+	}
+
+	if stats := noz.Stats(); stats.Failures != 5 {
+		t.Errorf("Expected cumulative Failures=5 to be kept Got=%d", stats.Failures)
+	}
+
+	if len(events) != 1 || events[0].Type != EventReset || events[0].State != Closing {
+		t.Errorf("Expected one %s event from %s Got=%+v", EventReset, Closing, events)
+	}
+
+	// The Strategy starts over, so the next failure only costs 1%.
+	fail()
+	noz.calculate()
+
+	if f := noz.FlowRate(); f != 99 {
+		t.Errorf("Expected FlowRate=99 Got=%d", f)
+	}
+}
+
+func TestResetEndsInterval(t *testing.T) {
+	t.Parallel()
+
+	var delivered []IntervalStats
+
+	var noz *Nozzle[any]
+
+	noz = newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+		OnIntervalEnd: func(stats IntervalStats) error {
+			delivered = append(delivered, stats)
+
+			// Reset may be called while an interval is being delivered.
+			if len(delivered) == 1 {
+				noz.Reset()
+			}
+
+			return nil
+		},
+	}, 100)
+
+	noz.DoBool(func() (any, bool) {
+		return nil, false
+	})
+	noz.Reset()
+
+	noz.DoBool(func() (any, bool) {
+		return nil, true
+	})
+	noz.calculate()
+
+	history := noz.History()
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 intervals in History Got=%+v", history)
+	}
+
+	if history[0].Failures != 1 || history[0].IntervalSeq != 1 {
+		t.Errorf("Expected the partial interval with Failures=1 IntervalSeq=1 Got=%+v", history[0])
+	}
+
+	// The Reset from OnIntervalEnd ended the second interval before anything happened in it.
+	if history[1].Allowed != 0 || history[1].IntervalSeq != 2 {
+		t.Errorf("Expected Allowed=0 IntervalSeq=2 Got=%+v", history[1])
+	}
+
+	if history[2].Successes != 1 || history[2].IntervalSeq != 3 {
+		t.Errorf("Expected Successes=1 IntervalSeq=3 Got=%+v", history[2])
+	}
+
+	if len(delivered) != 3 {
+		t.Fatalf("Expected 3 intervals delivered Got=%+v", delivered)
+	}
+
+	for i, stats := range delivered {
+		if stats.IntervalSeq != history[i].IntervalSeq {
+			t.Errorf("test=%d Expected IntervalSeq=%d Got=%d", i, history[i].IntervalSeq, stats.IntervalSeq)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package nozzle
+
+import (
+	"context"
+	"time"
+)
+
+// Retry configures how a Nozzle retries failed calls, see Options.Retry.
+//
+// A failure is only retried when it counts as a failure, so errors excused by Options.IsFailure, blocked calls, and calls whose caller gave up are never retried.
+// A retry the Nozzle blocks is not attempted, and the call returns the failure it was retrying.
+// Calls made with a context from WithoutRetry are never retried.
+type Retry struct {
+	// MaxAttempts is the most times a call runs, including the first attempt.
+	// If 1 or less, calls are never retried.
+	MaxAttempts int
+
+	// Backoff returns how long to wait before the retry that follows attempt, which starts at 1.
+	// DoErrorContext stops waiting, and returns the last failure, when its context is done.
+	// If nil, retries are attempted immediately.
+	Backoff func(attempt int) time.Duration
+
+	// Budget caps the retries attempted in an interval to a percentage of the calls the Nozzle allowed in it.
+	// Example: With a Budget of 10, an interval that allowed 1000 calls attempts at most 100 retries.
+	// If zero, retries are only limited by MaxAttempts and the flow rate.
+	Budget int64
+}
+
+// noRetryKey is the context key WithoutRetry sets.
+type noRetryKey struct{}
+
+// WithoutRetry returns a copy of ctx that stops Options.Retry from retrying calls made with it, for calls that cannot safely run twice.
+// It applies to DoErrorContext, and to any Nozzle the context reaches.
+//
+// Example:
+//
+//	_, err := n.DoErrorContext(nozzle.WithoutRetry(ctx), func(ctx context.Context) (*Receipt, error) {
+//		return payments.Charge(ctx, order)
+//	})
+func WithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// retry reports whether the call that failed its attempt, and weighs weight, should be retried.
+// When it should, the retry is counted against Retry.Budget, and retry waits for Retry.Backoff first.
+func (n *Nozzle[T]) retry(ctx context.Context, attempt int, weight int64) bool {
+	r := n.options().Retry
+
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+
+	if without, _ := ctx.Value(noRetryKey{}).(bool); without {
+		return false
+	}
+
+	n.mut.Lock()
+
+	if r.Budget > 0 && (n.retries+weight)*100 > r.Budget*n.engine.Observe().Allowed {
+		n.mut.Unlock()
+
+		return false
+	}
+
+	n.retries += weight
+	n.totals.Retries += weight
+
+	n.mut.Unlock()
+
+	if r.Backoff == nil {
+		return true
+	}
+
+	wait := r.Backoff(attempt)
+	if wait <= 0 {
+		return true
+	}
+
+	// A Ticker that is stopped after its first tick is a timer that Options.Clock can drive.
+	timer := n.clock().NewTicker(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
@@ -0,0 +1,171 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+	errNotFound := errors.New("not found")
+
+	tests := []struct {
+		retry           nozzle.Retry
+		successes       int
+		err             error
+		failures        int
+		expectedCalls   int
+		expectedRetries int64
+		expectedErr     error
+	}{
+		{
+			// Without Retry, a failure is returned right away.
+			err:             errUnavailable,
+			failures:        5,
+			expectedCalls:   1,
+			expectedRetries: 0,
+			expectedErr:     errUnavailable,
+		},
+		{
+			retry:           nozzle.Retry{MaxAttempts: 3},
+			err:             errUnavailable,
+			failures:        2,
+			expectedCalls:   3,
+			expectedRetries: 2,
+			expectedErr:     nil,
+		},
+		{
+			retry:           nozzle.Retry{MaxAttempts: 3},
+			err:             errUnavailable,
+			failures:        5,
+			expectedCalls:   3,
+			expectedRetries: 2,
+			expectedErr:     errUnavailable,
+		},
+		{
+			// Errors excused by IsFailure are not retried.
+			retry:           nozzle.Retry{MaxAttempts: 3},
+			err:             errNotFound,
+			failures:        5,
+			expectedCalls:   1,
+			expectedRetries: 0,
+			expectedErr:     errNotFound,
+		},
+		{
+			// One allowed call leaves no room for a retry in a budget of 20%.
+			retry:           nozzle.Retry{MaxAttempts: 10, Budget: 20},
+			err:             errUnavailable,
+			failures:        10,
+			expectedCalls:   1,
+			expectedRetries: 0,
+			expectedErr:     errUnavailable,
+		},
+		{
+			// After 10 successes, the 11th call may retry while retries stay within 20% of allowed calls.
+			retry:           nozzle.Retry{MaxAttempts: 10, Budget: 20},
+			successes:       10,
+			err:             errUnavailable,
+			failures:        10,
+			expectedCalls:   3,
+			expectedRetries: 2,
+			expectedErr:     errUnavailable,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			noz := nozzle.New(nozzle.Options[any]{
+				Interval:              time.Hour,
+				AllowedFailurePercent: 50,
+				Retry:                 test.retry,
+				IsFailure: func(err error) bool {
+					return !errors.Is(err, errNotFound)
+				},
+			})
+			defer noz.Close() //nolint:errcheck
+
+			for range test.successes {
+				_, _ = noz.DoError(func() (any, error) {
+					return nil, nil
+				})
+			}
+
+			var calls int
+
+			_, err := noz.DoError(func() (any, error) {
+				calls++
+
+				if calls <= test.failures {
+					return nil, test.err
+				}
+
+				return nil, nil
+			})
+
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("Expected err=%v Got=%v", test.expectedErr, err)
+			}
+
+			if calls != test.expectedCalls {
+				t.Errorf("Expected calls=%d Got=%d", test.expectedCalls, calls)
+			}
+
+			stats := noz.Stats()
+
+			if stats.Retries != test.expectedRetries {
+				t.Errorf("Expected Retries=%d Got=%d", test.expectedRetries, stats.Retries)
+			}
+
+			if expected := int64(test.successes + test.expectedCalls); stats.Allowed != expected {
+				t.Errorf("Expected retries to be counted, Allowed=%d Got=%d", expected, stats.Allowed)
+			}
+		})
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	t.Parallel()
+
+	errUnavailable := errors.New("unavailable")
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Retry: nozzle.Retry{
+			MaxAttempts: 3,
+			Backoff: func(int) time.Duration {
+				return time.Hour
+			},
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	var calls int
+
+	// The backoff outlasts ctx, so the call gives up after its first attempt.
+	_, err := noz.DoErrorContext(ctx, func(context.Context) (any, error) {
+		calls++
+
+		return nil, errUnavailable
+	})
+
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected err=%v Got=%v", errUnavailable, err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected calls=1 Got=%d", calls)
+	}
+}
@@ -0,0 +1,121 @@
+package nozzle
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler ticks many Nozzles from a single goroutine.
+//
+// By default, every Nozzle starts its own goroutine and ticker.
+// That is negligible for a handful of Nozzles, but with one Nozzle per key (per tenant, per host) it dominates their memory footprint.
+// Nozzles created with Options.Scheduler share the Scheduler's goroutine and ticker instead.
+//
+// Each Nozzle still ends its intervals according to its own Options.Interval.
+// The Scheduler only decides how often they are checked, so its resolution should be no larger than the smallest Interval it serves.
+//
+// Example:
+//
+//	scheduler := nozzle.NewScheduler(100 * time.Millisecond)
+//	defer scheduler.Stop()
+//
+//	n := nozzle.New(nozzle.Options[any]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//		Scheduler:             scheduler,
+//	})
+type Scheduler struct {
+	// mut guards nozzles.
+	mut sync.Mutex
+
+	// nozzles are the Nozzles ticked by the Scheduler.
+	nozzles map[scheduled]struct{}
+
+	// done is closed by Stop.
+	done chan struct{}
+
+	// stopOnce ensures Stop only runs once.
+	stopOnce sync.Once
+}
+
+// scheduled is the part of a Nozzle the Scheduler drives.
+// Every *Nozzle[T] implements it, whatever its T.
+type scheduled interface {
+	calculate()
+}
+
+// NewScheduler creates a Scheduler that checks its Nozzles every resolution.
+// A resolution below MinInterval is raised to MinInterval, and one that is not positive never ticks.
+func NewScheduler(resolution time.Duration) *Scheduler {
+	if resolution > 0 && resolution < MinInterval {
+		resolution = MinInterval
+	}
+
+	s := &Scheduler{
+		nozzles: map[scheduled]struct{}{},
+		done:    make(chan struct{}),
+	}
+
+	go s.run(resolution)
+
+	return s
+}
+
+// run ticks every Nozzle every resolution, until Stop is called.
+func (s *Scheduler) run(resolution time.Duration) {
+	if resolution <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+
+	var due []scheduled
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		// Copy the Nozzles so their callbacks may create or close Nozzles on this Scheduler.
+		s.mut.Lock()
+
+		due = due[:0]
+		for n := range s.nozzles {
+			due = append(due, n)
+		}
+
+		s.mut.Unlock()
+
+		for _, n := range due {
+			n.calculate()
+		}
+	}
+}
+
+// Stop stops ticking every Nozzle on the Scheduler.
+// The Nozzles keep admitting calls at their current flow rate, but no longer adapt.
+// Calling Stop more than once does nothing.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// add starts ticking n.
+func (s *Scheduler) add(n scheduled) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.nozzles[n] = struct{}{}
+}
+
+// remove stops ticking n.
+func (s *Scheduler) remove(n scheduled) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	delete(s.nozzles, n)
+}
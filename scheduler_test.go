@@ -0,0 +1,41 @@
+package nozzle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	scheduler := nozzle.NewScheduler(time.Millisecond)
+	defer scheduler.Stop()
+
+	nozzles := make([]*nozzle.Nozzle[any], 10)
+
+	for i := range nozzles {
+		nozzles[i] = nozzle.New(nozzle.Options[any]{
+			Interval:              time.Millisecond * 10,
+			AllowedFailurePercent: 50,
+			Scheduler:             scheduler,
+		})
+	}
+
+	for i, noz := range nozzles {
+		noz.DoBool(func() (any, bool) {
+			return nil, false
+		})
+
+		noz.Wait()
+
+		if fr := noz.FlowRate(); fr != 99 {
+			t.Errorf("nozzle=%d Expected FlowRate=99 Got=%d", i, fr)
+		}
+
+		if err := noz.Close(); err != nil {
+			t.Errorf("nozzle=%d Expected Close err=nil Got=%v", i, err)
+		}
+	}
+}
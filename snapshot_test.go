@@ -0,0 +1,92 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	noz := New(Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	if s := noz.Snapshot(); s.FlowRate != 100 || s.State != Opening || s.IntervalSeq != 1 {
+		t.Errorf("Expected FlowRate=100 State=%s IntervalSeq=1 Got FlowRate=%d State=%s IntervalSeq=%d", Opening, s.FlowRate, s.State, s.IntervalSeq)
+	}
+
+	noz.DoBool(func() (any, bool) {
+		return nil, false
+	})
+
+	noz.mut.Lock()
+	noz.start = time.Time{}
+	noz.mut.Unlock()
+
+	noz.calculate()
+
+	if s := noz.Snapshot(); s.Failures != 1 || s.State != Closing || s.Reason == "" || s.IntervalSeq != 1 {
+		t.Errorf("Expected the tick's snapshot with Failures=1 State=%s IntervalSeq=1 Got=%+v", Closing, s)
+	}
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	// Snapshot must not wait for the lock.
+	noz.mut.Lock()
+	defer noz.mut.Unlock()
+
+	done := make(chan StateSnapshot)
+
+	go func() {
+		done <- noz.Snapshot()
+	}()
+
+	select {
+	case s := <-done:
+		if s.State != ForcedClosed || s.OverrideReason != "maintenance" || s.IntervalSeq != 2 {
+			t.Errorf("Expected State=%s OverrideReason=%q IntervalSeq=2 Got State=%s OverrideReason=%q IntervalSeq=%d", ForcedClosed, "maintenance", s.State, s.OverrideReason, s.IntervalSeq)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected Snapshot not to take the lock")
+	}
+}
+
+func TestSnapshotNow(t *testing.T) {
+	t.Parallel()
+
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	noz := New(Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		Clock:                 clock,
+		ManualTick:            true,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	for i := range 4 {
+		noz.DoBool(func() (any, bool) {
+			return nil, i > 0
+		})
+	}
+
+	clock.Advance(time.Minute)
+
+	// The published Snapshot still describes the start, but SnapshotNow sees the calls.
+	if s := noz.Snapshot(); s.Allowed != 0 {
+		t.Errorf("Expected Snapshot Allowed=0 Got=%d", s.Allowed)
+	}
+
+	s := noz.SnapshotNow()
+
+	if s.Allowed != 4 || s.Failures != 1 || s.FailureRate != 25 || s.IntervalSeq != 1 {
+		t.Errorf("Expected Allowed=4 Failures=1 FailureRate=25 IntervalSeq=1 Got=%+v", s)
+	}
+
+	if expected := clock.Now(); !s.Time.Equal(expected) {
+		t.Errorf("Expected Time=%s Got=%s", expected, s.Time)
+	}
+}
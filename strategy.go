@@ -0,0 +1,246 @@
+package nozzle
+
+import (
+	"time"
+
+	"github.com/justindfuller/nozzle/engine"
+)
+
+// StateSnapshot is a point-in-time view of a Nozzle.
+// The rates and counters describe the current interval, exactly as the Nozzle's getters would report them.
+// They are reset at the end of every interval; see nozzle.Stats for totals that never reset.
+type StateSnapshot struct {
+	// IntervalSeq is the IntervalStats.IntervalSeq of the interval the counts describe.
+	// Consecutive ticks produce consecutive values, so comparing two snapshots tells exactly how many intervals ended between them.
+	IntervalSeq uint64
+
+	// Time is when the snapshot was taken.
+	Time time.Time
+
+	// FlowRate is the percentage of calls being allowed.
+	FlowRate int64
+
+	// State is the direction the Nozzle is moving, or the state of an active manual override.
+	State State
+
+	// Reason explains the most recent decision, or gives the reason for an active manual override.
+	// See nozzle.Reason.
+	Reason string
+
+	// FailureRate is the percentage of allowed calls that failed.
+	FailureRate int64
+
+	// SuccessRate is the percentage of allowed calls that succeeded.
+	SuccessRate int64
+
+	// Allowed is the number of calls allowed.
+	Allowed int64
+
+	// Blocked is the number of calls blocked.
+	Blocked int64
+
+	// Successes is the number of allowed calls that succeeded.
+	Successes int64
+
+	// Failures is the number of allowed calls that failed.
+	Failures int64
+
+	// InFlight is the number of admitted calls still running.
+	// See Options.MaxConcurrent.
+	InFlight int64
+
+	// BulkheadBlocked is the number of calls blocked because Options.MaxConcurrent calls were already running.
+	// They are not included in Blocked.
+	BulkheadBlocked int64
+
+	// Paused reports whether the flow rate is frozen by Pause.
+	Paused bool
+
+	// Maintenance reports whether the last completed interval overlapped a window declared by Options.Maintenance.
+	// The flow rate is frozen while it is set.
+	Maintenance bool
+
+	// OverrideReason is the reason given for the active manual override.
+	// It is empty when the Nozzle is adapting on its own.
+	OverrideReason string
+
+	// OverrideRemaining is how long until the active manual override expires.
+	// It is zero when there is no override, or when the override does not expire.
+	OverrideRemaining time.Duration
+}
+
+// snapshot builds a StateSnapshot of the Nozzle.
+// The caller must hold the lock.
+func (n *Nozzle[T]) snapshot() StateSnapshot {
+	o := n.observe()
+
+	s := StateSnapshot{
+		IntervalSeq:     n.intervals + 1,
+		Time:            n.now(),
+		FlowRate:        o.FlowRate,
+		State:           o.State,
+		Reason:          o.Reason,
+		FailureRate:     o.FailureRate,
+		SuccessRate:     o.SuccessRate,
+		Allowed:         o.Allowed,
+		Blocked:         o.Blocked,
+		Successes:       o.Successes,
+		Failures:        o.Failures,
+		InFlight:        n.inFlight,
+		BulkheadBlocked: n.bulkheadBlocked,
+		Paused:          n.paused,
+		Maintenance:     n.maintenance != "",
+	}
+
+	if n.forced() != "" {
+		s.OverrideReason = n.overrideReason
+
+		if !n.overrideUntil.IsZero() {
+			s.OverrideRemaining = n.overrideUntil.Sub(n.now())
+		}
+	}
+
+	return s
+}
+
+// publish stores a fresh snapshot for Snapshot to serve, and sends it to subscribers if it changed.
+// The caller must hold the lock.
+func (n *Nozzle[T]) publish() {
+	n.store(n.snapshot())
+}
+
+// Snapshot reports the StateSnapshot built by the most recent tick, or by the most recent manual override, whichever came last.
+// It never takes the Nozzle's lock, so polling it for dashboards or health checks is wait-free and cannot slow down calls, however contended the Nozzle is.
+//
+// Since it is only refreshed at the end of each interval, its counts describe the interval that just ended rather than the current one.
+// Use SnapshotNow when you need the live values.
+//
+// Example:
+//
+//	http.HandleFunc("/nozzle", func(w http.ResponseWriter, r *http.Request) {
+//		json.NewEncoder(w).Encode(n.Snapshot())
+//	})
+func (n *Nozzle[T]) Snapshot() StateSnapshot {
+	if n.passThrough() {
+		return passThroughSnapshot()
+	}
+
+	if s := n.latest.Load(); s != nil {
+		return *s
+	}
+
+	return StateSnapshot{}
+}
+
+// SnapshotNow reports a StateSnapshot of the current interval, as it is right now.
+// Every field is read under a single lock acquisition, so unlike calling FlowRate, FailureRate, and the other getters one by one, the values are consistent with each other.
+// It takes the Nozzle's lock; prefer Snapshot for frequent polling.
+//
+// Example:
+//
+//	s := n.SnapshotNow()
+//	fmt.Printf("%s flowRate=%d allowed=%d blocked=%d\n", s.Time.Format(time.RFC3339), s.FlowRate, s.Allowed, s.Blocked)
+func (n *Nozzle[T]) SnapshotNow() StateSnapshot {
+	if n.passThrough() {
+		return passThroughSnapshot()
+	}
+
+	n.mut.RLock()
+	defer n.mut.RUnlock()
+
+	return n.snapshot()
+}
+
+// observe reports the engine's Observation of the current interval, as callers experience it.
+// While a manual override is active, the flow rate, state, and rates reflect the override.
+// The caller must hold the lock.
+func (n *Nozzle[T]) observe() engine.Observation {
+	o := n.engine.Observe()
+
+	if forced := n.forced(); forced != "" {
+		o = o.WithFlowRate(n.effectiveFlowRate())
+		o.State = forced
+		o.Reason = n.overrideReason
+	}
+
+	return o
+}
+
+// The adaptation algorithm and its strategies live in the engine package, so they can be embedded without a Nozzle.
+// They are re-exported here so most programs only need to import nozzle.
+type (
+	// Strategy decides how the flow rate changes at the end of each interval.
+	//
+	// The Nozzle decides the direction: it is Closing when the failure rate exceeds Options.AllowedFailurePercent, and Opening otherwise.
+	// Next receives the current flow rate and an Observation whose State is that direction, and returns the next flow rate.
+	// The result is clamped to [0, 100].
+	//
+	// Next is called with the Nozzle's lock held, so it must not call the Nozzle's methods.
+	// Implementations may keep state between calls, so each Nozzle needs its own instance.
+	//
+	// Example:
+	//
+	//	// linear moves the flow rate by 10 each interval.
+	//	type linear struct{}
+	//
+	//	func (linear) Next(current int64, o nozzle.Observation) int64 {
+	//		if o.State == nozzle.Closing {
+	//			return current - 10
+	//		}
+	//		return current + 10
+	//	}
+	Strategy = engine.Strategy
+
+	// Observation is the view of the current interval passed to a Strategy.
+	// See engine.Observation.
+	Observation = engine.Observation
+
+	// Exponential is the default Strategy.
+	// See engine.Exponential.
+	Exponential = engine.Exponential
+
+	// AIMD is an additive-increase/multiplicative-decrease Strategy.
+	// See engine.AIMD.
+	AIMD = engine.AIMD
+
+	// PID is a Strategy that steers the failure rate toward a target.
+	// See engine.PID.
+	PID = engine.PID
+
+	// Ramp is a Strategy that follows separate curves for opening and closing.
+	// See engine.Ramp.
+	Ramp = engine.Ramp
+
+	// Curve decides how far a Ramp moves the flow rate on each consecutive interval in the same direction.
+	// See engine.Curve.
+	Curve = engine.Curve
+)
+
+const (
+	// DefaultKp is the proportional gain PID uses when no gains are set.
+	DefaultKp = engine.DefaultKp
+
+	// DefaultKi is the integral gain PID uses when no gains are set.
+	DefaultKi = engine.DefaultKi
+
+	// DefaultKd is the derivative gain PID uses when no gains are set.
+	DefaultKd = engine.DefaultKd
+)
+
+// LinearCurve moves the flow rate by the same amount every interval.
+// Example: LinearCurve(1) reopens by 1% per interval.
+func LinearCurve(delta int64) Curve {
+	return engine.LinearCurve(delta)
+}
+
+// ExponentialCurve moves the flow rate by 1, 2, 4, 8, ... doubling every interval.
+// It is the same curve the Exponential strategy follows.
+func ExponentialCurve() Curve {
+	return engine.ExponentialCurve()
+}
+
+// StepCurve moves the flow rate by each of deltas in turn, then keeps using the last one.
+// Example: StepCurve(1, 1, 5, 10) moves by 1, 1, 5, 10, 10, 10, ...
+func StepCurve(deltas ...int64) Curve {
+	return engine.StepCurve(deltas...)
+}
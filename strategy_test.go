@@ -0,0 +1,185 @@
+package nozzle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestExponential(t *testing.T) {
+	t.Parallel()
+
+	var strategy nozzle.Exponential
+
+	flowRate := int64(100)
+	expected := []int64{99, 97, 93, 85, 69, 37, 0, 1, 3, 7, 15, 31, 63, 100}
+	states := []nozzle.State{
+		nozzle.Closing, nozzle.Closing, nozzle.Closing, nozzle.Closing, nozzle.Closing, nozzle.Closing, nozzle.Closing,
+		nozzle.Opening, nozzle.Opening, nozzle.Opening, nozzle.Opening, nozzle.Opening, nozzle.Opening, nozzle.Opening,
+	}
+
+	for i, state := range states {
+		// The nozzle clamps whatever the strategy returns.
+		flowRate = min(max(strategy.Next(flowRate, nozzle.Observation{State: state}), 0), 100)
+
+		if flowRate != expected[i] {
+			t.Fatalf("step=%d Expected FlowRate=%d Got=%d", i, expected[i], flowRate)
+		}
+	}
+}
+
+// linear is a Strategy that moves the flow rate by 10 each interval.
+type linear struct{}
+
+func (linear) Next(current int64, o nozzle.Observation) int64 {
+	if o.State == nozzle.Closing {
+		return current - 10
+	}
+
+	return current + 10
+}
+
+func TestCustomStrategy(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+		Strategy:              linear{},
+	})
+
+	for _, expected := range []int64{90, 80, 70} {
+		noz.DoBool(func() (any, bool) {
+			return nil, false
+		})
+
+		noz.Wait()
+
+		if fr := noz.FlowRate(); fr != expected {
+			t.Errorf("Expected FlowRate=%d Got=%d", expected, fr)
+		}
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		strategy nozzle.AIMD
+		state    nozzle.State
+		expected []int64
+	}{
+		{
+			strategy: nozzle.AIMD{Increase: 5, DecreaseFactor: 0.5},
+			state:    nozzle.Closing,
+			expected: []int64{50, 25, 12, 6, 3, 1, 0},
+		},
+		{
+			strategy: nozzle.AIMD{Increase: 5, DecreaseFactor: 0.5},
+			state:    nozzle.Opening,
+			expected: []int64{5, 10, 15, 20},
+		},
+		{
+			strategy: nozzle.AIMD{},
+			state:    nozzle.Opening,
+			expected: []int64{1, 2, 3},
+		},
+		{
+			strategy: nozzle.AIMD{DecreaseFactor: 0.9},
+			state:    nozzle.Closing,
+			expected: []int64{90, 81, 72},
+		},
+	}
+
+	for i, test := range tests {
+		flowRate := int64(100)
+		if test.state == nozzle.Opening {
+			flowRate = 0
+		}
+
+		for j, expected := range test.expected {
+			flowRate = test.strategy.Next(flowRate, nozzle.Observation{State: test.state})
+
+			if flowRate != expected {
+				t.Fatalf("test=%d step=%d Expected FlowRate=%d Got=%d", i, j, expected, flowRate)
+			}
+		}
+	}
+}
+
+func TestPID(t *testing.T) {
+	t.Parallel()
+
+	strategy := nozzle.PID{TargetFailurePercent: 10, Kp: 0.5}
+
+	tests := []struct {
+		failureRate int64
+		expected    int64
+	}{
+		{failureRate: 90, expected: 60},
+		{failureRate: 50, expected: 40},
+		{failureRate: 10, expected: 40},
+		{failureRate: 0, expected: 45},
+	}
+
+	flowRate := int64(100)
+
+	for i, test := range tests {
+		flowRate = strategy.Next(flowRate, nozzle.Observation{FailureRate: test.failureRate})
+
+		if flowRate != test.expected {
+			t.Errorf("step=%d Expected FlowRate=%d Got=%d", i, test.expected, flowRate)
+		}
+	}
+
+	defaults := nozzle.PID{TargetFailurePercent: 10}
+
+	// err=-40: 0.5*-40 + 0.1*-40 + 0.1*0 = -24
+	if fr := defaults.Next(100, nozzle.Observation{FailureRate: 50}); fr != 76 {
+		t.Errorf("Expected FlowRate=76 Got=%d", fr)
+	}
+}
+
+func TestRamp(t *testing.T) {
+	t.Parallel()
+
+	strategy := nozzle.Ramp{
+		Open:  nozzle.LinearCurve(1),
+		Close: nozzle.StepCurve(1, 5, 20),
+	}
+
+	tests := []struct {
+		state    nozzle.State
+		expected int64
+	}{
+		{state: nozzle.Closing, expected: 99},
+		{state: nozzle.Closing, expected: 94},
+		{state: nozzle.Closing, expected: 74},
+		{state: nozzle.Closing, expected: 54},
+		{state: nozzle.Opening, expected: 55},
+		{state: nozzle.Opening, expected: 56},
+		{state: nozzle.Closing, expected: 55},
+	}
+
+	flowRate := int64(100)
+
+	for i, test := range tests {
+		flowRate = strategy.Next(flowRate, nozzle.Observation{State: test.state})
+
+		if flowRate != test.expected {
+			t.Errorf("step=%d Expected FlowRate=%d Got=%d", i, test.expected, flowRate)
+		}
+	}
+
+	exponential := nozzle.Ramp{}
+	flowRate = 100
+
+	for _, expected := range []int64{99, 97, 93, 85, 69, 37} {
+		flowRate = exponential.Next(flowRate, nozzle.Observation{State: nozzle.Closing})
+
+		if flowRate != expected {
+			t.Errorf("Expected FlowRate=%d Got=%d", expected, flowRate)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package nozzle
+
+// Subscribe returns a channel that receives a StateSnapshot every time the Nozzle's flow rate, state, pause, or maintenance changes,
+// whether at the end of an interval or by an override, Pause, Resume, or Reset.
+// Unlike Options.OnStateChange, any number of consumers may subscribe, at any time.
+//
+// The channel holds up to buffer snapshots, at least 1. A consumer that falls behind never slows the Nozzle down:
+// when its channel is full, its oldest snapshot is dropped, so the newest one is always delivered.
+//
+// Call the returned function to unsubscribe, which closes the channel. Close closes every channel too.
+// Subscribing to a closed Nozzle returns a closed channel.
+//
+// Example:
+//
+//	changes, unsubscribe := n.Subscribe(8)
+//	defer unsubscribe()
+//
+//	for s := range changes {
+//		log.Printf("nozzle is %s at %d%%: %s", s.State, s.FlowRate, s.Reason)
+//	}
+func (n *Nozzle[T]) Subscribe(buffer int) (<-chan StateSnapshot, func()) {
+	subscriber := make(chan StateSnapshot, max(buffer, 1))
+
+	// A pass-through never changes, so it is treated like a closed Nozzle.
+	if n.passThrough() {
+		close(subscriber)
+
+		return subscriber, func() {}
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if n.closed {
+		close(subscriber)
+
+		return subscriber, func() {}
+	}
+
+	if n.subscribers == nil {
+		n.subscribers = map[chan StateSnapshot]struct{}{}
+	}
+
+	n.subscribers[subscriber] = struct{}{}
+
+	return subscriber, func() {
+		n.mut.Lock()
+		defer n.mut.Unlock()
+
+		if _, ok := n.subscribers[subscriber]; ok {
+			delete(n.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// store makes snapshot the one Snapshot serves, and sends it to every subscriber if the flow rate, state, pause, or maintenance changed.
+// The caller must hold the lock.
+func (n *Nozzle[T]) store(snapshot StateSnapshot) {
+	previous := n.latest.Swap(&snapshot)
+
+	if previous != nil && previous.FlowRate == snapshot.FlowRate && previous.State == snapshot.State && previous.Paused == snapshot.Paused && previous.Maintenance == snapshot.Maintenance {
+		return
+	}
+
+	for subscriber := range n.subscribers {
+		select {
+		case subscriber <- snapshot:
+			continue
+		default:
+		}
+
+		// The subscriber is behind: drop its oldest snapshot to make room.
+		// Only senders hold the lock, so the room cannot be taken before the send.
+		select {
+		case <-subscriber:
+		default:
+		}
+
+		subscriber <- snapshot
+	}
+}
+
+// closeSubscribers closes every subscriber's channel.
+// The caller must hold the lock.
+func (n *Nozzle[T]) closeSubscribers() {
+	for subscriber := range n.subscribers {
+		close(subscriber)
+	}
+
+	clear(n.subscribers)
+}
@@ -0,0 +1,69 @@
+package nozzle_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestSubscribe(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+
+	fast, unsubscribe := noz.Subscribe(4)
+	slow, _ := noz.Subscribe(1)
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	if s := <-fast; s.State != nozzle.ForcedClosed || s.FlowRate != 0 {
+		t.Errorf("Expected State=%s FlowRate=0 Got State=%s FlowRate=%d", nozzle.ForcedClosed, s.State, s.FlowRate)
+	}
+
+	noz.Pause()
+	noz.Pause()
+	noz.Unforce()
+
+	if s := <-fast; s.State != nozzle.ForcedClosed || !s.Paused {
+		t.Errorf("Expected State=%s Paused=true Got State=%s Paused=%t", nozzle.ForcedClosed, s.State, s.Paused)
+	}
+
+	if s := <-fast; s.State == nozzle.ForcedClosed || !s.Paused {
+		t.Errorf("Expected the override to end while Paused=true Got State=%s Paused=%t", s.State, s.Paused)
+	}
+
+	select {
+	case s := <-fast:
+		t.Errorf("Expected no snapshot for a Pause that changed nothing Got=%+v", s)
+	default:
+	}
+
+	// The slow subscriber only kept the newest snapshot.
+	if s := <-slow; s.State == nozzle.ForcedClosed || !s.Paused {
+		t.Errorf("Expected the newest snapshot Got State=%s Paused=%t", s.State, s.Paused)
+	}
+
+	unsubscribe()
+	unsubscribe()
+
+	if _, ok := <-fast; ok {
+		t.Error("Expected unsubscribing to close the channel")
+	}
+
+	if err := noz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-slow; ok {
+		t.Error("Expected Close to close the channel")
+	}
+
+	closed, _ := noz.Subscribe(1)
+	if _, ok := <-closed; ok {
+		t.Error("Expected subscribing to a closed Nozzle to return a closed channel")
+	}
+}
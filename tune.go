@@ -0,0 +1,79 @@
+package nozzle
+
+import "time"
+
+// SetAllowedFailurePercent changes Options.AllowedFailurePercent of a running Nozzle.
+// It takes effect at the next tick, so the interval in progress is still judged by the threshold it started with.
+// Use it to apply configuration pushed from a control plane without recreating the Nozzle.
+// It is safe to call concurrently with every other method.
+//
+// Example:
+//
+//	n.SetAllowedFailurePercent(cfg.AllowedFailurePercent)
+func (n *Nozzle[T]) SetAllowedFailurePercent(percent int64) {
+	if n.passThrough() {
+		return
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	n.nextAllowedFailurePercent = &percent
+}
+
+// SetInterval changes Options.Interval of a running Nozzle.
+// It takes effect at the next tick: the interval in progress ends on its original schedule, and the ones after it last d.
+// Like in New, a d below MinInterval is raised to MinInterval.
+// A d that is not positive does nothing, and neither does any d for a Nozzle created without a positive Interval,
+// even one ticked with Options.ManualTick, so its rates are never rescaled to an Interval it was not designed for.
+// It is safe to call concurrently with every other method.
+//
+// Example:
+//
+//	n.SetInterval(cfg.Interval)
+func (n *Nozzle[T]) SetInterval(d time.Duration) {
+	if n.passThrough() {
+		return
+	}
+
+	if d <= 0 {
+		return
+	}
+
+	n.mut.Lock()
+	defer n.mut.Unlock()
+
+	if n.options().Interval <= 0 {
+		return
+	}
+
+	n.nextInterval = max(d, MinInterval)
+}
+
+// tune applies the changes made by SetAllowedFailurePercent and SetInterval.
+// The caller must hold the lock.
+func (n *Nozzle[T]) tune() {
+	if n.nextAllowedFailurePercent == nil && n.nextInterval <= 0 {
+		return
+	}
+
+	options := *n.options()
+
+	if n.nextAllowedFailurePercent != nil {
+		options.AllowedFailurePercent = *n.nextAllowedFailurePercent
+		n.engine.SetAllowedFailurePercent(*n.nextAllowedFailurePercent)
+		n.nextAllowedFailurePercent = nil
+	}
+
+	if n.nextInterval > 0 {
+		options.Interval = n.nextInterval
+		n.nextInterval = 0
+	}
+
+	n.config.Store(&options)
+}
+
+// interval reports the Interval in effect.
+func (n *Nozzle[T]) interval() time.Duration {
+	return n.options().Interval
+}
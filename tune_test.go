@@ -0,0 +1,94 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetAllowedFailurePercent(t *testing.T) {
+	t.Parallel()
+
+	noz := newTestNozzle(Options[any]{
+		AllowedFailurePercent: 50,
+	}, 100)
+
+	interval := func() {
+		// 6 of 10 calls fail.
+		for i := range 10 {
+			noz.DoBool(func() (any, bool) {
+				return nil, i >= 6
+			})
+		}
+
+		noz.calculate()
+	}
+
+	noz.SetAllowedFailurePercent(90)
+
+	// The interval in progress is judged by the threshold it started with.
+	interval()
+
+	if s := noz.State(); s != Closing {
+		t.Errorf("Expected State=%s Got=%s", Closing, s)
+	}
+
+	interval()
+
+	if s := noz.State(); s != Opening {
+		t.Errorf("Expected State=%s with the new threshold Got=%s", Opening, s)
+	}
+
+	if d := noz.DescribeConfig(); d.AllowedFailurePercent != 90 {
+		t.Errorf("Expected AllowedFailurePercent=90 Got=%d", d.AllowedFailurePercent)
+	}
+}
+
+func TestSetInterval(t *testing.T) {
+	t.Parallel()
+
+	noz := New(Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	noz.SetInterval(0)
+	noz.SetInterval(time.Hour)
+
+	// The interval in progress ends on its original schedule.
+	if _, err := noz.WaitSnapshot(context.Background()); err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if _, err := noz.WaitSnapshot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected no tick within the new Interval, err=%v Got=%v", context.DeadlineExceeded, err)
+	}
+
+	if d := noz.DescribeConfig(); d.Interval != time.Hour {
+		t.Errorf("Expected Interval=%s Got=%s", time.Hour, d.Interval)
+	}
+}
+
+func TestSetIntervalWithoutInterval(t *testing.T) {
+	t.Parallel()
+
+	noz := New(Options[any]{
+		AllowedFailurePercent: 50,
+		ManualTick:            true,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	noz.SetInterval(time.Second)
+
+	// A ManualTick Nozzle still ticks, but keeps the Interval it was created with.
+	noz.Tick()
+
+	if d := noz.DescribeConfig(); d.Interval != 0 {
+		t.Errorf("Expected Interval=0 Got=%s", d.Interval)
+	}
+}
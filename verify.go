@@ -0,0 +1,68 @@
+package nozzle
+
+import (
+	"context"
+	"time"
+)
+
+// verify runs Options.Verify every Options.VerifyInterval, or every interval if it is not set, until Close is called.
+// Without a positive interval, it never runs.
+func (n *Nozzle[T]) verify(interval time.Duration) {
+	defer n.ticking.Done()
+
+	if n.options().VerifyInterval > 0 {
+		interval = n.options().VerifyInterval
+	}
+
+	if interval <= 0 {
+		<-n.done
+
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-n.done
+		cancel()
+	}()
+
+	ticker := n.clock().NewTicker(max(interval, MinInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C():
+		}
+
+		n.ending.Add(1)
+
+		if err := n.options().Verify(ctx); err != nil && ctx.Err() == nil {
+			n.verificationFailed(err)
+		}
+
+		n.ending.Add(-1)
+	}
+}
+
+// verificationFailed marks the current interval as overloaded, so the Nozzle closes at its end.
+// The caller must not hold the lock.
+func (n *Nozzle[T]) verificationFailed(err error) {
+	reason := "verification failed: " + err.Error()
+
+	n.mut.Lock()
+
+	n.totals.VerificationFailures++
+	n.engine.Overload(reason)
+
+	n.mut.Unlock()
+
+	n.emit(Event{
+		Type:   EventVerificationFailed,
+		Time:   n.now(),
+		Reason: reason,
+	})
+}
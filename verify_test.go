@@ -0,0 +1,99 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	errCorrupt := errors.New("checksum mismatch")
+
+	var events atomic.Int64
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 20,
+		AllowedFailurePercent: 50,
+		VerifyInterval:        time.Millisecond * 5,
+		Verify: func(context.Context) error {
+			return errCorrupt
+		},
+		OnEvent: func(e nozzle.Event) {
+			if e.Type == nozzle.EventVerificationFailed {
+				events.Add(1)
+			}
+		},
+	})
+	defer noz.Close() //nolint:errcheck
+
+	// Every call succeeds, so only verification can close the Nozzle.
+	for range 5 {
+		noz.DoBool(func() (any, bool) {
+			return nil, true
+		})
+
+		snapshot, err := noz.WaitSnapshot(context.Background())
+		if err != nil {
+			t.Fatalf("Expected err=nil Got=%v", err)
+		}
+
+		if snapshot.State == nozzle.Closing {
+			if !strings.Contains(snapshot.Reason, errCorrupt.Error()) {
+				t.Errorf("Expected Reason to contain %q Got=%q", errCorrupt, snapshot.Reason)
+			}
+
+			break
+		}
+	}
+
+	if state := noz.State(); state != nozzle.Closing {
+		t.Errorf("Expected State=%s Got=%s", nozzle.Closing, state)
+	}
+
+	if failures := noz.Stats().VerificationFailures; failures == 0 {
+		t.Errorf("Expected VerificationFailures>0 Got=%d", failures)
+	}
+
+	if events.Load() == 0 {
+		t.Error("Expected EventVerificationFailed to be reported")
+	}
+}
+
+func TestVerifyClose(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{}, 1)
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+		Verify: func(ctx context.Context) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+
+			<-ctx.Done()
+
+			return ctx.Err()
+		},
+	})
+
+	<-started
+
+	// Close cancels a running Verify, and does not count it as a failure.
+	if err := noz.Close(); err != nil {
+		t.Fatalf("Expected Close err=nil Got=%v", err)
+	}
+
+	if failures := noz.Stats().VerificationFailures; failures != 0 {
+		t.Errorf("Expected VerificationFailures=0 Got=%d", failures)
+	}
+}
@@ -0,0 +1,199 @@
+package nozzle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClosed is returned by WaitSnapshot when the Nozzle is closed before the next tick.
+// It is also wrapped by the panic of calls made after Close when Options.ClosedPanic is set.
+// Registry.New returns it once the Registry is closed.
+var ErrClosed = errors.New("nozzle: closed")
+
+// ErrWaitTimeout is matched by the error DoWaitContext returns when a call waited Options.MaxWait without being allowed.
+// Unlike ctx.Err(), it means the caller's context still had time left.
+var ErrWaitTimeout = errors.New("nozzle: wait timed out")
+
+// WaitError is the error DoWaitContext returns when a call waited Options.MaxWait without being allowed.
+// It matches ErrWaitTimeout, and ErrBlocked through the BlockedError of the call's last attempt.
+//
+// Example:
+//
+//	var waited *nozzle.WaitError
+//	if errors.As(err, &waited) {
+//		log.Printf("gave up after %s, retry after %s", waited.Waited, waited.Blocked.RetryAfter)
+//	}
+type WaitError struct {
+	// Waited is how long the call waited before giving up.
+	Waited time.Duration
+
+	// Blocked is the BlockedError of the call's last attempt.
+	Blocked *BlockedError
+}
+
+// Error implements error.
+func (e *WaitError) Error() string {
+	return fmt.Sprintf("%s after %s: %s", ErrWaitTimeout, e.Waited, e.Blocked)
+}
+
+// Unwrap returns ErrWaitTimeout and the BlockedError of the call's last attempt.
+func (e *WaitError) Unwrap() []error {
+	return []error{ErrWaitTimeout, e.Blocked}
+}
+
+// WaitSnapshot blocks until the Nozzle processes the next tick, and returns the snapshot that tick computed.
+// The snapshot reflects the decision made at the end of the interval: the new flow rate, state, and reason, along with the counts of the interval that just ended.
+//
+// Any number of goroutines may wait at once; every one of them is released by the same tick and receives the same snapshot.
+// It returns ctx.Err() if ctx is done first, and ErrClosed if the Nozzle is closed, or already was.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//
+//	snapshot, err := n.WaitSnapshot(ctx)
+//	if err != nil {
+//		// the Nozzle was closed, or the tick did not come in time.
+//	}
+//
+//	fmt.Println(snapshot.FlowRate, snapshot.Reason)
+func (n *Nozzle[T]) WaitSnapshot(ctx context.Context) (StateSnapshot, error) {
+	if n.passThrough() {
+		return StateSnapshot{}, ErrClosed
+	}
+
+	n.mut.Lock()
+
+	if n.closed {
+		n.mut.Unlock()
+
+		return StateSnapshot{}, ErrClosed
+	}
+
+	// Buffered, so a tick never blocks on a waiter that has given up.
+	waiter := make(chan StateSnapshot, 1)
+
+	if n.waiters == nil {
+		n.waiters = map[chan StateSnapshot]struct{}{}
+	}
+
+	n.waiters[waiter] = struct{}{}
+
+	n.mut.Unlock()
+
+	select {
+	case snapshot, ok := <-waiter:
+		if !ok {
+			return StateSnapshot{}, ErrClosed
+		}
+
+		return snapshot, nil
+	case <-ctx.Done():
+		n.mut.Lock()
+		delete(n.waiters, waiter)
+		n.mut.Unlock()
+
+		return StateSnapshot{}, ctx.Err()
+	}
+}
+
+// release sends snapshot to every waiter and forgets them.
+// The caller must hold the lock.
+func (n *Nozzle[T]) release(snapshot StateSnapshot) {
+	for waiter := range n.waiters {
+		waiter <- snapshot
+	}
+
+	clear(n.waiters)
+}
+
+// closeWaiters wakes every waiter without a snapshot, so they return ErrClosed.
+// The caller must hold the lock.
+func (n *Nozzle[T]) closeWaiters() {
+	for waiter := range n.waiters {
+		close(waiter)
+	}
+
+	clear(n.waiters)
+}
+
+// DoWaitContext is like DoErrorContext, except a blocked call waits for the Nozzle's next decision and tries again, instead of failing with ErrBlocked.
+// It suits batch workloads that would rather be delayed than drop work.
+//
+// A waiting call tries again once per tick, so it does not inflate the blocked count of the interval it waits in.
+// It waits until the call is allowed, until ctx is done, until Options.MaxWait has passed, or until the Nozzle is closed.
+// When ctx is done, it returns ctx.Err(). After MaxWait, it returns a *WaitError, which matches ErrWaitTimeout.
+// When the Nozzle is closed, it returns the BlockedError of its last attempt, since no tick will come to allow it.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//
+//	for _, job := range batch {
+//		_, err := n.DoWaitContext(ctx, func(ctx context.Context) (any, error) {
+//			return nil, job.Run(ctx)
+//		})
+//		if err != nil {
+//			return err
+//		}
+//	}
+func (n *Nozzle[T]) DoWaitContext(ctx context.Context, callback func(context.Context) (T, error)) (T, error) {
+	if n.passedThrough() {
+		return callback(ctx)
+	}
+
+	if _, ok := n.reentered(ctx); ok {
+		// A nested call is handled exactly like DoErrorContext, so it never waits on its own parent.
+		return n.DoErrorContext(ctx, callback)
+	}
+
+	start := time.Now()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return *new(T), err
+		}
+
+		decision, ok := n.admit(ctx, 1)
+		if ok {
+			res, panicked, err := n.call(ctx, decision, callback)
+			if !panicked {
+				n.outcomeContext(ctx, err)
+			}
+
+			return res, err
+		}
+
+		blocked := n.blocked(decision)
+
+		waitCtx, cancel := ctx, context.CancelFunc(func() {})
+
+		if maxWait := n.options().MaxWait; maxWait > 0 {
+			remaining := maxWait - time.Since(start)
+			if remaining <= 0 {
+				return *new(T), &WaitError{Waited: time.Since(start), Blocked: blocked}
+			}
+
+			waitCtx, cancel = context.WithTimeout(ctx, remaining)
+		}
+
+		_, err := n.WaitSnapshot(waitCtx)
+
+		cancel()
+
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrClosed):
+			return *new(T), blocked
+		case ctx.Err() == nil:
+			// Only MaxWait ends the wait while the caller's ctx still has time left.
+			return *new(T), &WaitError{Waited: time.Since(start), Blocked: blocked}
+		default:
+			return *new(T), err
+		}
+	}
+}
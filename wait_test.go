@@ -0,0 +1,215 @@
+package nozzle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestWaitSnapshot(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	const waiters = 10
+
+	var wg sync.WaitGroup
+
+	snapshots := make([]nozzle.StateSnapshot, waiters)
+	errs := make([]error, waiters)
+
+	for i := range waiters {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			snapshots[i], errs[i] = noz.WaitSnapshot(context.Background())
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range waiters {
+		if errs[i] != nil {
+			t.Errorf("waiter=%d Expected err=nil Got=%v", i, errs[i])
+		}
+
+		if snapshots[i].Reason == "" {
+			t.Errorf("waiter=%d Expected a Reason from the tick Got=%+v", i, snapshots[i])
+		}
+	}
+}
+
+func TestWaitSnapshotCancel(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	if _, err := noz.WaitSnapshot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected err=%v Got=%v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWaitSnapshotClose(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+	})
+
+	errs := make(chan error)
+
+	go func() {
+		_, err := noz.WaitSnapshot(context.Background())
+		errs <- err
+	}()
+
+	// Give the waiter a chance to start waiting before closing.
+	time.Sleep(time.Millisecond * 10)
+
+	if err := noz.Close(); err != nil {
+		t.Fatalf("Expected Close err=nil Got=%v", err)
+	}
+
+	if err := <-errs; !errors.Is(err, nozzle.ErrClosed) {
+		t.Errorf("Expected err=%v Got=%v", nozzle.ErrClosed, err)
+	}
+
+	if _, err := noz.WaitSnapshot(context.Background()); !errors.Is(err, nozzle.ErrClosed) {
+		t.Errorf("Expected err=%v after Close Got=%v", nozzle.ErrClosed, err)
+	}
+}
+
+func TestDoWaitContext(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	noz.ForceCloseFor(time.Millisecond*50, "maintenance")
+
+	if _, err := noz.DoErrorContext(context.Background(), func(context.Context) (any, error) {
+		return nil, nil
+	}); !errors.Is(err, nozzle.ErrBlocked) {
+		t.Fatalf("Expected err=%v before waiting Got=%v", nozzle.ErrBlocked, err)
+	}
+
+	var calls int
+
+	res, err := noz.DoWaitContext(context.Background(), func(context.Context) (any, error) {
+		calls++
+
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatalf("Expected err=nil Got=%v", err)
+	}
+
+	if res != "done" || calls != 1 {
+		t.Errorf("Expected res=done calls=1 Got res=%v calls=%d", res, calls)
+	}
+
+	if blocked := noz.Stats().Blocked; blocked > 10 {
+		t.Errorf("Expected waiting to retry once per tick, Blocked<=10 Got=%d", blocked)
+	}
+}
+
+func TestDoWaitContextCancel(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+	})
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+
+	if _, err := noz.DoWaitContext(ctx, func(context.Context) (any, error) {
+		return nil, nil
+	}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected err=%v Got=%v", context.DeadlineExceeded, err)
+	}
+
+	errs := make(chan error)
+
+	go func() {
+		_, err := noz.DoWaitContext(context.Background(), func(context.Context) (any, error) {
+			return nil, nil
+		})
+		errs <- err
+	}()
+
+	// Give the call a chance to start waiting before closing.
+	time.Sleep(time.Millisecond * 10)
+
+	if err := noz.Close(); err != nil {
+		t.Fatalf("Expected Close err=nil Got=%v", err)
+	}
+
+	if err := <-errs; !errors.Is(err, nozzle.ErrBlocked) {
+		t.Errorf("Expected err=%v after Close Got=%v", nozzle.ErrBlocked, err)
+	}
+}
+
+func TestDoWaitContextMaxWait(t *testing.T) {
+	t.Parallel()
+
+	noz := nozzle.New(nozzle.Options[any]{
+		Interval:              time.Millisecond * 10,
+		AllowedFailurePercent: 50,
+		MaxWait:               time.Millisecond * 30,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	noz.ForceCloseFor(time.Hour, "maintenance")
+
+	_, err := noz.DoWaitContext(context.Background(), func(context.Context) (any, error) {
+		return nil, nil
+	})
+
+	var waited *nozzle.WaitError
+	if !errors.As(err, &waited) {
+		t.Fatalf("Expected a *nozzle.WaitError Got=%v", err)
+	}
+
+	if !errors.Is(err, nozzle.ErrWaitTimeout) || !errors.Is(err, nozzle.ErrBlocked) || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected err to match %v and %v, not %v Got=%v", nozzle.ErrWaitTimeout, nozzle.ErrBlocked, context.DeadlineExceeded, err)
+	}
+
+	if waited.Waited < time.Millisecond*30 || waited.Blocked == nil || waited.Blocked.State != nozzle.ForcedClosed {
+		t.Errorf("Expected Waited>=30ms and the last attempt blocked by %s Got=%+v", nozzle.ForcedClosed, waited)
+	}
+
+	// A context that ends first is still reported as the caller's own.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+
+	if _, err := noz.DoWaitContext(ctx, func(context.Context) (any, error) {
+		return nil, nil
+	}); !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nozzle.ErrWaitTimeout) {
+		t.Errorf("Expected err=%v Got=%v", context.DeadlineExceeded, err)
+	}
+}
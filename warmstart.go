@@ -0,0 +1,89 @@
+package nozzle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/justindfuller/nozzle/engine"
+)
+
+// DefaultWarmStartTimeout is used when Options.WarmStartTimeout is zero.
+const DefaultWarmStartTimeout = time.Second
+
+// ErrWarmStart is wrapped by errors returned from WarmStartURL.
+var ErrWarmStart = errors.New("nozzle: warm start failed")
+
+// initialFlowRate decides the flow rate a new Nozzle starts with.
+// It prefers Options.WarmStart, then Options.InitialFlowRate, then fully open.
+func (n *Nozzle[T]) initialFlowRate() int64 {
+	fallback := int64(100)
+	if n.options().InitialFlowRate != 0 {
+		fallback = engine.Clamp(n.options().InitialFlowRate)
+	}
+
+	if n.options().WarmStart == nil {
+		return fallback
+	}
+
+	timeout := n.options().WarmStartTimeout
+	if timeout <= 0 {
+		timeout = DefaultWarmStartTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	flowRate, err := n.options().WarmStart(ctx)
+	if err != nil {
+		if n.options().Logger != nil {
+			n.options().Logger.Warn("nozzle: warm start failed, using the initial flow rate", "error", err, "flowRate", fallback)
+		}
+
+		return fallback
+	}
+
+	return engine.Clamp(flowRate)
+}
+
+// WarmStartURL creates an Options.WarmStart function that reads the starting flow rate from url.
+// The URL must respond with a 2xx status and a JSON number between 0 and 100, such as `42`.
+// This lets a fleet publish its average flow rate for a dependency, so new instances do not start fully open during an incident.
+//
+// Example:
+//
+//	nozzle.New(nozzle.Options[any]{
+//		Interval:              time.Second,
+//		AllowedFailurePercent: 50,
+//		InitialFlowRate:       100,
+//		WarmStart:             nozzle.WarmStartURL("http://flow-rates.internal/payments"),
+//	})
+func WarmStartURL(url string) func(context.Context) (int64, error) {
+	return func(ctx context.Context) (int64, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrWarmStart, err)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrWarmStart, err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return 0, fmt.Errorf("%w: unexpected status %d", ErrWarmStart, res.StatusCode)
+		}
+
+		var flowRate int64
+
+		if err := json.NewDecoder(res.Body).Decode(&flowRate); err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrWarmStart, err)
+		}
+
+		return flowRate, nil
+	}
+}
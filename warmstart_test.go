@@ -0,0 +1,94 @@
+package nozzle_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestWarmStart(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			fmt.Fprint(w, "42")
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name     string
+		options  nozzle.Options[any]
+		expected int64
+	}{
+		{
+			name:     "default",
+			options:  nozzle.Options[any]{},
+			expected: 100,
+		},
+		{
+			name:     "initial",
+			options:  nozzle.Options[any]{InitialFlowRate: 30},
+			expected: 30,
+		},
+		{
+			name: "url",
+			options: nozzle.Options[any]{
+				InitialFlowRate: 30,
+				WarmStart:       nozzle.WarmStartURL(server.URL + "/ok"),
+			},
+			expected: 42,
+		},
+		{
+			name: "not found",
+			options: nozzle.Options[any]{
+				InitialFlowRate: 30,
+				WarmStart:       nozzle.WarmStartURL(server.URL + "/missing"),
+			},
+			expected: 30,
+		},
+		{
+			name: "timeout",
+			options: nozzle.Options[any]{
+				InitialFlowRate:  30,
+				WarmStart:        nozzle.WarmStartURL(server.URL + "/slow"),
+				WarmStartTimeout: 10 * time.Millisecond,
+			},
+			expected: 30,
+		},
+		{
+			name: "clamped",
+			options: nozzle.Options[any]{
+				WarmStart: func(context.Context) (int64, error) {
+					return 250, nil
+				},
+			},
+			expected: 100,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			test.options.Interval = time.Hour
+			test.options.AllowedFailurePercent = 50
+
+			noz := nozzle.New(test.options)
+
+			if fr := noz.FlowRate(); fr != test.expected {
+				t.Errorf("Expected FlowRate=%d Got=%d", test.expected, fr)
+			}
+		})
+	}
+}
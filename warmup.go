@@ -0,0 +1,34 @@
+package nozzle
+
+import "fmt"
+
+// DefaultWarmUpFlowRate is used when Options.WarmUp is set and Options.WarmUpFlowRate is zero.
+const DefaultWarmUpFlowRate = 10
+
+// warmUpFlowRate is the flow rate a Nozzle with Options.WarmUp starts at.
+func (n *Nozzle[T]) warmUpFlowRate() int64 {
+	if n.options().WarmUpFlowRate > 0 {
+		return n.options().WarmUpFlowRate
+	}
+
+	return DefaultWarmUpFlowRate
+}
+
+// warmUp caps the flow rate while the Nozzle is within Options.WarmUp of its creation.
+// The cap rises linearly from the warm-up flow rate to 100.
+// The caller must hold the lock.
+func (n *Nozzle[T]) warmUp() {
+	if n.options().WarmUp <= 0 {
+		return
+	}
+
+	elapsed := n.since(n.created)
+	if elapsed >= n.options().WarmUp {
+		return
+	}
+
+	floor := n.warmUpFlowRate()
+	limit := floor + int64(float64(100-floor)*float64(elapsed)/float64(n.options().WarmUp))
+
+	n.engine.Cap(limit, fmt.Sprintf("warming up, capped at %d%%", limit))
+}
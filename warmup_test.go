@@ -0,0 +1,74 @@
+package nozzle //nolint:testpackage // meant to NOT be a blackbox test
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWarmUp(t *testing.T) {
+	t.Parallel()
+
+	noz := New(Options[any]{
+		Interval:              time.Hour,
+		AllowedFailurePercent: 50,
+		WarmUp:                10 * time.Second,
+	})
+	defer noz.Close() //nolint:errcheck
+
+	if fr := noz.FlowRate(); fr != DefaultWarmUpFlowRate {
+		t.Errorf("Expected FlowRate=%d Got=%d", DefaultWarmUpFlowRate, fr)
+	}
+
+	noz.mut.Lock()
+	noz.created = time.Now().Add(-5 * time.Second)
+	noz.mut.Unlock()
+
+	// Halfway through the warm-up, successes may only open the Nozzle to 55.
+	for range 10 {
+		noz.mut.Lock()
+		noz.start = time.Time{}
+		noz.engine.Record(100, 0)
+		noz.mut.Unlock()
+
+		noz.calculate()
+	}
+
+	if fr := noz.FlowRate(); fr != 55 {
+		t.Errorf("Expected FlowRate=55 Got=%d", fr)
+	}
+
+	if r := noz.Reason(); !strings.Contains(r, "warming up, capped at 55%") {
+		t.Errorf("Expected Reason to mention the warm-up Got=%q", r)
+	}
+
+	// Failures still close the Nozzle during the warm-up.
+	noz.mut.Lock()
+	noz.start = time.Time{}
+	noz.engine.Record(0, 100)
+	noz.mut.Unlock()
+
+	noz.calculate()
+
+	if s := noz.State(); s != Closing {
+		t.Errorf("Expected State=%s Got=%s", Closing, s)
+	}
+
+	// Once the warm-up is over, the Nozzle opens fully.
+	noz.mut.Lock()
+	noz.created = time.Now().Add(-time.Minute)
+	noz.mut.Unlock()
+
+	for range 10 {
+		noz.mut.Lock()
+		noz.start = time.Time{}
+		noz.engine.Record(100, 0)
+		noz.mut.Unlock()
+
+		noz.calculate()
+	}
+
+	if fr := noz.FlowRate(); fr != 100 {
+		t.Errorf("Expected FlowRate=100 Got=%d", fr)
+	}
+}
@@ -0,0 +1,20 @@
+package nozzle
+
+// ExponentialWeights returns weights for Options.FailureWindowWeights that decay by factor per interval, newest first.
+// Each interval weighs factor times the one after it, so a factor below 1 lets recent intervals dominate.
+//
+// Example:
+//
+//	nozzle.ExponentialWeights(4, 0.5) // 1, 0.5, 0.25, 0.125
+func ExponentialWeights(intervals int, factor float64) []float64 {
+	weights := make([]float64, max(intervals, 0))
+
+	weight := 1.0
+
+	for i := range weights {
+		weights[i] = weight
+		weight *= factor
+	}
+
+	return weights
+}
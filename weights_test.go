@@ -0,0 +1,35 @@
+package nozzle_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/justindfuller/nozzle"
+)
+
+func TestExponentialWeights(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		intervals int
+		factor    float64
+		expected  []float64
+	}{
+		{intervals: 4, factor: 0.5, expected: []float64{1, 0.5, 0.25, 0.125}},
+		{intervals: 3, factor: 1, expected: []float64{1, 1, 1}},
+		{intervals: 1, factor: 0.1, expected: []float64{1}},
+		{intervals: 0, factor: 0.5, expected: []float64{}},
+		{intervals: -1, factor: 0.5, expected: []float64{}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("test=%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			if weights := nozzle.ExponentialWeights(test.intervals, test.factor); !slices.Equal(weights, test.expected) {
+				t.Errorf("Expected=%v Got=%v", test.expected, weights)
+			}
+		})
+	}
+}